@@ -9,8 +9,15 @@ import (
 	"go-metrics-service/cmd/common/config/flagtypes"
 	agent "go-metrics-service/internal/agent/config"
 	"go-metrics-service/internal/agent/sender/driver"
+	"go-metrics-service/internal/agent/spool"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/pkg/circuitbreaker"
+	"go-metrics-service/pkg/timeutils"
+	"math"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,21 +33,106 @@ const (
 	rsaPublicKeyFileFlag       = "crypto-key"
 	rsaPublicKeyFileEnv        = "CRYPTO_KEY"
 	rsaPublicKeyFileJSON       = "crypto_key"
+	rsaKeyIDFlag               = "crypto-key-id"
+	rsaKeyIDEnv                = "CRYPTO_KEY_ID"
+	rsaKeyIDJSON               = "crypto_key_id"
+	hashKeyIDFlag              = "key-id"
+	hashKeyIDEnv               = "KEY_ID"
+	hashKeyIDJSON              = "key_id"
 	grpcPortFlag               = "grpc-port"
 	grpcPortEnv                = "GRPC_PORT"
 	grpcPortJSON               = "grpc_port"
+	grpcAddressFlag            = "grpc-address"
+	grpcAddressEnv             = "GRPC_ADDRESS"
+	grpcAddressJSON            = "grpc_address"
+	agentIDFlag                = "agent-id"
+	agentIDEnv                 = "AGENT_ID"
+	agentIDJSON                = "agent_id"
+	labelsFlag                 = "labels"
+	labelsEnv                  = "LABELS"
+	labelsJSON                 = "labels"
+	histogramBucketsFlag       = "histogram-buckets"
+	histogramBucketsEnv        = "HISTOGRAM_BUCKETS"
+	histogramBucketsJSON       = "histogram_buckets"
+	grpcTLSFlag                = "grpc-tls"
+	grpcTLSEnv                 = "GRPC_TLS"
+	grpcTLSJSON                = "grpc_tls"
+	grpcCAFlag                 = "grpc-ca"
+	grpcCAEnv                  = "GRPC_CA"
+	grpcCAJSON                 = "grpc_ca"
+	grpcServerNameFlag         = "grpc-server-name"
+	grpcServerNameEnv          = "GRPC_SERVER_NAME"
+	grpcServerNameJSON         = "grpc_server_name"
+	spoolDirFlag               = "spool-dir"
+	spoolDirEnv                = "SPOOL_DIR"
+	spoolDirJSON               = "spool_dir"
+	spoolMaxSizeFlag           = "spool-max-size"
+	spoolMaxSizeEnv            = "SPOOL_MAX_SIZE"
+	spoolMaxSizeJSON           = "spool_max_size"
+	spoolMaxAgeFlag            = "spool-max-age"
+	spoolMaxAgeEnv             = "SPOOL_MAX_AGE"
+	spoolMaxAgeJSON            = "spool_max_age"
+	breakerThresholdFlag       = "breaker-threshold"
+	breakerThresholdEnv        = "BREAKER_THRESHOLD"
+	breakerThresholdJSON       = "breaker_threshold"
+	breakerMaxOpenFlag         = "breaker-max-open"
+	breakerMaxOpenEnv          = "BREAKER_MAX_OPEN"
+	breakerMaxOpenJSON         = "breaker_max_open"
+	destinationsFlag           = "destinations"
+	destinationsEnv            = "DESTINATIONS"
+	destinationsJSON           = "destinations"
+	sendPolicyFlag             = "send-policy"
+	sendPolicyEnv              = "SEND_POLICY"
+	sendPolicyJSON             = "send_policy"
+	disableCollectorsFlag      = "disable-collectors"
+	disableCollectorsEnv       = "DISABLE_COLLECTORS"
+	disableCollectorsJSON      = "disable_collectors"
+	collectorIntervalsFlag     = "collector-intervals"
+	collectorIntervalsEnv      = "COLLECTOR_INTERVALS"
+	collectorIntervalsJSON     = "collector_intervals"
 )
 
 const (
-	defaultSendingInterval = time.Second * 10
-	defaultPollingInterval = time.Second * 2
-	defaultRateLimit       = 2
-	defaultRSAPublicKey    = ""
-	defaultSHA256Key       = ""
+	defaultSendingInterval  = time.Second * 10
+	defaultPollingInterval  = time.Second * 2
+	defaultRateLimit        = 2
+	defaultRSAPublicKey     = ""
+	defaultSHA256Key        = ""
+	defaultSpoolMaxSize     = 64 << 20
+	defaultSpoolMaxAge      = 24 * time.Hour
+	defaultBreakerThreshold = 5
+	defaultBreakerMaxOpen   = 2 * time.Minute
+	defaultSendPolicy       = string(driver.PolicyAll)
+	grpcScheme              = "grpc://"
+	httpScheme              = "http://"
 )
 
 var defaultGRPCPort *uint16 = nil
-var defaultRetryAttempts = []time.Duration{time.Second, 3 * time.Second, 5 * time.Second}
+var defaultRetryBackoff = timeutils.Backoff{
+	Initial:    time.Second,
+	Max:        10 * time.Second,
+	Multiplier: 3,
+	Jitter:     0.2,
+	Attempts:   3,
+}
+
+// defaultGRPCReconnectBackoff reopens updates stream broken before any ack three times before
+// failing pending batches, which are then kept by sender.
+var defaultGRPCReconnectBackoff = timeutils.Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        5 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+	Attempts:   3,
+}
+
+// defaultBreakerOpenBackoff keeps breaker open for 5s after it trips, doubling on every failed probe.
+var defaultBreakerOpenBackoff = timeutils.Backoff{
+	Initial:    5 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+var defaultHistogramBuckets = []float64{1e-5, 5e-5, 1e-4, 5e-4, 1e-3, 5e-3, 1e-2, 5e-2, 0.1, 0.5, 1}
 
 type Config struct {
 	Agent      agent.Config
@@ -53,9 +145,32 @@ func Load() (Config, error) {
 	sendingInterval := defaultSendingInterval
 	pollingInterval := defaultPollingInterval
 	rsaPublicKeyFilePath := defaultRSAPublicKey
+	rsaKeyID := ""
 	sha256Key := defaultSHA256Key
+	hashKeyID := ""
 	rateLimit := defaultRateLimit
 	grpcPort := defaultGRPCPort
+	grpcAddress := ""
+	var labels map[string]string = nil
+	histogramBuckets := defaultHistogramBuckets
+	grpcTLS := false
+	grpcCAPath := ""
+	grpcTLSCertPath := ""
+	grpcTLSKeyPath := ""
+	grpcServerName := ""
+	spoolDir := ""
+	spoolMaxSize := int64(defaultSpoolMaxSize)
+	spoolMaxAge := defaultSpoolMaxAge
+	breakerThreshold := defaultBreakerThreshold
+	breakerMaxOpen := defaultBreakerMaxOpen
+	destinationAddresses := make([]string, 0)
+	sendPolicy := defaultSendPolicy
+	disabledCollectors := make([]string, 0)
+	collectorIntervals := make(map[string]time.Duration)
+	agentID, err := os.Hostname()
+	if err != nil {
+		agentID = ""
+	}
 
 	// Flags Definition.
 
@@ -77,11 +192,87 @@ func Load() (Config, error) {
 	sha256KeyFlagVal := flagtypes.NewString()
 	flag.Var(sha256KeyFlagVal, common.SHA256KeyFlag, "SHA256 key")
 
+	hashKeyIDFlagVal := flagtypes.NewString()
+	flag.Var(hashKeyIDFlagVal, hashKeyIDFlag, "ID of SHA256 key on server")
+
 	rsaPublicKeyFilePathFlagVal := flagtypes.NewString()
-	flag.Var(rsaPublicKeyFilePathFlagVal, rsaPublicKeyFileFlag, "RSA public key file path")
+	flag.Var(
+		rsaPublicKeyFilePathFlagVal,
+		rsaPublicKeyFileFlag,
+		"RSA public key file path, HTTP only, GRPC requires TLS with it",
+	)
+
+	rsaKeyIDFlagVal := flagtypes.NewString()
+	flag.Var(rsaKeyIDFlagVal, rsaKeyIDFlag, "ID of server RSA key matching public key")
 
 	grpcPortFlagVal := flagtypes.NewString()
-	flag.Var(grpcPortFlagVal, grpcPortFlag, "Server GRPC port")
+	flag.Var(grpcPortFlagVal, grpcPortFlag, "Server GRPC port on localhost, overridden by GRPC address")
+
+	grpcAddressFlagVal := flagtypes.NewString()
+	flag.Var(
+		grpcAddressFlagVal,
+		grpcAddressFlag,
+		"Server GRPC target host:port, every address host resolves to is used in round robin",
+	)
+
+	agentIDFlagVal := flagtypes.NewString()
+	flag.Var(agentIDFlagVal, agentIDFlag, "Agent instance ID, hostname by default")
+
+	labelsFlagVal := flagtypes.NewString()
+	flag.Var(labelsFlagVal, labelsFlag, "Metric labels as name=value pairs separated by commas")
+
+	histogramBucketsFlagVal := flagtypes.NewString()
+	flag.Var(histogramBucketsFlagVal, histogramBucketsFlag, "Histogram bucket upper bounds separated by commas")
+
+	grpcTLSFlagVal := flagtypes.NewBool()
+	flag.Var(grpcTLSFlagVal, grpcTLSFlag, "Use TLS for GRPC, enabled by CA or client certificate too")
+
+	grpcCAFlagVal := flagtypes.NewString()
+	flag.Var(grpcCAFlagVal, grpcCAFlag, "CA file path to verify GRPC server certificate, system roots by default")
+
+	grpcTLSCertFlagVal := flagtypes.NewString()
+	flag.Var(grpcTLSCertFlagVal, common.GRPCTLSCertFlag, "GRPC client certificate file path")
+
+	grpcTLSKeyFlagVal := flagtypes.NewString()
+	flag.Var(grpcTLSKeyFlagVal, common.GRPCTLSKeyFlag, "GRPC client private key file path")
+
+	grpcServerNameFlagVal := flagtypes.NewString()
+	flag.Var(grpcServerNameFlagVal, grpcServerNameFlag, "Expected GRPC server certificate name, server address host by default")
+
+	spoolDirFlagVal := flagtypes.NewString()
+	flag.Var(spoolDirFlagVal, spoolDirFlag, "Directory keeping unsent batches across restarts, disabled when empty")
+
+	spoolMaxSizeFlagVal := flagtypes.NewInt()
+	flag.Var(spoolMaxSizeFlagVal, spoolMaxSizeFlag, "Size limit of unsent batches in bytes, in spool or in memory, oldest batches are evicted, 0 disables")
+
+	spoolMaxAgeFlagVal := flagtypes.NewInt()
+	flag.Var(spoolMaxAgeFlagVal, spoolMaxAgeFlag, "Seconds unsent batch is kept, in spool or in memory, 0 disables")
+
+	breakerThresholdFlagVal := flagtypes.NewInt()
+	flag.Var(breakerThresholdFlagVal, breakerThresholdFlag, "Consecutive sending failures opening circuit breaker")
+
+	breakerMaxOpenFlagVal := flagtypes.NewInt()
+	flag.Var(breakerMaxOpenFlagVal, breakerMaxOpenFlag, "Longest seconds circuit breaker stays open before probing server")
+
+	destinationsFlagVal := flagtypes.NewString()
+	flag.Var(
+		destinationsFlagVal,
+		destinationsFlag,
+		"Additional servers separated by commas, grpc://host:port or http://host:port, HTTP when scheme is omitted",
+	)
+
+	sendPolicyFlagVal := flagtypes.NewString()
+	flag.Var(sendPolicyFlagVal, sendPolicyFlag, "Sending to several servers succeeds for: all, any, failover")
+
+	disableCollectorsFlagVal := flagtypes.NewString()
+	flag.Var(disableCollectorsFlagVal, disableCollectorsFlag, "Names of collectors not to run separated by commas")
+
+	collectorIntervalsFlagVal := flagtypes.NewString()
+	flag.Var(
+		collectorIntervalsFlagVal,
+		collectorIntervalsFlag,
+		"Collectors polling frequency in seconds as name=seconds pairs separated by commas",
+	)
 
 	flag.Parse()
 
@@ -120,13 +311,103 @@ func Load() (Config, error) {
 		if val, ok := rawJSON[rsaPublicKeyFileJSON]; ok {
 			rsaPublicKeyFilePath = val.(string)
 		}
+		if val, ok := rawJSON[rsaKeyIDJSON]; ok {
+			rsaKeyID = val.(string)
+		}
+		if val, ok := rawJSON[hashKeyIDJSON]; ok {
+			hashKeyID = val.(string)
+		}
 		if val, ok := rawJSON[grpcPortJSON]; ok {
-			i, ok := val.(uint16)
-			if !ok {
-				return Config{}, fmt.Errorf("invalid value for grpc port: %s", val)
+			f, ok := val.(float64)
+			if !ok || f < 0 || f > math.MaxUint16 {
+				return Config{}, fmt.Errorf("invalid value for grpc port: %v", val)
 			}
+			i := uint16(f)
 			grpcPort = &i
 		}
+		if val, ok := rawJSON[grpcAddressJSON]; ok {
+			grpcAddress = val.(string)
+		}
+		if val, ok := rawJSON[grpcTLSJSON]; ok {
+			grpcTLS = val.(bool)
+		}
+		if val, ok := rawJSON[grpcCAJSON]; ok {
+			grpcCAPath = val.(string)
+		}
+		if val, ok := rawJSON[common.GRPCTLSCertJSON]; ok {
+			grpcTLSCertPath = val.(string)
+		}
+		if val, ok := rawJSON[common.GRPCTLSKeyJSON]; ok {
+			grpcTLSKeyPath = val.(string)
+		}
+		if val, ok := rawJSON[grpcServerNameJSON]; ok {
+			grpcServerName = val.(string)
+		}
+		if val, ok := rawJSON[spoolDirJSON]; ok {
+			spoolDir = val.(string)
+		}
+		if val, ok := rawJSON[spoolMaxSizeJSON]; ok {
+			f, ok := val.(float64)
+			if !ok {
+				return Config{}, fmt.Errorf("invalid value for spool max size: %v", val)
+			}
+			spoolMaxSize = int64(f)
+		}
+		if val, ok := rawJSON[spoolMaxAgeJSON]; ok {
+			spoolMaxAge, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for spool max age: %w", err)
+			}
+		}
+		if val, ok := rawJSON[breakerThresholdJSON]; ok {
+			f, ok := val.(float64)
+			if !ok {
+				return Config{}, fmt.Errorf("invalid value for breaker threshold: %v", val)
+			}
+			breakerThreshold = int(f)
+		}
+		if val, ok := rawJSON[breakerMaxOpenJSON]; ok {
+			breakerMaxOpen, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for breaker max open: %w", err)
+			}
+		}
+		if val, ok := rawJSON[destinationsJSON]; ok {
+			destinationAddresses, err = parseJSONStrings(val, destinationsJSON)
+			if err != nil {
+				return Config{}, err
+			}
+		}
+		if val, ok := rawJSON[sendPolicyJSON]; ok {
+			sendPolicy = val.(string)
+		}
+		if val, ok := rawJSON[disableCollectorsJSON]; ok {
+			disabledCollectors, err = parseJSONStrings(val, disableCollectorsJSON)
+			if err != nil {
+				return Config{}, err
+			}
+		}
+		if val, ok := rawJSON[collectorIntervalsJSON]; ok {
+			collectorIntervals, err = parseJSONCollectorIntervals(val)
+			if err != nil {
+				return Config{}, err
+			}
+		}
+		if val, ok := rawJSON[agentIDJSON]; ok {
+			agentID = val.(string)
+		}
+		if val, ok := rawJSON[labelsJSON]; ok {
+			labels, err = parseJSONLabels(val)
+			if err != nil {
+				return Config{}, err
+			}
+		}
+		if val, ok := rawJSON[histogramBucketsJSON]; ok {
+			histogramBuckets, err = parseJSONBuckets(val)
+			if err != nil {
+				return Config{}, err
+			}
+		}
 	}
 
 	// Flags Parse.
@@ -151,10 +432,18 @@ func Load() (Config, error) {
 		sha256Key = val
 	}
 
+	if val, ok := hashKeyIDFlagVal.Value(); ok {
+		hashKeyID = val
+	}
+
 	if val, ok := rsaPublicKeyFilePathFlagVal.Value(); ok {
 		rsaPublicKeyFilePath = val
 	}
 
+	if val, ok := rsaKeyIDFlagVal.Value(); ok {
+		rsaKeyID = val
+	}
+
 	if val, ok := grpcPortFlagVal.Value(); ok {
 		rawPort, err := strconv.Atoi(val)
 		if err != nil {
@@ -164,6 +453,87 @@ func Load() (Config, error) {
 		grpcPort = &port
 	}
 
+	if val, ok := grpcAddressFlagVal.Value(); ok {
+		grpcAddress = val
+	}
+
+	if val, ok := grpcTLSFlagVal.Value(); ok {
+		grpcTLS = val
+	}
+
+	if val, ok := grpcCAFlagVal.Value(); ok {
+		grpcCAPath = val
+	}
+
+	if val, ok := grpcTLSCertFlagVal.Value(); ok {
+		grpcTLSCertPath = val
+	}
+
+	if val, ok := grpcTLSKeyFlagVal.Value(); ok {
+		grpcTLSKeyPath = val
+	}
+
+	if val, ok := grpcServerNameFlagVal.Value(); ok {
+		grpcServerName = val
+	}
+
+	if val, ok := spoolDirFlagVal.Value(); ok {
+		spoolDir = val
+	}
+
+	if val, ok := spoolMaxSizeFlagVal.Value(); ok {
+		spoolMaxSize = int64(val)
+	}
+
+	if val, ok := spoolMaxAgeFlagVal.Value(); ok {
+		spoolMaxAge = time.Duration(val) * time.Second
+	}
+
+	if val, ok := breakerThresholdFlagVal.Value(); ok {
+		breakerThreshold = val
+	}
+
+	if val, ok := breakerMaxOpenFlagVal.Value(); ok {
+		breakerMaxOpen = time.Duration(val) * time.Second
+	}
+
+	if val, ok := destinationsFlagVal.Value(); ok {
+		destinationAddresses = parseList(val)
+	}
+
+	if val, ok := sendPolicyFlagVal.Value(); ok {
+		sendPolicy = val
+	}
+
+	if val, ok := disableCollectorsFlagVal.Value(); ok {
+		disabledCollectors = parseList(val)
+	}
+
+	if val, ok := collectorIntervalsFlagVal.Value(); ok {
+		collectorIntervals, err = parseCollectorIntervals(val)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	if val, ok := agentIDFlagVal.Value(); ok {
+		agentID = val
+	}
+
+	if val, ok := labelsFlagVal.Value(); ok {
+		labels, err = parseLabels(val)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	if val, ok := histogramBucketsFlagVal.Value(); ok {
+		histogramBuckets, err = parseBuckets(val)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
 	// Environment Variables.
 
 	if valStr, ok := os.LookupEnv(common.ServerAddressEnv); ok {
@@ -198,10 +568,18 @@ func Load() (Config, error) {
 		sha256Key = valStr
 	}
 
+	if valStr, ok := os.LookupEnv(hashKeyIDEnv); ok {
+		hashKeyID = valStr
+	}
+
 	if valStr, ok := os.LookupEnv(rsaPublicKeyFileEnv); ok {
 		rsaPublicKeyFilePath = valStr
 	}
 
+	if valStr, ok := os.LookupEnv(rsaKeyIDEnv); ok {
+		rsaKeyID = valStr
+	}
+
 	if valStr, ok := os.LookupEnv(grpcPortEnv); ok {
 		rawPort, err := strconv.Atoi(valStr)
 		if err != nil {
@@ -211,8 +589,127 @@ func Load() (Config, error) {
 		grpcPort = &port
 	}
 
+	if valStr, ok := os.LookupEnv(grpcAddressEnv); ok {
+		grpcAddress = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(grpcTLSEnv); ok {
+		val, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, grpcTLSEnv)
+		}
+		grpcTLS = val
+	}
+
+	if valStr, ok := os.LookupEnv(grpcCAEnv); ok {
+		grpcCAPath = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(common.GRPCTLSCertEnv); ok {
+		grpcTLSCertPath = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(common.GRPCTLSKeyEnv); ok {
+		grpcTLSKeyPath = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(grpcServerNameEnv); ok {
+		grpcServerName = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(spoolDirEnv); ok {
+		spoolDir = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(spoolMaxSizeEnv); ok {
+		val, err := strconv.ParseInt(valStr, 10, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, spoolMaxSizeEnv)
+		}
+		spoolMaxSize = val
+	}
+
+	if valStr, ok := os.LookupEnv(spoolMaxAgeEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, spoolMaxAgeEnv)
+		}
+		spoolMaxAge = time.Duration(val) * time.Second
+	}
+
+	if valStr, ok := os.LookupEnv(breakerThresholdEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, breakerThresholdEnv)
+		}
+		breakerThreshold = val
+	}
+
+	if valStr, ok := os.LookupEnv(breakerMaxOpenEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, breakerMaxOpenEnv)
+		}
+		breakerMaxOpen = time.Duration(val) * time.Second
+	}
+
+	if valStr, ok := os.LookupEnv(destinationsEnv); ok {
+		destinationAddresses = parseList(valStr)
+	}
+
+	if valStr, ok := os.LookupEnv(sendPolicyEnv); ok {
+		sendPolicy = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(disableCollectorsEnv); ok {
+		disabledCollectors = parseList(valStr)
+	}
+
+	if valStr, ok := os.LookupEnv(collectorIntervalsEnv); ok {
+		collectorIntervals, err = parseCollectorIntervals(valStr)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	if valStr, ok := os.LookupEnv(agentIDEnv); ok {
+		agentID = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(labelsEnv); ok {
+		labels, err = parseLabels(valStr)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	if valStr, ok := os.LookupEnv(histogramBucketsEnv); ok {
+		histogramBuckets, err = parseBuckets(valStr)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
 	// Validation.
 
+	if err := protocol.ValidateLabels(labels); err != nil {
+		return Config{}, fmt.Errorf("invalid value for labels: %w", err)
+	}
+
+	bucketsCheck := protocol.NewHistogramValue(histogramBuckets)
+	if err := bucketsCheck.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid value for histogram buckets: %w", err)
+	}
+
+	if hashKeyID != "" && sha256Key == "" {
+		return Config{}, errors.New("key id requires key")
+	}
+
+	// Server accepts agent key only from agent with the same ID.
+	if hashKeyID != "" && hashKeyID != agentID {
+		return Config{}, errors.New("key id must match agent id")
+	}
+
 	if sendingInterval < time.Duration(0) {
 		return Config{}, errors.New("sending frequency must be greater than zero")
 	}
@@ -221,6 +718,27 @@ func Load() (Config, error) {
 		return Config{}, errors.New("polling frequency must be greater than zero")
 	}
 
+	if spoolMaxSize < 0 || spoolMaxAge < time.Duration(0) {
+		return Config{}, errors.New("spool limits must not be negative")
+	}
+
+	policy, err := driver.ParsePolicy(sendPolicy)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid value for send policy: %w", err)
+	}
+
+	if breakerThreshold <= 0 {
+		return Config{}, errors.New("breaker threshold must be greater than zero")
+	}
+
+	if breakerMaxOpen <= time.Duration(0) {
+		return Config{}, errors.New("breaker max open must be greater than zero")
+	}
+
+	if (grpcTLSCertPath == "") != (grpcTLSKeyPath == "") {
+		return Config{}, errors.New("grpc tls certificate and key must be set together")
+	}
+
 	// RSA pem file reading.
 
 	var rsaPublicKeyPem []byte = nil
@@ -237,23 +755,271 @@ func Load() (Config, error) {
 
 	var grpcConfig *driver.GRPCConfig = nil
 
-	if grpcPort != nil {
-		grpcConfig = &driver.GRPCConfig{
-			Port: *grpcPort,
+	if grpcAddress == "" && grpcPort != nil {
+		grpcAddress = fmt.Sprintf("localhost:%d", *grpcPort)
+	}
+
+	grpcTLSFiles := grpcTLSPaths{
+		enabled: grpcTLS || grpcCAPath != "" || grpcTLSCertPath != "",
+		ca:      grpcCAPath,
+		cert:    grpcTLSCertPath,
+		key:     grpcTLSKeyPath,
+	}
+
+	if grpcAddress != "" {
+		grpcConfig, err = newGRPCConfig(grpcAddress, grpcServerName, grpcTLSFiles)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	// Destinations Config.
+
+	destinations := make([]agent.Destination, 0, len(destinationAddresses))
+
+	for _, raw := range destinationAddresses {
+		if address, ok := strings.CutPrefix(raw, grpcScheme); ok {
+			// Server name set for primary server does not fit other hosts.
+			cfg, err := newGRPCConfig(address, "", grpcTLSFiles)
+			if err != nil {
+				return Config{}, err
+			}
+			destinations = append(destinations, agent.Destination{GRPC: cfg})
+			continue
 		}
+		destinations = append(destinations, agent.Destination{ServerAddress: strings.TrimPrefix(raw, httpScheme)})
+	}
+
+	// Payload encryption covers HTTP only, metrics sent over gRPC are kept confidential by TLS.
+	grpcUsed := grpcConfig != nil
+	for _, destination := range destinations {
+		grpcUsed = grpcUsed || destination.GRPC != nil
+	}
+	if rsaPublicKeyPem != nil && grpcUsed && !grpcTLSFiles.enabled {
+		return Config{}, errors.New("crypto key requires grpc tls, grpc payload is not encrypted")
+	}
+
+	// Spool Config.
+
+	spoolConfig := spool.Config{
+		Dir:      spoolDir,
+		MaxBytes: spoolMaxSize,
+		MaxAge:   spoolMaxAge,
 	}
 
+	breakerOpenBackoff := defaultBreakerOpenBackoff
+	breakerOpenBackoff.Max = breakerMaxOpen
+
 	return Config{
 		Agent: agent.Config{
+			AgentID:         agentID,
 			ServerAddress:   serverAddress,
 			SHA256Key:       sha256Key,
+			SHA256KeyID:     hashKeyID,
 			SendingInterval: sendingInterval,
 			PollingInterval: pollingInterval,
-			RetryAttempts:   defaultRetryAttempts,
-			RateLimit:       rateLimit,
-			RSAPublicKeyPem: rsaPublicKeyPem,
-			GRPC:            grpcConfig,
+			RetryBackoff:    defaultRetryBackoff,
+			Breaker: circuitbreaker.Config{
+				OpenBackoff:      breakerOpenBackoff,
+				FailureThreshold: breakerThreshold,
+			},
+			RateLimit:          rateLimit,
+			RSAPublicKeyPem:    rsaPublicKeyPem,
+			RSAKeyID:           rsaKeyID,
+			GRPC:               grpcConfig,
+			Labels:             labels,
+			HistogramBuckets:   histogramBuckets,
+			Spool:              spoolConfig,
+			Destinations:       destinations,
+			SendPolicy:         policy,
+			DisabledCollectors: disabledCollectors,
+			CollectorIntervals: collectorIntervals,
 		},
 		Production: false,
 	}, nil
 }
+
+// grpcTLSPaths are TLS settings shared by every gRPC server.
+type grpcTLSPaths struct {
+	ca      string
+	cert    string
+	key     string
+	enabled bool
+}
+
+// newGRPCConfig returns config of gRPC server, server name defaults to target host.
+func newGRPCConfig(address, serverName string, paths grpcTLSPaths) (*driver.GRPCConfig, error) {
+	cfg := &driver.GRPCConfig{
+		Address:          address,
+		TLS:              paths.enabled,
+		ReconnectBackoff: defaultGRPCReconnectBackoff,
+	}
+	if !cfg.TLS {
+		return cfg, nil
+	}
+	var err error
+	if cfg.CAPem, err = common.ReadOptionalFile(paths.ca); err != nil {
+		return nil, err
+	}
+	if cfg.CertPem, err = common.ReadOptionalFile(paths.cert); err != nil {
+		return nil, err
+	}
+	if cfg.KeyPem, err = common.ReadOptionalFile(paths.key); err != nil {
+		return nil, err
+	}
+	cfg.ServerName = serverName
+	if cfg.ServerName == "" {
+		cfg.ServerName = targetHost(address)
+	}
+	return cfg, nil
+}
+
+// parseList parses comma separated list skipping empty items.
+func parseList(raw string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseJSONStrings parses JSON array of strings of option with given name.
+func parseJSONStrings(raw any, name string) ([]string, error) {
+	arr, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %s: array expected", name)
+	}
+	items := make([]string, len(arr))
+	for i, val := range arr {
+		item, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %s[%d]: string expected", name, i)
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// parseCollectorIntervals parses "name=seconds,name2=seconds2" list.
+func parseCollectorIntervals(raw string) (map[string]time.Duration, error) {
+	intervals := make(map[string]time.Duration)
+	for _, pair := range parseList(raw) {
+		name, rawSeconds, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid value for collector intervals: '%s' is not name=seconds pair", pair)
+		}
+		seconds, err := strconv.Atoi(strings.TrimSpace(rawSeconds))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for collector intervals: %w", err)
+		}
+		intervals[strings.TrimSpace(name)] = time.Duration(seconds) * time.Second
+	}
+	return intervals, nil
+}
+
+// parseJSONCollectorIntervals parses JSON object of duration strings.
+func parseJSONCollectorIntervals(raw any) (map[string]time.Duration, error) {
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %s: object expected", collectorIntervalsJSON)
+	}
+	intervals := make(map[string]time.Duration, len(obj))
+	for name, val := range obj {
+		rawInterval, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %s.%s: string expected", collectorIntervalsJSON, name)
+		}
+		interval, err := time.ParseDuration(rawInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s.%s: %w", collectorIntervalsJSON, name, err)
+		}
+		intervals[name] = interval
+	}
+	return intervals, nil
+}
+
+// targetHost returns host part of gRPC target, like "dns:///host:port" or "host:port".
+func targetHost(target string) string {
+	if _, rest, ok := strings.Cut(target, ":///"); ok {
+		target = rest
+	}
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		host = target
+	}
+	if host == "" {
+		return "localhost"
+	}
+	return host
+}
+
+// parseLabels parses "name=value,name2=value2" list.
+func parseLabels(raw string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid value for labels: '%s' is not name=value pair", pair)
+		}
+		labels[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+// parseJSONLabels parses JSON object of string values.
+func parseJSONLabels(raw any) (map[string]string, error) {
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %s: object expected", labelsJSON)
+	}
+	labels := make(map[string]string, len(obj))
+	for name, val := range obj {
+		value, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %s.%s: string expected", labelsJSON, name)
+		}
+		labels[name] = value
+	}
+	return labels, nil
+}
+
+// parseBuckets parses comma separated list of bucket bounds.
+func parseBuckets(raw string) ([]float64, error) {
+	buckets := make([]float64, 0)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		bound, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for histogram buckets: %w", err)
+		}
+		buckets = append(buckets, bound)
+	}
+	return buckets, nil
+}
+
+// parseJSONBuckets parses JSON array of numbers.
+func parseJSONBuckets(raw any) ([]float64, error) {
+	arr, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %s: array expected", histogramBucketsJSON)
+	}
+	buckets := make([]float64, len(arr))
+	for i, val := range arr {
+		bound, ok := val.(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %s[%d]: number expected", histogramBucketsJSON, i)
+		}
+		buckets[i] = bound
+	}
+	return buckets, nil
+}
@@ -15,6 +15,12 @@ const (
 	SHA256KeyEnv      = "KEY"
 	ConfigFlag        = "c"
 	ConfigEnv         = "CONFIG"
+	GRPCTLSCertFlag   = "grpc-tls-cert"
+	GRPCTLSCertEnv    = "GRPC_TLS_CERT"
+	GRPCTLSCertJSON   = "grpc_tls_cert"
+	GRPCTLSKeyFlag    = "grpc-tls-key"
+	GRPCTLSKeyEnv     = "GRPC_TLS_KEY"
+	GRPCTLSKeyJSON    = "grpc_tls_key"
 )
 
 const (
@@ -33,3 +39,15 @@ func GetRawJSON(path string) (map[string]any, error) {
 	}
 	return rawJSON, nil
 }
+
+// ReadOptionalFile reads file content, nil is returned for empty path.
+func ReadOptionalFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file '%s': %w", path, err)
+	}
+	return content, nil
+}
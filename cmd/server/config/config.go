@@ -7,11 +7,17 @@ import (
 	"fmt"
 	common "go-metrics-service/cmd/common/config"
 	"go-metrics-service/cmd/common/config/flagtypes"
+	"go-metrics-service/internal/common/hashing"
 	"go-metrics-service/internal/server"
+	"go-metrics-service/internal/server/alerting"
+	"go-metrics-service/internal/server/alerting/webhook"
+	"go-metrics-service/internal/server/data"
 	"go-metrics-service/internal/server/data/storages/backupmemstorage"
 	"go-metrics-service/internal/server/database"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -31,9 +37,62 @@ const (
 	rsaPrivateKeyFileFlag  = "crypto-key"
 	rsaPrivateKeyFileEnv   = "CRYPTO_KEY"
 	rsaPrivateKeyFileJSON  = "crypto_key"
+	rsaPrivateKeysFlag     = "crypto-keys"
+	rsaPrivateKeysEnv      = "CRYPTO_KEYS"
+	rsaPrivateKeysJSON     = "crypto_keys"
+	rsaPrivateKeysDirFlag  = "crypto-keys-dir"
+	rsaPrivateKeysDirEnv   = "CRYPTO_KEYS_DIR"
+	rsaPrivateKeysDirJSON  = "crypto_keys_dir"
+	hashKeysFlag           = "hash-keys"
+	hashKeysEnv            = "HASH_KEYS"
+	hashKeysJSON           = "hash_keys"
+	hashReplayWindowFlag   = "hash-replay-window"
+	hashReplayWindowEnv    = "HASH_REPLAY_WINDOW"
+	hashReplayWindowJSON   = "hash_replay_window"
+	nonceCacheSizeFlag     = "nonce-cache-size"
+	nonceCacheSizeEnv      = "NONCE_CACHE_SIZE"
+	nonceCacheSizeJSON     = "nonce_cache_size"
+	watchBufferSizeFlag    = "watch-buffer-size"
+	watchBufferSizeEnv     = "WATCH_BUFFER_SIZE"
+	watchBufferSizeJSON    = "watch_buffer_size"
+	idempotencyTTLFlag     = "idempotency-ttl"
+	idempotencyTTLEnv      = "IDEMPOTENCY_TTL"
+	idempotencyTTLJSON     = "idempotency_ttl"
 	trustedSubnetFlag      = "t"
 	trustedSubnetEnv       = "TRUSTED_SUBNET"
 	trustedSubnetJSON      = "trusted_subnet"
+	historyFlag            = "history"
+	historyEnv             = "HISTORY"
+	historyJSON            = "history"
+	instanceLabelFlag      = "instance-label"
+	instanceLabelEnv       = "INSTANCE_LABEL"
+	instanceLabelJSON      = "instance_label"
+	historyRetentionFlag   = "history-retention"
+	historyRetentionEnv    = "HISTORY_RETENTION"
+	historyRetentionJSON   = "history_retention"
+	alertRulesJSON         = "alert_rules"
+	alertIntervalFlag      = "alert-interval"
+	alertIntervalEnv       = "ALERT_INTERVAL"
+	alertIntervalJSON      = "alert_interval"
+	alertWebhooksJSON      = "alert_webhooks"
+	staleAfterFlag         = "stale-after"
+	staleAfterEnv          = "STALE_AFTER"
+	staleAfterJSON         = "stale_after"
+	purgeAfterFlag         = "purge-after"
+	purgeAfterEnv          = "PURGE_AFTER"
+	purgeAfterJSON         = "purge_after"
+	batchRetentionFlag     = "batch-retention"
+	batchRetentionEnv      = "BATCH_RETENTION"
+	batchRetentionJSON     = "batch_retention"
+	auditFileFlag          = "audit-file"
+	auditFileEnv           = "AUDIT_FILE"
+	auditFileJSON          = "audit_file"
+	grpcClientCAFlag       = "grpc-client-ca"
+	grpcClientCAEnv        = "GRPC_CLIENT_CA"
+	grpcClientCAJSON       = "grpc_client_ca"
+	grpcAddressFlag        = "grpc-address"
+	grpcAddressEnv         = "GRPC_ADDRESS"
+	grpcAddressJSON        = "grpc_address"
 )
 
 const (
@@ -44,21 +103,54 @@ const (
 	defaultNeedRestore           = true
 	defaultDBConnectionString    = ""
 	defaultSHA256Key             = ""
+	defaultHashReplayWindow      = 5 * time.Minute
+	defaultNonceCacheSize        = 100_000
+	defaultIdempotencyTTL        = 10 * time.Minute
+	defaultIdempotencyCacheSize  = 10_000
 	defaultRSAPrivateKeyFilePath = ""
 	defaultTrustedSubnet         = ""
+	defaultHistory               = false
+	defaultInstanceLabel         = false
+	defaultHistoryRetention      = 24 * time.Hour
+	defaultAlertInterval         = 15 * time.Second
+	defaultWebhookGroupWait      = 10 * time.Second
+	defaultWebhookRepeatInterval = 4 * time.Hour
+	defaultWebhookTickInterval   = time.Second
+	defaultStaleAfter            = time.Duration(0)
+	defaultPurgeAfter            = time.Duration(0)
+	defaultBatchRetention        = 24 * time.Hour
+	defaultSweepInterval         = time.Minute
+	defaultAuditFile             = ""
+	defaultGRPCAddress           = ":3200"
+	defaultWatchBufferSize       = 1024
 )
 
 var defaultRetryAttempts = []time.Duration{time.Second, 3 * time.Second, 5 * time.Second}
 
 type Config struct {
-	SHA256Key        string
+	// HashKeys verify signed requests, shared key has empty ID.
+	HashKeys         []hashing.Key
+	History          data.HistoryConfig
+	Expiry           data.ExpiryConfig
+	Alerting         alerting.Config
+	Webhooks         webhook.Config
 	Database         database.Config
 	BackupMemStorage backupmemstorage.Config
 	Server           server.Config
 	GRPCServer       server.GRPCConfig
 	ShutdownTimeout  time.Duration
 	Production       bool
-	RSAPrivateKeyPem string
+	// RSAPrivateKeyFiles maps key ID to private key file path, key without ID has empty ID.
+	// Keys decrypt HTTP payload only, gRPC server requires TLS when they are set.
+	RSAPrivateKeyFiles map[string]string
+	// RSAPrivateKeysDir holds private key files named by key ID, it is listed again on SIGHUP.
+	RSAPrivateKeysDir string
+	// AuditFile receives audit events as JSON lines, events are only logged when empty.
+	AuditFile string
+	// WatchBufferSize is a number of updates buffered for every watching client.
+	WatchBufferSize int
+	// InstanceLabel puts ID of reporting agent into instance label of its series.
+	InstanceLabel bool
 }
 
 func Load() (Config, error) {
@@ -69,8 +161,29 @@ func Load() (Config, error) {
 	needRestore := defaultNeedRestore
 	dbConnectionString := defaultDBConnectionString
 	sha256Key := defaultSHA256Key
+	hashKeys := make([]hashing.Key, 0)
+	hashReplayWindow := defaultHashReplayWindow
+	nonceCacheSize := defaultNonceCacheSize
+	watchBufferSize := defaultWatchBufferSize
+	idempotencyTTL := defaultIdempotencyTTL
 	rsaPrivateKeyFilePath := defaultRSAPrivateKeyFilePath
+	rsaPrivateKeyFiles := make(map[string]string)
+	rsaPrivateKeysDir := ""
 	trustedSubnet := defaultTrustedSubnet
+	auditFile := defaultAuditFile
+	grpcAddress := defaultGRPCAddress
+	grpcTLSCertPath := ""
+	grpcTLSKeyPath := ""
+	grpcClientCAPath := ""
+	history := defaultHistory
+	instanceLabel := defaultInstanceLabel
+	historyRetention := defaultHistoryRetention
+	alertInterval := defaultAlertInterval
+	alertRules := make([]alerting.Rule, 0)
+	webhookReceivers := make([]webhook.ReceiverConfig, 0)
+	staleAfter := defaultStaleAfter
+	purgeAfter := defaultPurgeAfter
+	batchRetention := defaultBatchRetention
 
 	// Flags Definition.
 
@@ -95,12 +208,73 @@ func Load() (Config, error) {
 	sha256KeyFlagVal := flagtypes.NewString()
 	flag.Var(sha256KeyFlagVal, common.SHA256KeyFlag, "SHA256 key")
 
+	hashKeysFlagVal := flagtypes.NewString()
+	flag.Var(hashKeysFlagVal, hashKeysFlag, "SHA256 keys by key ID as id=key,id=key")
+
+	hashReplayWindowFlagVal := flagtypes.NewInt()
+	flag.Var(hashReplayWindowFlagVal, hashReplayWindowFlag, "Maximum age of signed request in seconds")
+
+	nonceCacheSizeFlagVal := flagtypes.NewInt()
+	flag.Var(nonceCacheSizeFlagVal, nonceCacheSizeFlag, "Maximum number of remembered signed request nonces")
+
+	watchBufferSizeFlagVal := flagtypes.NewInt()
+	flag.Var(watchBufferSizeFlagVal, watchBufferSizeFlag, "Number of updates buffered for every watching client")
+
+	idempotencyTTLFlagVal := flagtypes.NewInt()
+	flag.Var(idempotencyTTLFlagVal, idempotencyTTLFlag, "Seconds responses are kept for retried requests, 0 disables")
+
 	rsaPrivateKeyFilePathFlagVal := flagtypes.NewString()
-	flag.Var(rsaPrivateKeyFilePathFlagVal, rsaPrivateKeyFileFlag, "RSA private key file path")
+	flag.Var(
+		rsaPrivateKeyFilePathFlagVal,
+		rsaPrivateKeyFileFlag,
+		"RSA private key file path, HTTP only, requires GRPC TLS certificate",
+	)
+
+	rsaPrivateKeysFlagVal := flagtypes.NewString()
+	flag.Var(rsaPrivateKeysFlagVal, rsaPrivateKeysFlag, "RSA private key files by key ID as id=path,id=path")
+
+	rsaPrivateKeysDirFlagVal := flagtypes.NewString()
+	flag.Var(rsaPrivateKeysDirFlagVal, rsaPrivateKeysDirFlag, "Directory of RSA private key files named <key id>.pem")
 
 	trustedSubnetFlagVal := flagtypes.NewString()
 	flag.Var(trustedSubnetFlagVal, trustedSubnetFlag, "Trusted subnet CIDR")
 
+	auditFileFlagVal := flagtypes.NewString()
+	flag.Var(auditFileFlagVal, auditFileFlag, "Audit trail file path")
+
+	grpcAddressFlagVal := flagtypes.NewString()
+	flag.Var(grpcAddressFlagVal, grpcAddressFlag, "GRPC server address host:port")
+
+	grpcTLSCertFlagVal := flagtypes.NewString()
+	flag.Var(grpcTLSCertFlagVal, common.GRPCTLSCertFlag, "GRPC server TLS certificate file path, enables TLS")
+
+	grpcTLSKeyFlagVal := flagtypes.NewString()
+	flag.Var(grpcTLSKeyFlagVal, common.GRPCTLSKeyFlag, "GRPC server TLS private key file path")
+
+	grpcClientCAFlagVal := flagtypes.NewString()
+	flag.Var(grpcClientCAFlagVal, grpcClientCAFlag, "CA file path to verify GRPC client certificates, enables mutual TLS")
+
+	historyFlagVal := flagtypes.NewBool()
+	flag.Var(historyFlagVal, historyFlag, "Keep metrics history true/false")
+
+	instanceLabelFlagVal := flagtypes.NewBool()
+	flag.Var(instanceLabelFlagVal, instanceLabelFlag, "Keep series of every agent under instance label true/false")
+
+	historyRetentionFlagVal := flagtypes.NewInt()
+	flag.Var(historyRetentionFlagVal, historyRetentionFlag, "Metrics history retention in seconds, 0 keeps forever")
+
+	alertIntervalFlagVal := flagtypes.NewInt()
+	flag.Var(alertIntervalFlagVal, alertIntervalFlag, "Alerting rules evaluation interval in seconds")
+
+	staleAfterFlagVal := flagtypes.NewInt()
+	flag.Var(staleAfterFlagVal, staleAfterFlag, "Seconds without updates after which series is stale, 0 disables")
+
+	purgeAfterFlagVal := flagtypes.NewInt()
+	flag.Var(purgeAfterFlagVal, purgeAfterFlag, "Seconds without updates after which series is deleted, 0 disables")
+
+	batchRetentionFlagVal := flagtypes.NewInt()
+	flag.Var(batchRetentionFlagVal, batchRetentionFlag, "Seconds applied batch IDs are kept for deduplication")
+
 	flag.Parse()
 
 	// Config JSON.
@@ -141,9 +315,105 @@ func Load() (Config, error) {
 		if val, ok := rawJSON[rsaPrivateKeyFileJSON]; ok {
 			rsaPrivateKeyFilePath = val.(string)
 		}
+		if val, ok := rawJSON[rsaPrivateKeysJSON]; ok {
+			rsaPrivateKeyFiles, err = parseKeyFilesJSON(val)
+			if err != nil {
+				return Config{}, err
+			}
+		}
+		if val, ok := rawJSON[rsaPrivateKeysDirJSON]; ok {
+			rsaPrivateKeysDir = val.(string)
+		}
+		if val, ok := rawJSON[hashKeysJSON]; ok {
+			hashKeys, err = parseHashKeysJSON(val)
+			if err != nil {
+				return Config{}, err
+			}
+		}
+		if val, ok := rawJSON[hashReplayWindowJSON]; ok {
+			hashReplayWindow, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for hash replay window: %w", err)
+			}
+		}
+		if val, ok := rawJSON[nonceCacheSizeJSON]; ok {
+			nonceCacheSize = int(val.(float64))
+		}
+		if val, ok := rawJSON[watchBufferSizeJSON]; ok {
+			watchBufferSize = int(val.(float64))
+		}
+		if val, ok := rawJSON[idempotencyTTLJSON]; ok {
+			idempotencyTTL, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for idempotency ttl: %w", err)
+			}
+		}
 		if val, ok := rawJSON[trustedSubnetJSON]; ok {
 			trustedSubnet = val.(string)
 		}
+		if val, ok := rawJSON[auditFileJSON]; ok {
+			auditFile = val.(string)
+		}
+		if val, ok := rawJSON[grpcAddressJSON]; ok {
+			grpcAddress = val.(string)
+		}
+		if val, ok := rawJSON[common.GRPCTLSCertJSON]; ok {
+			grpcTLSCertPath = val.(string)
+		}
+		if val, ok := rawJSON[common.GRPCTLSKeyJSON]; ok {
+			grpcTLSKeyPath = val.(string)
+		}
+		if val, ok := rawJSON[grpcClientCAJSON]; ok {
+			grpcClientCAPath = val.(string)
+		}
+		if val, ok := rawJSON[historyJSON]; ok {
+			history = val.(bool)
+		}
+		if val, ok := rawJSON[instanceLabelJSON]; ok {
+			instanceLabel = val.(bool)
+		}
+		if val, ok := rawJSON[historyRetentionJSON]; ok {
+			historyRetention, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for history retention: %w", err)
+			}
+		}
+		if val, ok := rawJSON[alertIntervalJSON]; ok {
+			alertInterval, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for alert interval: %w", err)
+			}
+		}
+		if val, ok := rawJSON[staleAfterJSON]; ok {
+			staleAfter, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for stale interval: %w", err)
+			}
+		}
+		if val, ok := rawJSON[purgeAfterJSON]; ok {
+			purgeAfter, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for purge interval: %w", err)
+			}
+		}
+		if val, ok := rawJSON[batchRetentionJSON]; ok {
+			batchRetention, err = time.ParseDuration(val.(string))
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid value for batch retention: %w", err)
+			}
+		}
+		if val, ok := rawJSON[alertRulesJSON]; ok {
+			alertRules, err = parseAlertRules(val)
+			if err != nil {
+				return Config{}, err
+			}
+		}
+		if val, ok := rawJSON[alertWebhooksJSON]; ok {
+			webhookReceivers, err = parseWebhookReceivers(val)
+			if err != nil {
+				return Config{}, err
+			}
+		}
 	}
 
 	// Flags Parse.
@@ -172,14 +442,98 @@ func Load() (Config, error) {
 		sha256Key = val
 	}
 
+	if val, ok := hashKeysFlagVal.Value(); ok {
+		keys, err := parseHashKeys(val)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid value for %s flag: %w", hashKeysFlag, err)
+		}
+		hashKeys = keys
+	}
+
+	if val, ok := hashReplayWindowFlagVal.Value(); ok {
+		hashReplayWindow = time.Duration(val) * time.Second
+	}
+
+	if val, ok := nonceCacheSizeFlagVal.Value(); ok {
+		nonceCacheSize = val
+	}
+
+	if val, ok := watchBufferSizeFlagVal.Value(); ok {
+		watchBufferSize = val
+	}
+
+	if val, ok := idempotencyTTLFlagVal.Value(); ok {
+		idempotencyTTL = time.Duration(val) * time.Second
+	}
+
 	if val, ok := rsaPrivateKeyFilePathFlagVal.Value(); ok {
 		rsaPrivateKeyFilePath = val
 	}
 
+	if val, ok := rsaPrivateKeysFlagVal.Value(); ok {
+		files, err := parseKeyFiles(val)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid value for %s flag: %w", rsaPrivateKeysFlag, err)
+		}
+		rsaPrivateKeyFiles = files
+	}
+
+	if val, ok := rsaPrivateKeysDirFlagVal.Value(); ok {
+		rsaPrivateKeysDir = val
+	}
+
 	if val, ok := trustedSubnetFlagVal.Value(); ok {
 		trustedSubnet = val
 	}
 
+	if val, ok := auditFileFlagVal.Value(); ok {
+		auditFile = val
+	}
+
+	if val, ok := grpcAddressFlagVal.Value(); ok {
+		grpcAddress = val
+	}
+
+	if val, ok := grpcTLSCertFlagVal.Value(); ok {
+		grpcTLSCertPath = val
+	}
+
+	if val, ok := grpcTLSKeyFlagVal.Value(); ok {
+		grpcTLSKeyPath = val
+	}
+
+	if val, ok := grpcClientCAFlagVal.Value(); ok {
+		grpcClientCAPath = val
+	}
+
+	if val, ok := historyFlagVal.Value(); ok {
+		history = val
+	}
+
+	if val, ok := instanceLabelFlagVal.Value(); ok {
+		instanceLabel = val
+	}
+
+	if val, ok := historyRetentionFlagVal.Value(); ok {
+		historyRetention = time.Duration(val) * time.Second
+	}
+
+	if val, ok := alertIntervalFlagVal.Value(); ok {
+		alertInterval = time.Duration(val) * time.Second
+	}
+
+	if val, ok := staleAfterFlagVal.Value(); ok {
+		staleAfter = time.Duration(val) * time.Second
+	}
+
+	if val, ok := purgeAfterFlagVal.Value(); ok {
+		purgeAfter = time.Duration(val) * time.Second
+	}
+
+	if val, ok := batchRetentionFlagVal.Value(); ok {
+		batchRetention = time.Duration(val) * time.Second
+	}
+
 	// Environment Variables.
 
 	if valStr, ok := os.LookupEnv(common.ServerAddressEnv); ok {
@@ -214,30 +568,230 @@ func Load() (Config, error) {
 		sha256Key = valStr
 	}
 
+	if valStr, ok := os.LookupEnv(hashKeysEnv); ok {
+		keys, err := parseHashKeys(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, hashKeysEnv)
+		}
+		hashKeys = keys
+	}
+
+	if valStr, ok := os.LookupEnv(hashReplayWindowEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, hashReplayWindowEnv)
+		}
+		hashReplayWindow = time.Duration(val) * time.Second
+	}
+
+	if valStr, ok := os.LookupEnv(nonceCacheSizeEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, nonceCacheSizeEnv)
+		}
+		nonceCacheSize = val
+	}
+
+	if valStr, ok := os.LookupEnv(watchBufferSizeEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, watchBufferSizeEnv)
+		}
+		watchBufferSize = val
+	}
+
+	if valStr, ok := os.LookupEnv(idempotencyTTLEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, idempotencyTTLEnv)
+		}
+		idempotencyTTL = time.Duration(val) * time.Second
+	}
+
 	if valStr, ok := os.LookupEnv(rsaPrivateKeyFileEnv); ok {
 		rsaPrivateKeyFilePath = valStr
 	}
 
+	if valStr, ok := os.LookupEnv(rsaPrivateKeysEnv); ok {
+		files, err := parseKeyFiles(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, rsaPrivateKeysEnv)
+		}
+		rsaPrivateKeyFiles = files
+	}
+
+	if valStr, ok := os.LookupEnv(rsaPrivateKeysDirEnv); ok {
+		rsaPrivateKeysDir = valStr
+	}
+
 	if valStr, ok := os.LookupEnv(trustedSubnetEnv); ok {
 		trustedSubnet = valStr
 	}
 
+	if valStr, ok := os.LookupEnv(auditFileEnv); ok {
+		auditFile = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(grpcAddressEnv); ok {
+		grpcAddress = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(common.GRPCTLSCertEnv); ok {
+		grpcTLSCertPath = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(common.GRPCTLSKeyEnv); ok {
+		grpcTLSKeyPath = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(grpcClientCAEnv); ok {
+		grpcClientCAPath = valStr
+	}
+
+	if valStr, ok := os.LookupEnv(historyEnv); ok {
+		val, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, historyEnv)
+		}
+		history = val
+	}
+
+	if valStr, ok := os.LookupEnv(instanceLabelEnv); ok {
+		val, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, instanceLabelEnv)
+		}
+		instanceLabel = val
+	}
+
+	if valStr, ok := os.LookupEnv(historyRetentionEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, historyRetentionEnv)
+		}
+		historyRetention = time.Duration(val) * time.Second
+	}
+
+	if valStr, ok := os.LookupEnv(alertIntervalEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, alertIntervalEnv)
+		}
+		alertInterval = time.Duration(val) * time.Second
+	}
+
+	if valStr, ok := os.LookupEnv(staleAfterEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, staleAfterEnv)
+		}
+		staleAfter = time.Duration(val) * time.Second
+	}
+
+	if valStr, ok := os.LookupEnv(purgeAfterEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, purgeAfterEnv)
+		}
+		purgeAfter = time.Duration(val) * time.Second
+	}
+
+	if valStr, ok := os.LookupEnv(batchRetentionEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: '%s' env variable parsing failed", err, batchRetentionEnv)
+		}
+		batchRetention = time.Duration(val) * time.Second
+	}
+
 	// Validation.
 
 	if storeInterval < time.Duration(0) {
 		return Config{}, errors.New("store internal must be greater than zero")
 	}
 
-	// RSA pem file reading.
+	if historyRetention < time.Duration(0) {
+		return Config{}, errors.New("history retention must not be negative")
+	}
+
+	if alertInterval <= time.Duration(0) {
+		return Config{}, errors.New("alert interval must be greater than zero")
+	}
+
+	if staleAfter < time.Duration(0) || purgeAfter < time.Duration(0) {
+		return Config{}, errors.New("stale and purge intervals must not be negative")
+	}
+
+	if staleAfter > 0 && purgeAfter > 0 && purgeAfter < staleAfter {
+		return Config{}, errors.New("purge interval must not be shorter than stale interval")
+	}
+
+	if batchRetention <= time.Duration(0) {
+		return Config{}, errors.New("batch retention must be greater than zero")
+	}
+
+	if hashReplayWindow <= time.Duration(0) {
+		return Config{}, errors.New("hash replay window must be greater than zero")
+	}
+
+	if nonceCacheSize <= 0 {
+		return Config{}, errors.New("nonce cache size must be greater than zero")
+	}
+
+	// Watcher with no buffer would be dropped on the first update it is not waiting for.
+	if watchBufferSize <= 0 {
+		return Config{}, errors.New("watch buffer size must be greater than zero")
+	}
+
+	if idempotencyTTL < time.Duration(0) {
+		return Config{}, errors.New("idempotency ttl must not be negative")
+	}
+
+	if _, _, err := net.SplitHostPort(grpcAddress); err != nil {
+		return Config{}, fmt.Errorf("invalid value for grpc address: %w", err)
+	}
+
+	if (grpcTLSCertPath == "") != (grpcTLSKeyPath == "") {
+		return Config{}, errors.New("grpc tls certificate and key must be set together")
+	}
 
-	var rsaPrivateKeyPem []byte = nil
+	if grpcClientCAPath != "" && grpcTLSCertPath == "" {
+		return Config{}, errors.New("grpc client CA requires grpc tls certificate")
+	}
+
+	// Payload encryption covers HTTP only, metrics sent over gRPC are kept confidential by TLS.
+	hasCryptoKeys := rsaPrivateKeyFilePath != "" || len(rsaPrivateKeyFiles) > 0 || rsaPrivateKeysDir != ""
+	if hasCryptoKeys && grpcTLSCertPath == "" {
+		return Config{}, errors.New("crypto key requires grpc tls certificate, grpc payload is not encrypted")
+	}
 
+	// History is pruned by sweep only when it is kept.
+	expiryHistoryRetention := time.Duration(0)
+	if history {
+		expiryHistoryRetention = historyRetention
+	}
+
+	// Key without ID is kept for agents not sending key ID.
 	if rsaPrivateKeyFilePath != "" {
-		prv, err := os.ReadFile(rsaPrivateKeyFilePath)
-		if err != nil {
-			return Config{}, fmt.Errorf("failed to read file '%s': %w", rsaPrivateKeyFilePath, err)
-		}
-		rsaPrivateKeyPem = prv
+		rsaPrivateKeyFiles[""] = rsaPrivateKeyFilePath
+	}
+	if sha256Key != "" {
+		hashKeys = append(hashKeys, hashing.Key{Secret: sha256Key})
+	}
+
+	// GRPC TLS files reading.
+
+	grpcTLSCertPem, err := common.ReadOptionalFile(grpcTLSCertPath)
+	if err != nil {
+		return Config{}, err
+	}
+	grpcTLSKeyPem, err := common.ReadOptionalFile(grpcTLSKeyPath)
+	if err != nil {
+		return Config{}, err
+	}
+	grpcClientCAPem, err := common.ReadOptionalFile(grpcClientCAPath)
+	if err != nil {
+		return Config{}, err
 	}
 
 	return Config{
@@ -253,15 +807,200 @@ func Load() (Config, error) {
 			NeedRestore: needRestore,
 		},
 		Server: server.Config{
-			ServerAddress:   serverAddress,
-			ShutdownTimeout: defaultServerShutdownTimeout,
-			TrustedSubnet:   trustedSubnet,
+			ServerAddress:        serverAddress,
+			ShutdownTimeout:      defaultServerShutdownTimeout,
+			TrustedSubnet:        trustedSubnet,
+			HashReplayWindow:     hashReplayWindow,
+			NonceCacheSize:       nonceCacheSize,
+			IdempotencyTTL:       idempotencyTTL,
+			IdempotencyCacheSize: defaultIdempotencyCacheSize,
 		},
 		GRPCServer: server.GRPCConfig{
-			Port: 3200,
+			Address:          grpcAddress,
+			CertPem:          grpcTLSCertPem,
+			KeyPem:           grpcTLSKeyPem,
+			ClientCAPem:      grpcClientCAPem,
+			TrustedSubnet:    trustedSubnet,
+			HashReplayWindow: hashReplayWindow,
+			NonceCacheSize:   nonceCacheSize,
+		},
+		History: data.HistoryConfig{
+			Enabled:   history,
+			Retention: historyRetention,
+		},
+		Expiry: data.ExpiryConfig{
+			StaleAfter:       staleAfter,
+			PurgeAfter:       purgeAfter,
+			BatchRetention:   batchRetention,
+			HistoryRetention: expiryHistoryRetention,
+			SweepInterval:    defaultSweepInterval,
 		},
-		SHA256Key:        sha256Key,
-		ShutdownTimeout:  defaultAppShutdownTimeout,
-		RSAPrivateKeyPem: string(rsaPrivateKeyPem),
+		Alerting: alerting.Config{
+			Rules:              alertRules,
+			EvaluationInterval: alertInterval,
+		},
+		Webhooks: webhook.Config{
+			Receivers:     webhookReceivers,
+			RetryAttempts: defaultRetryAttempts,
+			TickInterval:  defaultWebhookTickInterval,
+		},
+		HashKeys:           hashKeys,
+		ShutdownTimeout:    defaultAppShutdownTimeout,
+		RSAPrivateKeyFiles: rsaPrivateKeyFiles,
+		RSAPrivateKeysDir:  rsaPrivateKeysDir,
+		AuditFile:          auditFile,
+		WatchBufferSize:    watchBufferSize,
+		InstanceLabel:      instanceLabel,
 	}, nil
 }
+
+// parseAlertRules parses JSON array of {"name": "...", "expr": "..."} objects.
+func parseAlertRules(raw any) ([]alerting.Rule, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %s: array expected", alertRulesJSON)
+	}
+	rules := make([]alerting.Rule, 0, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %s[%d]: object expected", alertRulesJSON, i)
+		}
+		expr, ok := obj["expr"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %s[%d]: expr expected", alertRulesJSON, i)
+		}
+		name, _ := obj["name"].(string)
+		rule, err := alerting.ParseRule(name, expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s[%d]: %w", alertRulesJSON, i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseWebhookReceivers parses JSON array of {"url": "...", "group_wait": "10s", "repeat_interval": "4h"} objects.
+func parseWebhookReceivers(raw any) ([]webhook.ReceiverConfig, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %s: array expected", alertWebhooksJSON)
+	}
+	receivers := make([]webhook.ReceiverConfig, 0, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %s[%d]: object expected", alertWebhooksJSON, i)
+		}
+		url, ok := obj["url"].(string)
+		if !ok || url == "" {
+			return nil, fmt.Errorf("invalid value for %s[%d]: url expected", alertWebhooksJSON, i)
+		}
+		receiver := webhook.ReceiverConfig{
+			URL:            url,
+			GroupWait:      defaultWebhookGroupWait,
+			RepeatInterval: defaultWebhookRepeatInterval,
+		}
+		if val, ok := obj["group_wait"].(string); ok {
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s[%d] group wait: %w", alertWebhooksJSON, i, err)
+			}
+			receiver.GroupWait = d
+		}
+		if val, ok := obj["repeat_interval"].(string); ok {
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s[%d] repeat interval: %w", alertWebhooksJSON, i, err)
+			}
+			receiver.RepeatInterval = d
+		}
+		receivers = append(receivers, receiver)
+	}
+	return receivers, nil
+}
+
+// parseKeyFiles parses "id=path,id=path" list of key files.
+func parseKeyFiles(raw string) (map[string]string, error) {
+	return parseKeyList(raw)
+}
+
+// parseHashKeys parses "id=key,id=key" list of SHA256 keys. Keys with expiration are set by JSON config only.
+func parseHashKeys(raw string) ([]hashing.Key, error) {
+	list, err := parseKeyList(raw)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]hashing.Key, 0, len(list))
+	for id, secret := range list {
+		keys = append(keys, hashing.Key{ID: id, Secret: secret})
+	}
+	return keys, nil
+}
+
+func parseKeyList(raw string) (map[string]string, error) {
+	list := make(map[string]string)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(item, "=")
+		if !ok || id == "" || value == "" {
+			return nil, fmt.Errorf("invalid item '%s': id=value expected", item)
+		}
+		if _, ok := list[id]; ok {
+			return nil, fmt.Errorf("duplicate key id '%s'", id)
+		}
+		list[id] = value
+	}
+	return list, nil
+}
+
+// parseKeyFilesJSON parses JSON object of {"id": "path"} key files.
+func parseKeyFilesJSON(raw any) (map[string]string, error) {
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %s: object expected", rsaPrivateKeysJSON)
+	}
+	files := make(map[string]string, len(obj))
+	for id, val := range obj {
+		path, ok := val.(string)
+		if !ok || id == "" || path == "" {
+			return nil, fmt.Errorf("invalid value for %s[%s]: key id and path expected", rsaPrivateKeysJSON, id)
+		}
+		files[id] = path
+	}
+	return files, nil
+}
+
+// parseHashKeysJSON parses JSON array of {"id": "...", "key": "...", "expires_at": "RFC3339"} objects.
+// Key with expires_at is retired and still verifies requests until then.
+func parseHashKeysJSON(raw any) ([]hashing.Key, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid value for %s: array expected", hashKeysJSON)
+	}
+	keys := make([]hashing.Key, 0, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for %s[%d]: object expected", hashKeysJSON, i)
+		}
+		id, _ := obj["id"].(string)
+		secret, ok := obj["key"].(string)
+		if id == "" || !ok || secret == "" {
+			return nil, fmt.Errorf("invalid value for %s[%d]: id and key expected", hashKeysJSON, i)
+		}
+		key := hashing.Key{ID: id, Secret: secret}
+		if val, ok := obj["expires_at"].(string); ok {
+			expiresAt, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s[%d] expires_at: %w", hashKeysJSON, i, err)
+			}
+			key.ExpiresAt = expiresAt
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
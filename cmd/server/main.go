@@ -8,6 +8,10 @@ import (
 	"go-metrics-service/internal/common/hashing"
 	"go-metrics-service/internal/common/logging"
 	"go-metrics-service/internal/server"
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/alerting"
+	"go-metrics-service/internal/server/alerting/webhook"
+	"go-metrics-service/internal/server/audit"
 	"go-metrics-service/internal/server/controllers"
 	"go-metrics-service/internal/server/data/repositories/dbrepository"
 	"go-metrics-service/internal/server/data/repositories/memrepository"
@@ -19,8 +23,12 @@ import (
 	"go-metrics-service/internal/server/handlers"
 	"go-metrics-service/internal/server/logic"
 	"go-metrics-service/internal/server/middleware"
+	"go-metrics-service/internal/server/watch"
+	"go-metrics-service/pkg/closehelpers"
 	"go-metrics-service/pkg/rsahelpers"
+	"io"
 	"log"
+	"os"
 	"os/signal"
 	"syscall"
 
@@ -61,7 +69,6 @@ func main() {
 func run(cfg *config.Config, logger *zap.Logger) error {
 	rootCtx, cancelCtx := signal.NotifyContext(
 		context.Background(),
-		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT,
@@ -96,7 +103,7 @@ func run(cfg *config.Config, logger *zap.Logger) error {
 			dbStorage.Close()
 			return nil
 		})
-		rep = dbrepository.New(dbStorage, logger)
+		rep = dbrepository.New(dbStorage, cfg.History, logger)
 		tm = dbstorage.NewTransactionsManager(dbStorage, logger)
 	case cfg.BackupMemStorage.Backup.FilePath != "":
 		backupMemStorage, err := backupmemstorage.New(cfg.BackupMemStorage, logger)
@@ -109,38 +116,154 @@ func run(cfg *config.Config, logger *zap.Logger) error {
 			defer backupMemStorage.Stop()
 			return nil
 		})
-		rep = memrepository.New(backupMemStorage, logger)
+		rep = memrepository.New(backupMemStorage, cfg.History, logger)
 		tm = storages.NewDummyTransactionsManager()
 	default:
 		memStorage := memstorage.New(logger)
-		rep = memrepository.New(memStorage, logger)
+		rep = memrepository.New(memStorage, cfg.History, logger)
 		tm = storages.NewDummyTransactionsManager()
 	}
 
-	var hashFactory middleware.HashFactory = nil
-	if cfg.SHA256Key != "" {
-		hashFactory = hashing.NewHMAC(cfg.SHA256Key)
+	var hashKeys middleware.HashKeys = nil
+	if len(cfg.HashKeys) > 0 {
+		keyring, err := hashing.NewKeyring(cfg.HashKeys)
+		if err != nil {
+			return fmt.Errorf("failed to load hash keys: %w", err)
+		}
+		hashKeys = keyring
 	}
 
 	var decoder middleware.Decoder = nil
-	if cfg.RSAPrivateKeyPem != "" {
-		d, err := rsahelpers.NewOAEPDecoder([]byte(cfg.RSAPrivateKeyPem))
+	if len(cfg.RSAPrivateKeyFiles) > 0 || cfg.RSAPrivateKeysDir != "" {
+		keyring, err := rsahelpers.NewKeyring(cfg.RSAPrivateKeyFiles, cfg.RSAPrivateKeysDir)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to load rsa keys: %w", err)
 		}
-		decoder = d
+		logger.Info("RSA keys loaded", zap.Strings("ids", keyring.IDs()))
+		g.Go(func() error {
+			defer logger.Info("RSA keys reloading stopped")
+			reloadCh := make(chan os.Signal, 1)
+			signal.Notify(reloadCh, syscall.SIGHUP)
+			defer signal.Stop(reloadCh)
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-reloadCh:
+					if err := keyring.Reload(); err != nil {
+						logger.Error("failed to reload rsa keys, previous keys are kept", zap.Error(err))
+						continue
+					}
+					logger.Info("RSA keys reloaded", zap.Strings("ids", keyring.IDs()))
+				}
+			}
+		})
+		decoder = keyring
+	}
+
+	var notifier alerting.Notifier = nil
+	if len(cfg.Webhooks.Receivers) > 0 {
+		webhookNotifier := webhook.New(cfg.Webhooks, logger)
+		g.Go(func() error {
+			defer logger.Info("Webhook notifier errors handler stopped")
+			errCh := webhookNotifier.Start()
+			for err := range errCh {
+				logger.Error("webhook notifier error", zap.Error(err))
+			}
+			return nil
+		})
+		g.Go(func() error {
+			defer logger.Info("Webhook notifier stopped")
+			<-ctx.Done()
+			webhookNotifier.Stop()
+			return nil
+		})
+		notifier = webhookNotifier
 	}
 
+	alertingEngine := alerting.NewEngine(cfg.Alerting, rep, notifier, logger)
+
+	g.Go(func() error {
+		defer logger.Info("Alerting errors handler stopped")
+		errCh := alertingEngine.Start()
+		for err := range errCh {
+			logger.Error("alerting error", zap.Error(err))
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		defer logger.Info("Alerting stopped")
+		<-ctx.Done()
+		alertingEngine.Stop()
+		return nil
+	})
+
+	expiry := logic.NewExpiry(cfg.Expiry, rep, logger)
+
+	g.Go(func() error {
+		defer logger.Info("Expiry sweeper errors handler stopped")
+		errCh := expiry.Start()
+		for err := range errCh {
+			logger.Error("expiry sweeper error", zap.Error(err))
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		defer logger.Info("Expiry sweeper stopped")
+		<-ctx.Done()
+		expiry.Stop()
+		return nil
+	})
+
+	var auditWriter io.Writer = nil
+
+	if cfg.AuditFile != "" {
+		auditFile, err := os.OpenFile(cfg.AuditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open audit file: %w", err)
+		}
+		defer closehelpers.CloseWithErrorLogging(auditFile, "audit file", logger)
+		auditWriter = auditFile
+	}
+
+	agentsRegistry := agents.New(cfg.Expiry.StaleAfter, cfg.Expiry.PurgeAfter)
 	service := logic.NewService(rep, logger)
-	controller := controllers.NewController(tm, service, logger)
+	hub := watch.New(cfg.WatchBufferSize)
+	controller := controllers.NewController(
+		controllers.Config{InstanceLabel: cfg.InstanceLabel},
+		tm,
+		service,
+		agentsRegistry,
+		audit.New(auditWriter, logger),
+		hub,
+		logger,
+	)
 	httpServer, err := server.NewHTTP(
 		cfg.Server,
 		rep,
-		hashFactory,
+		hashKeys,
 		pingables,
 		logger,
 		decoder,
 		controller,
+		alertingEngine,
+		agentsRegistry,
+		expiry,
+	)
+	if err != nil {
+		return err
+	}
+
+	grpcServer, err := server.NewGRPC(
+		cfg.GRPCServer,
+		controller,
+		alertingEngine,
+		rep,
+		hub,
+		hashKeys,
+		logger,
 	)
 	if err != nil {
 		return err
@@ -162,8 +285,6 @@ func run(cfg *config.Config, logger *zap.Logger) error {
 		return nil
 	})
 
-	grpcServer := server.NewGRPC(cfg.GRPCServer, controller)
-
 	g.Go(func() error {
 		if err := grpcServer.Run(); err != nil {
 			return fmt.Errorf("grpc server error: %w", err)
@@ -174,9 +295,8 @@ func run(cfg *config.Config, logger *zap.Logger) error {
 	g.Go(func() error {
 		defer logger.Info("Shutting down server")
 		<-ctx.Done()
-		if err := httpServer.Shutdown(); err != nil {
-			return fmt.Errorf("failed to shutdown grpc server: %w", err)
-		}
+		hub.Close()
+		grpcServer.Shutdown()
 		return nil
 	})
 
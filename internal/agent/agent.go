@@ -8,6 +8,7 @@ import (
 	pollerPkg "go-metrics-service/internal/agent/poller"
 	senderPkg "go-metrics-service/internal/agent/sender"
 	"go-metrics-service/internal/agent/sender/driver"
+	"go-metrics-service/internal/agent/spool"
 	storagePkg "go-metrics-service/internal/agent/storage"
 	"go-metrics-service/internal/common/hashing"
 	"go-metrics-service/pkg/ipdeterminer"
@@ -26,43 +27,94 @@ func Run(cfg *config.Config, logger *zap.Logger) error {
 		return fmt.Errorf("getting IP failed: %w", err)
 	}
 
+	agentID := cfg.AgentID
+	if agentID == "" {
+		agentID = ip.String()
+	}
+
 	storage := storagePkg.New()
-	poller := pollerPkg.New(storage, logger)
+	poller, err := pollerPkg.New(
+		storage,
+		pollerPkg.Config{
+			Intervals:        cfg.CollectorIntervals,
+			Disabled:         cfg.DisabledCollectors,
+			HistogramBuckets: cfg.HistogramBuckets,
+			Interval:         cfg.PollingInterval,
+		},
+		pollerPkg.NewDefaultRegistry(cfg.HistogramBuckets),
+		logger,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to configure poller: %w", err)
+	}
 
 	var hashFactory driver.HashFactory = nil
 	if cfg.SHA256Key != "" {
-		hashFactory = hashing.NewHMAC(cfg.SHA256Key)
+		hashFactory = hashing.NewKeyedHMAC(cfg.SHA256KeyID, cfg.SHA256Key)
 	}
 
 	var encoder driver.Encoder
 	if cfg.RSAPublicKeyPem != nil {
-		e, err := rsahelpers.NewOAEPEncoder(cfg.RSAPublicKeyPem)
+		e, err := rsahelpers.NewHybridEncoder(cfg.RSAPublicKeyPem, cfg.RSAKeyID)
 		if err != nil {
 			return err
 		}
 		encoder = e
 	}
 
-	var drv senderPkg.Driver = nil
-
-	if cfg.GRPC != nil {
-		d, err := driver.NewGrpcDriver(*cfg.GRPC)
-		if err != nil {
-			return err
+	newDriver := func(dst config.Destination) (driver.UpdatesSender, error) {
+		if dst.GRPC != nil {
+			return driver.NewGrpcDriver(*dst.GRPC, agentID, ip, hashFactory, logger)
 		}
-		drv = d
-	} else {
-		drv = driver.NewHTTPDriver(
+		return driver.NewHTTPDriver(
 			logger,
 			storage,
 			hashFactory,
-			cfg.ServerAddress,
+			dst.ServerAddress,
 			encoder,
 			ip,
-		)
+			agentID,
+		), nil
 	}
 
-	sender := senderPkg.New(cfg.RetryAttempts, storage, logger, drv)
+	primary := config.Destination{GRPC: cfg.GRPC, ServerAddress: cfg.ServerAddress}
+	drv, err := newDriver(primary)
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.Destinations) > 0 {
+		destinations := []driver.Destination{{Driver: drv, Name: destinationName(primary)}}
+		for _, dst := range cfg.Destinations {
+			d, err := newDriver(dst)
+			if err != nil {
+				return err
+			}
+			destinations = append(destinations, driver.Destination{Driver: d, Name: destinationName(dst)})
+		}
+		drv = driver.NewMultiDriver(cfg.SendPolicy, destinations, cfg.Breaker, logger)
+	}
+
+	var sp *spool.Spool
+	if cfg.Spool.Dir != "" {
+		sp, err = spool.Open(cfg.Spool, logger)
+		if err != nil {
+			return err //nolint:wrapcheck // already wrapped
+		}
+	}
+
+	senderCfg := senderPkg.Config{
+		RetryBackoff: cfg.RetryBackoff,
+		OutboxLimits: senderPkg.OutboxLimits{
+			MaxBytes: cfg.Spool.MaxBytes,
+			MaxAge:   cfg.Spool.MaxAge,
+		},
+	}
+	// Multi driver keeps breaker per destination, shared one would stop healthy destinations too.
+	if len(cfg.Destinations) == 0 {
+		senderCfg.Breaker = &cfg.Breaker
+	}
+	sender := senderPkg.New(senderCfg, storage, logger, drv, cfg.Labels, sp)
 
 	rootCtx, cancelCtx := signal.NotifyContext(
 		context.Background(),
@@ -78,7 +130,7 @@ func Run(cfg *config.Config, logger *zap.Logger) error {
 
 	g.Go(func() error {
 		defer logger.Info("Poller errors handler stopped")
-		errCh := poller.Start(cfg.PollingInterval)
+		errCh := poller.Start()
 		for err := range errCh {
 			logger.Error("poller error", zap.Error(err))
 		}
@@ -114,3 +166,10 @@ func Run(cfg *config.Config, logger *zap.Logger) error {
 
 	return nil
 }
+
+func destinationName(dst config.Destination) string {
+	if dst.GRPC != nil {
+		return "grpc://" + dst.GRPC.Address
+	}
+	return "http://" + dst.ServerAddress
+}
@@ -3,16 +3,50 @@ package config
 
 import (
 	"go-metrics-service/internal/agent/sender/driver"
+	"go-metrics-service/internal/agent/spool"
+	"go-metrics-service/pkg/circuitbreaker"
+	"go-metrics-service/pkg/timeutils"
 	"time"
 )
 
 type Config struct {
-	ServerAddress   string
-	SHA256Key       string
-	RetryAttempts   []time.Duration
+	// AgentID identifies agent on server, outbound IP is used when empty.
+	AgentID       string
+	ServerAddress string
+	SHA256Key     string
+	// SHA256KeyID names SHA256Key on server holding per-agent keys.
+	SHA256KeyID string
+	// RetryBackoff delays retries of counter batches.
+	RetryBackoff timeutils.Backoff
+	// Breaker stops sending while server keeps failing.
+	Breaker         circuitbreaker.Config
 	RateLimit       int
 	PollingInterval time.Duration
 	SendingInterval time.Duration
+	// RSAPublicKeyPem encrypts HTTP payload. gRPC payload is not encrypted, so GRPC requires TLS with it.
 	RSAPublicKeyPem []byte
-	GRPC            *driver.GRPCConfig
+	// RSAKeyID names server key matching RSAPublicKeyPem.
+	RSAKeyID string
+	GRPC     *driver.GRPCConfig
+	// Labels are attached to every sent metric.
+	Labels map[string]string
+	// HistogramBuckets are upper bounds of collected histograms buckets.
+	HistogramBuckets []float64
+	// DisabledCollectors names poller collectors which are not run.
+	DisabledCollectors []string
+	// CollectorIntervals override PollingInterval for collectors by name.
+	CollectorIntervals map[string]time.Duration
+	// Spool keeps unsent batches on disk, they are kept in memory only when Dir is empty.
+	// Spool limits bound unsent batches in both cases.
+	Spool spool.Config
+	// Destinations are additional servers receiving updates along with the primary one.
+	Destinations []Destination
+	// SendPolicy decides when updates sent to several servers are considered sent.
+	SendPolicy driver.Policy
+}
+
+// Destination is a server reached over gRPC when GRPC is set, over HTTP otherwise.
+type Destination struct {
+	GRPC          *driver.GRPCConfig
+	ServerAddress string
 }
@@ -0,0 +1,148 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	storagePkg "go-metrics-service/internal/agent/storage"
+	"math/rand"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+const (
+	RuntimeCollectorName = "runtime"
+	MemoryCollectorName  = "memory"
+	CPUCollectorName     = "cpu"
+)
+
+const (
+	pollCountMetricName = "PollCount"
+	gcPauseMetricName   = "GCPause"
+)
+
+// RuntimeCollector collects Go runtime memory statistics and garbage collection pauses.
+type RuntimeCollector struct {
+	histogramBuckets []float64
+	lastNumGC        uint32
+}
+
+func NewRuntimeCollector(histogramBuckets []float64) *RuntimeCollector {
+	return &RuntimeCollector{
+		histogramBuckets: histogramBuckets,
+	}
+}
+
+func (c *RuntimeCollector) Name() string {
+	return RuntimeCollectorName
+}
+
+func (c *RuntimeCollector) Collect(_ context.Context, storage *storagePkg.Storage) error {
+	runtimeMetrics := runtime.MemStats{}
+	runtime.ReadMemStats(&runtimeMetrics)
+
+	newPollCount := int64(1)
+	if val, ok := storage.GetCounter(pollCountMetricName); ok {
+		newPollCount = val + 1
+	}
+
+	storage.SetCounter(pollCountMetricName, newPollCount)
+	storage.ObserveHistogram(gcPauseMetricName, c.histogramBuckets, c.newGCPauses(&runtimeMetrics)...)
+
+	storage.SetGauges(map[string]float64{
+		"Alloc":         float64(runtimeMetrics.Alloc),
+		"BuckHashSys":   float64(runtimeMetrics.BuckHashSys),
+		"Frees":         float64(runtimeMetrics.Frees),
+		"GCCPUFraction": runtimeMetrics.GCCPUFraction,
+		"GCSys":         float64(runtimeMetrics.GCSys),
+		"HeapAlloc":     float64(runtimeMetrics.HeapAlloc),
+		"HeapIdle":      float64(runtimeMetrics.HeapIdle),
+		"HeapInuse":     float64(runtimeMetrics.HeapInuse),
+		"HeapObjects":   float64(runtimeMetrics.HeapObjects),
+		"HeapReleased":  float64(runtimeMetrics.HeapReleased),
+		"HeapSys":       float64(runtimeMetrics.HeapSys),
+		"LastGC":        float64(runtimeMetrics.LastGC),
+		"Lookups":       float64(runtimeMetrics.Lookups),
+		"MCacheInuse":   float64(runtimeMetrics.MCacheInuse),
+		"MCacheSys":     float64(runtimeMetrics.MCacheSys),
+		"MSpanInuse":    float64(runtimeMetrics.MSpanInuse),
+		"MSpanSys":      float64(runtimeMetrics.MSpanSys),
+		"Mallocs":       float64(runtimeMetrics.Mallocs),
+		"NextGC":        float64(runtimeMetrics.NextGC),
+		"NumForcedGC":   float64(runtimeMetrics.NumForcedGC),
+		"NumGC":         float64(runtimeMetrics.NumGC),
+		"OtherSys":      float64(runtimeMetrics.OtherSys),
+		"PauseTotalNs":  float64(runtimeMetrics.PauseTotalNs),
+		"StackInuse":    float64(runtimeMetrics.StackInuse),
+		"StackSys":      float64(runtimeMetrics.StackSys),
+		"Sys":           float64(runtimeMetrics.Sys),
+		"TotalAlloc":    float64(runtimeMetrics.TotalAlloc),
+		"RandomValue":   rand.Float64(),
+	})
+	return nil
+}
+
+// newGCPauses returns pauses in seconds of garbage collections finished since previous call.
+// Only the most recent pauses kept by runtime ring buffer are available.
+func (c *RuntimeCollector) newGCPauses(stats *runtime.MemStats) []float64 {
+	first := c.lastNumGC + 1
+	if stats.NumGC-c.lastNumGC > uint32(len(stats.PauseNs)) {
+		first = stats.NumGC - uint32(len(stats.PauseNs)) + 1
+	}
+	pauses := make([]float64, 0, stats.NumGC-first+1)
+	for n := first; n <= stats.NumGC; n++ {
+		pause := stats.PauseNs[(n+uint32(len(stats.PauseNs))-1)%uint32(len(stats.PauseNs))]
+		pauses = append(pauses, time.Duration(pause).Seconds())
+	}
+	c.lastNumGC = stats.NumGC
+	return pauses
+}
+
+// CPUCollector collects utilization of every CPU.
+type CPUCollector struct{}
+
+func NewCPUCollector() *CPUCollector {
+	return &CPUCollector{}
+}
+
+func (c *CPUCollector) Name() string {
+	return CPUCollectorName
+}
+
+func (c *CPUCollector) Collect(_ context.Context, storage *storagePkg.Storage) error {
+	cpuInfos, err := cpu.Percent(0, true)
+	if err != nil {
+		return fmt.Errorf("failed to get cpu info: %w", err)
+	}
+	cpuValues := make(map[string]float64)
+	for i, cpuInfo := range cpuInfos {
+		cpuValues[fmt.Sprintf("CpuUtilization%v", i)] = cpuInfo
+	}
+	storage.SetGauges(cpuValues)
+	return nil
+}
+
+// MemoryCollector collects system memory usage.
+type MemoryCollector struct{}
+
+func NewMemoryCollector() *MemoryCollector {
+	return &MemoryCollector{}
+}
+
+func (c *MemoryCollector) Name() string {
+	return MemoryCollectorName
+}
+
+func (c *MemoryCollector) Collect(_ context.Context, storage *storagePkg.Storage) error {
+	memInfo, err := mem.VirtualMemory()
+	if err != nil {
+		return fmt.Errorf("failed to get mem info: %w", err)
+	}
+	storage.SetGauges(map[string]float64{
+		"FreeMemory":  float64(memInfo.Free),
+		"TotalMemory": float64(memInfo.Total),
+	})
+	return nil
+}
@@ -0,0 +1,80 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	storagePkg "go-metrics-service/internal/agent/storage"
+	"sync"
+)
+
+var (
+	ErrDuplicateCollector = errors.New("duplicate collector")
+	ErrUnknownCollector   = errors.New("unknown collector")
+)
+
+// Collector gathers metrics into agent storage. Collect is called from one goroutine at a time.
+type Collector interface {
+	// Name identifies collector in config and self-metrics.
+	Name() string
+	Collect(ctx context.Context, storage *storagePkg.Storage) error
+}
+
+// Registry keeps collectors available to poller in registration order.
+type Registry struct {
+	mux        *sync.Mutex
+	byName     map[string]Collector
+	collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		mux:        &sync.Mutex{},
+		byName:     make(map[string]Collector),
+		collectors: make([]Collector, 0),
+	}
+}
+
+// NewDefaultRegistry returns registry with runtime, memory and cpu collectors.
+func NewDefaultRegistry(histogramBuckets []float64) *Registry {
+	r := NewRegistry()
+	for _, c := range []Collector{
+		NewRuntimeCollector(histogramBuckets),
+		NewMemoryCollector(),
+		NewCPUCollector(),
+	} {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register adds collector, names must be unique.
+func (r *Registry) Register(c Collector) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, ok := r.byName[c.Name()]; ok {
+		return fmt.Errorf("%w: '%s'", ErrDuplicateCollector, c.Name())
+	}
+	r.byName[c.Name()] = c
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+// Has reports whether collector with given name is registered.
+func (r *Registry) Has(name string) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	_, ok := r.byName[name]
+	return ok
+}
+
+// Collectors returns registered collectors in registration order.
+func (r *Registry) Collectors() []Collector {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	res := make([]Collector, len(r.collectors))
+	copy(res, r.collectors)
+	return res
+}
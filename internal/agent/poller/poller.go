@@ -6,40 +6,89 @@ import (
 	"fmt"
 	storagePkg "go-metrics-service/internal/agent/storage"
 	"go-metrics-service/pkg/gohelpers"
-	"math/rand"
-	"runtime"
+	"slices"
 	"time"
 
 	"go.uber.org/zap"
-
-	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/mem"
 )
 
 const (
-	pollCountMetricName = "PollCount"
+	collectorErrorsMetricPrefix   = "CollectorErrors_"
+	collectorDurationMetricPrefix = "CollectorDuration_"
 )
 
+// Config selects collectors run by poller and their intervals.
+type Config struct {
+	// Intervals override Interval for collectors by name.
+	Intervals map[string]time.Duration
+	// Disabled names collectors which are not run.
+	Disabled []string
+	// HistogramBuckets are bounds of collectors duration histograms.
+	HistogramBuckets []float64
+	Interval         time.Duration
+}
+
+type scheduledCollector struct {
+	collector Collector
+	interval  time.Duration
+}
+
 type Poller struct {
-	storage *storagePkg.Storage
-	logger  *zap.Logger
-	doneCh  chan struct{}
+	storage    *storagePkg.Storage
+	logger     *zap.Logger
+	doneCh     chan struct{}
+	buckets    []float64
+	collectors []scheduledCollector
 }
 
-func New(storage *storagePkg.Storage, logger *zap.Logger) *Poller {
-	return &Poller{
-		storage: storage,
-		logger:  logger,
-		doneCh:  make(chan struct{}),
+// New creates poller running every enabled collector of registry with its own interval.
+func New(storage *storagePkg.Storage, cfg Config, registry *Registry, logger *zap.Logger) (*Poller, error) {
+	for _, name := range cfg.Disabled {
+		if !registry.Has(name) {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnknownCollector, name)
+		}
 	}
+	for name, interval := range cfg.Intervals {
+		if !registry.Has(name) {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnknownCollector, name)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("interval of collector '%s' must be greater than zero", name)
+		}
+	}
+	collectors := make([]scheduledCollector, 0)
+	for _, c := range registry.Collectors() {
+		if slices.Contains(cfg.Disabled, c.Name()) {
+			logger.Info("collector disabled", zap.String("collector", c.Name()))
+			continue
+		}
+		interval, ok := cfg.Intervals[c.Name()]
+		if !ok {
+			interval = cfg.Interval
+		}
+		collectors = append(collectors, scheduledCollector{collector: c, interval: interval})
+	}
+	return &Poller{
+		storage:    storage,
+		logger:     logger,
+		doneCh:     make(chan struct{}),
+		buckets:    cfg.HistogramBuckets,
+		collectors: collectors,
+	}, nil
 }
 
-func (p *Poller) Start(interval time.Duration) chan error {
-	errCh1 := gohelpers.StartTickerProcess(p.doneCh, p.CollectRuntimeMetrics, interval)
-	errCh2 := gohelpers.StartTickerProcess(p.doneCh, p.CollectGopsutilMemMetrics, interval)
-	errCh3 := gohelpers.StartTickerProcess(p.doneCh, p.CollectGopsutilCPUMetrics, interval)
-
-	return gohelpers.AggregateErrors(errCh1, errCh2, errCh3)
+func (p *Poller) Start() chan error {
+	errChs := make([]chan error, 0, len(p.collectors))
+	for _, c := range p.collectors {
+		errChs = append(errChs, gohelpers.StartTickerProcess(
+			p.doneCh,
+			func(ctx context.Context) error {
+				return p.collect(ctx, c.collector)
+			},
+			c.interval,
+		))
+	}
+	return gohelpers.AggregateErrors(errChs...)
 }
 
 func (p *Poller) Stop() {
@@ -62,71 +111,16 @@ func (p *Poller) PollProcess(f func(), interval time.Duration, errCh chan error)
 	}
 }
 
-func (p *Poller) CollectRuntimeMetrics(_ context.Context) error {
-	runtimeMetrics := runtime.MemStats{}
-	runtime.ReadMemStats(&runtimeMetrics)
-
-	newPollCount := int64(1)
-	if val, ok := p.storage.GetCounter(pollCountMetricName); ok {
-		newPollCount = val + 1
-	}
-
-	p.storage.SetCounter(pollCountMetricName, newPollCount)
-
-	p.storage.SetGauges(map[string]float64{
-		"Alloc":         float64(runtimeMetrics.Alloc),
-		"BuckHashSys":   float64(runtimeMetrics.BuckHashSys),
-		"Frees":         float64(runtimeMetrics.Frees),
-		"GCCPUFraction": runtimeMetrics.GCCPUFraction,
-		"GCSys":         float64(runtimeMetrics.GCSys),
-		"HeapAlloc":     float64(runtimeMetrics.HeapAlloc),
-		"HeapIdle":      float64(runtimeMetrics.HeapIdle),
-		"HeapInuse":     float64(runtimeMetrics.HeapInuse),
-		"HeapObjects":   float64(runtimeMetrics.HeapObjects),
-		"HeapReleased":  float64(runtimeMetrics.HeapReleased),
-		"HeapSys":       float64(runtimeMetrics.HeapSys),
-		"LastGC":        float64(runtimeMetrics.LastGC),
-		"Lookups":       float64(runtimeMetrics.Lookups),
-		"MCacheInuse":   float64(runtimeMetrics.MCacheInuse),
-		"MCacheSys":     float64(runtimeMetrics.MCacheSys),
-		"MSpanInuse":    float64(runtimeMetrics.MSpanInuse),
-		"MSpanSys":      float64(runtimeMetrics.MSpanSys),
-		"Mallocs":       float64(runtimeMetrics.Mallocs),
-		"NextGC":        float64(runtimeMetrics.NextGC),
-		"NumForcedGC":   float64(runtimeMetrics.NumForcedGC),
-		"NumGC":         float64(runtimeMetrics.NumGC),
-		"OtherSys":      float64(runtimeMetrics.OtherSys),
-		"PauseTotalNs":  float64(runtimeMetrics.PauseTotalNs),
-		"StackInuse":    float64(runtimeMetrics.StackInuse),
-		"StackSys":      float64(runtimeMetrics.StackSys),
-		"Sys":           float64(runtimeMetrics.Sys),
-		"TotalAlloc":    float64(runtimeMetrics.TotalAlloc),
-		"RandomValue":   rand.Float64(),
-	})
-	return nil
-}
-
-func (p *Poller) CollectGopsutilCPUMetrics(_ context.Context) error {
-	cpuInfos, err := cpu.Percent(0, true)
-	if err != nil {
-		return fmt.Errorf("failed to get cpu info: %w", err)
-	}
-	cpuValues := make(map[string]float64)
-	for i, cpuInfo := range cpuInfos {
-		cpuValues[fmt.Sprintf("CpuUtilization%v", i)] = cpuInfo
-	}
-	p.storage.SetGauges(cpuValues)
-	return nil
-}
-
-func (p *Poller) CollectGopsutilMemMetrics(_ context.Context) error {
-	memInfo, err := mem.VirtualMemory()
+// collect runs collector and records its duration and errors as agent self-metrics.
+func (p *Poller) collect(ctx context.Context, c Collector) error {
+	start := time.Now()
+	err := c.Collect(ctx, p.storage)
+	p.storage.ObserveHistogram(collectorDurationMetricPrefix+c.Name(), p.buckets, time.Since(start).Seconds())
 	if err != nil {
-		return fmt.Errorf("failed to get mem info: %w", err)
+		errorsKey := collectorErrorsMetricPrefix + c.Name()
+		count, _ := p.storage.GetCounter(errorsKey)
+		p.storage.SetCounter(errorsKey, count+1)
+		return fmt.Errorf("collector '%s' failed: %w", c.Name(), err)
 	}
-	p.storage.SetGauges(map[string]float64{
-		"FreeMemory":  float64(memInfo.Free),
-		"TotalMemory": float64(memInfo.Total),
-	})
 	return nil
 }
@@ -0,0 +1,97 @@
+package poller
+
+import (
+	"context"
+	storagePkg "go-metrics-service/internal/agent/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeCollector struct {
+	err  error
+	name string
+}
+
+func (c *fakeCollector) Name() string {
+	return c.name
+}
+
+func (c *fakeCollector) Collect(_ context.Context, storage *storagePkg.Storage) error {
+	if c.err != nil {
+		return c.err
+	}
+	storage.SetGauge(c.name, 1)
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewDefaultRegistry([]float64{1})
+	assert.ErrorIs(t, registry.Register(&fakeCollector{name: CPUCollectorName}), ErrDuplicateCollector)
+	require.NoError(t, registry.Register(&fakeCollector{name: "custom"}))
+
+	names := make([]string, 0)
+	for _, c := range registry.Collectors() {
+		names = append(names, c.Name())
+	}
+	assert.Equal(t, []string{RuntimeCollectorName, MemoryCollectorName, CPUCollectorName, "custom"}, names)
+}
+
+func TestPollerConfig(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(&fakeCollector{name: "fast"}))
+	require.NoError(t, registry.Register(&fakeCollector{name: "slow"}))
+	require.NoError(t, registry.Register(&fakeCollector{name: "off"}))
+
+	p, err := New(
+		storagePkg.New(),
+		Config{
+			Intervals: map[string]time.Duration{"slow": time.Minute},
+			Disabled:  []string{"off"},
+			Interval:  time.Second,
+		},
+		registry,
+		zap.NewNop(),
+	)
+	require.NoError(t, err)
+	require.Len(t, p.collectors, 2)
+	assert.Equal(t, time.Second, p.collectors[0].interval)
+	assert.Equal(t, time.Minute, p.collectors[1].interval)
+
+	_, err = New(storagePkg.New(), Config{Disabled: []string{"missing"}}, registry, zap.NewNop())
+	assert.ErrorIs(t, err, ErrUnknownCollector)
+	_, err = New(
+		storagePkg.New(),
+		Config{Intervals: map[string]time.Duration{"missing": time.Second}},
+		registry,
+		zap.NewNop(),
+	)
+	assert.ErrorIs(t, err, ErrUnknownCollector)
+}
+
+func TestPollerSelfMetrics(t *testing.T) {
+	storage := storagePkg.New()
+	p, err := New(storage, Config{HistogramBuckets: []float64{1}, Interval: time.Second}, NewRegistry(), zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, p.collect(context.Background(), &fakeCollector{name: "good"}))
+	broken := &fakeCollector{name: "broken", err: assert.AnError}
+	assert.ErrorIs(t, p.collect(context.Background(), broken), assert.AnError)
+	assert.ErrorIs(t, p.collect(context.Background(), broken), assert.AnError)
+
+	value, ok := storage.GetGauge("good")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), value)
+	errorsCount, ok := storage.GetCounter(collectorErrorsMetricPrefix + "broken")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), errorsCount)
+	_, ok = storage.GetCounter(collectorErrorsMetricPrefix + "good")
+	assert.False(t, ok)
+
+	histograms := storage.ConsumeHistograms()
+	assert.Equal(t, uint64(1), histograms[collectorDurationMetricPrefix+"good"].Count)
+	assert.Equal(t, uint64(2), histograms[collectorDurationMetricPrefix+"broken"].Count)
+}
@@ -1,37 +1,139 @@
 package driver
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/pkg/timeutils"
+	"go-metrics-service/pkg/tlshelpers"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	pb "go-metrics-service/proto"
 
+	"go.uber.org/zap"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// GrpcDriver pushes batches over one long-lived stream. Every batch waits for
+// the ack with its sequence, unacknowledged batches are retransmitted after reconnect.
 type GrpcDriver struct {
 	conn          *grpc.ClientConn
 	updateMetrics pb.UpdateMetricsClient
+	// sendMux orders writes to stream and reconnects, must be taken before mux.
+	sendMux  *sync.Mutex
+	mux      *sync.Mutex
+	pending  map[uint64]*pendingBatch
+	stream   *updatesStream
+	sequence atomic.Uint64
+	// reconnectBackoff delays reopening of streams broken before any ack.
+	reconnectBackoff timeutils.Backoff
+	// unackedBreaks counts streams broken before any ack in a row, guarded by sendMux.
+	unackedBreaks int
 }
 
+// roundRobinServiceConfig spreads streams over resolved addresses. Updates stream is
+// long-lived, so agent moves to another address when stream is reopened.
+const roundRobinServiceConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
 type GRPCConfig struct {
-	Port uint16
+	// Address is gRPC target, "host:port" is resolved with DNS and calls are
+	// balanced in round robin over every resolved address.
+	Address string
+	// TLS enables transport security, server certificate is verified against CAPem
+	// or system roots when CAPem is empty.
+	TLS        bool
+	CAPem      []byte
+	ServerName string
+	// CertPem and KeyPem are presented to server requiring client certificates.
+	CertPem []byte
+	KeyPem  []byte
+	// ReconnectBackoff delays reopening of stream broken before any ack, pending
+	// batches fail once its attempts are exhausted.
+	ReconnectBackoff timeutils.Backoff
+}
+
+type pendingBatch struct {
+	batch  *pb.MetricsBatch
+	result chan error
+}
+
+type updatesStream struct {
+	stream grpc.BidiStreamingClient[pb.MetricsBatch, pb.MetricsBatchAck]
+	cancel context.CancelFunc
+	// sendErr is the write failure which broke the stream, guarded by GrpcDriver.mux.
+	sendErr error
 }
 
-func NewGrpcDriver(cfg GRPCConfig) (*GrpcDriver, error) {
-	options := grpc.WithTransportCredentials(insecure.NewCredentials())
-	conn, err := grpc.NewClient(fmt.Sprintf(":%v", cfg.Port), options)
+func NewGrpcDriver(
+	cfg GRPCConfig,
+	agentID string,
+	ip net.IP,
+	hashFactory HashFactory,
+	logger *zap.Logger,
+) (*GrpcDriver, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS {
+		tlsConfig, err := tlshelpers.NewClientConfig(cfg.CAPem, cfg.CertPem, cfg.KeyPem, cfg.ServerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure grpc tls: %w", err)
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	unary, stream := interceptors(agentID, ip, hashFactory, logger)
+	conn, err := grpc.NewClient(
+		cfg.Address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(roundRobinServiceConfig),
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	)
 	if err != nil {
 		return nil, err
 	}
-	updateMetrics := pb.NewUpdateMetricsClient(conn)
+	return newGrpcDriver(conn, cfg.ReconnectBackoff), nil
+}
+
+// interceptors returns client interceptors matching server ones: identity metadata
+// for subnet filter, request signing and logging.
+func interceptors(
+	agentID string,
+	ip net.IP,
+	hashFactory HashFactory,
+	logger *zap.Logger,
+) ([]grpc.UnaryClientInterceptor, []grpc.StreamClientInterceptor) {
+	identityUnary, identityStream := identityInterceptors(agentID, ip)
+	loggingUnary, loggingStream := loggingInterceptors(logger)
+	unary := []grpc.UnaryClientInterceptor{loggingUnary, identityUnary}
+	stream := []grpc.StreamClientInterceptor{loggingStream, identityStream}
+	if hashFactory != nil {
+		hashUnary, hashStream := hashInterceptors(hashFactory)
+		unary = append(unary, hashUnary)
+		stream = append(stream, hashStream)
+	}
+	return unary, stream
+}
+
+func newGrpcDriver(conn *grpc.ClientConn, reconnectBackoff timeutils.Backoff) *GrpcDriver {
 	return &GrpcDriver{
-		conn:          conn,
-		updateMetrics: updateMetrics,
-	}, nil
+		conn:             conn,
+		updateMetrics:    pb.NewUpdateMetricsClient(conn),
+		sendMux:          &sync.Mutex{},
+		mux:              &sync.Mutex{},
+		pending:          make(map[uint64]*pendingBatch),
+		reconnectBackoff: reconnectBackoff,
+	}
 }
 
 func (s *GrpcDriver) SendUpdates(ctx context.Context, metrics []protocol.Metrics) error {
@@ -39,19 +141,214 @@ func (s *GrpcDriver) SendUpdates(ctx context.Context, metrics []protocol.Metrics
 	if err != nil {
 		return err
 	}
-	request := pb.UpdateMetricsRequest_builder{
-		Values: ms,
-	}.Build()
-	response, err := s.updateMetrics.UpdateMetrics(ctx, request)
-	if err != nil {
+	// Batch retransmitted after reconnect may be applied already, its ID lets server skip it.
+	batchID := IdempotencyKeyFromContext(ctx)
+	if batchID == "" {
+		batchID, err = NewIdempotencyKey()
+		if err != nil {
+			return err
+		}
+	}
+	sequence := s.sequence.Add(1)
+	batch := &pendingBatch{
+		batch: pb.MetricsBatch_builder{
+			Sequence: &sequence,
+			Values:   ms,
+			BatchId:  &batchID,
+		}.Build(),
+		result: make(chan error, 1),
+	}
+
+	s.mux.Lock()
+	s.pending[sequence] = batch
+	s.mux.Unlock()
+	defer func() {
+		s.mux.Lock()
+		delete(s.pending, sequence)
+		s.mux.Unlock()
+	}()
+
+	if err := s.send(batch); err != nil {
+		return err
+	}
+	select {
+	case err := <-batch.result:
 		return err
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // context error is returned as is
+	}
+}
+
+// send writes batch to current stream or opens new one, which retransmits every pending batch.
+// Failed write breaks the stream: batch stays pending and is retransmitted when receiver reconnects.
+func (s *GrpcDriver) send(batch *pendingBatch) error {
+	s.sendMux.Lock()
+	defer s.sendMux.Unlock()
+	s.mux.Lock()
+	current := s.stream
+	s.mux.Unlock()
+	if current == nil {
+		return s.connect()
+	}
+	if err := current.stream.Send(batch.batch); err != nil {
+		s.breakStream(current, err)
+	}
+	return nil
+}
+
+// breakStream cancels stream which failed to write, so its receiver stops and reconnects.
+func (s *GrpcDriver) breakStream(current *updatesStream, err error) {
+	s.mux.Lock()
+	current.sendErr = err
+	s.mux.Unlock()
+	current.cancel()
+}
+
+// connect opens new stream and retransmits pending batches in sequence order. Requires sendMux.
+func (s *GrpcDriver) connect() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := s.updateMetrics.StreamUpdates(ctx)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to open updates stream: %w", err)
+	}
+	current := &updatesStream{stream: stream, cancel: cancel}
+
+	s.mux.Lock()
+	s.stream = current
+	batches := make([]*pendingBatch, 0, len(s.pending))
+	for _, batch := range s.pending {
+		batches = append(batches, batch)
 	}
-	if response.GetError() != "" {
-		return errors.New(response.GetError())
+	s.mux.Unlock()
+	slices.SortFunc(batches, func(a, b *pendingBatch) int {
+		return cmp.Compare(a.batch.GetSequence(), b.batch.GetSequence())
+	})
+
+	go s.receive(current)
+	for _, batch := range batches {
+		if err := stream.Send(batch.batch); err != nil {
+			s.breakStream(current, err)
+			break
+		}
 	}
 	return nil
 }
 
+// receive delivers acks to waiting batches until stream breaks.
+func (s *GrpcDriver) receive(current *updatesStream) {
+	acked := false
+	for {
+		ack, err := current.stream.Recv()
+		if err != nil {
+			s.mux.Lock()
+			if current.sendErr != nil {
+				err = current.sendErr
+			}
+			s.mux.Unlock()
+			s.reconnect(current, acked, err)
+			return
+		}
+		acked = true
+		s.mux.Lock()
+		batch, ok := s.pending[ack.GetSequence()]
+		s.mux.Unlock()
+		if !ok {
+			continue
+		}
+		result := ackError(ack)
+		select {
+		case batch.result <- result:
+		default:
+		}
+	}
+}
+
+// reconnect replaces broken stream and retransmits batches left without ack.
+// Streams broken before any ack are reopened with reconnect backoff to avoid reconnect loop,
+// pending batches fail once its attempts are exhausted.
+func (s *GrpcDriver) reconnect(current *updatesStream, acked bool, cause error) {
+	current.cancel()
+	s.sendMux.Lock()
+	s.mux.Lock()
+	if s.stream == current {
+		s.stream = nil
+	}
+	idle := len(s.pending) == 0
+	s.mux.Unlock()
+	if acked {
+		s.unackedBreaks = 0
+	}
+	if idle {
+		s.sendMux.Unlock()
+		return
+	}
+	err := fmt.Errorf("updates stream broken: %w", classify(cause))
+	if !acked {
+		if s.unackedBreaks >= s.reconnectBackoff.Attempts {
+			s.unackedBreaks = 0
+			s.sendMux.Unlock()
+			s.failPending(err)
+			return
+		}
+		delay := s.reconnectBackoff.Delay(s.unackedBreaks)
+		s.unackedBreaks++
+		// Sends are not blocked while waiting, the first of them reopens the stream itself.
+		s.sendMux.Unlock()
+		time.Sleep(delay)
+		s.sendMux.Lock()
+	}
+	defer s.sendMux.Unlock()
+	s.mux.Lock()
+	reopened := s.stream != nil
+	s.mux.Unlock()
+	if reopened {
+		return
+	}
+	if connectErr := s.connect(); connectErr != nil {
+		s.failPending(errors.Join(err, connectErr))
+	}
+}
+
+func (s *GrpcDriver) failPending(err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, batch := range s.pending {
+		select {
+		case batch.result <- err:
+		default:
+		}
+	}
+}
+
+// ackError restores status of rejected batch, servers not reporting status are treated as unavailable.
+func ackError(ack *pb.MetricsBatchAck) error {
+	if ack.GetError() == "" && len(ack.GetStatus()) == 0 {
+		return nil
+	}
+	var st spb.Status
+	if err := proto.Unmarshal(ack.GetStatus(), &st); err != nil || st.GetCode() == int32(codes.OK) {
+		return classify(status.Error(codes.Unavailable, ack.GetError()))
+	}
+	return classify(status.ErrorProto(&st))
+}
+
+// classify marks batch failing server validation as ErrRejected, it will not succeed on retry.
+// Other codes, including Unauthenticated and PermissionDenied caused by rotated key or clock skew,
+// keep batch pending.
+func classify(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() {
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	default:
+		return err
+	}
+}
+
 func ConvertMetrics(ms []protocol.Metrics) ([]*pb.Metric, error) {
 	res := make([]*pb.Metric, len(ms))
 	for i, metric := range ms {
@@ -71,18 +368,36 @@ func ConvertMetric(m protocol.Metrics) (*pb.Metric, error) {
 		metricType := pb.Metric_GAUGE
 		val := *m.Value
 		return pb.Metric_builder{
-			Id:    &id,
-			Type:  &metricType,
-			Value: &val,
+			Id:     &id,
+			Type:   &metricType,
+			Value:  &val,
+			Labels: m.Labels,
 		}.Build(), nil
 	case protocol.Counter:
 		id := m.ID
 		metricType := pb.Metric_COUNTER
 		delta := *m.Delta
 		return pb.Metric_builder{
-			Id:    &id,
-			Type:  &metricType,
-			Delta: &delta,
+			Id:     &id,
+			Type:   &metricType,
+			Delta:  &delta,
+			Labels: m.Labels,
+		}.Build(), nil
+	case protocol.Histogram:
+		id := m.ID
+		metricType := pb.Metric_HISTOGRAM
+		sum := m.Histogram.Sum
+		count := m.Histogram.Count
+		return pb.Metric_builder{
+			Id:   &id,
+			Type: &metricType,
+			Histogram: pb.Histogram_builder{
+				Bounds: m.Histogram.Bounds,
+				Counts: m.Histogram.Counts,
+				Sum:    &sum,
+				Count:  &count,
+			}.Build(),
+			Labels: m.Labels,
 		}.Build(), nil
 	default:
 		return nil, errors.New("unknown metric type " + m.MType)
@@ -0,0 +1,307 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"go-metrics-service/internal/common/hashing"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/pkg/timeutils"
+	pb "go-metrics-service/proto"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// flakyUpdatesServer acks first batch of first stream and breaks it on second batch.
+type flakyUpdatesServer struct {
+	pb.UnimplementedUpdateMetricsServer
+	mux      *sync.Mutex
+	batchIDs map[uint64][]string
+	streams  int
+	received [][]uint64
+}
+
+func (s *flakyUpdatesServer) StreamUpdates(stream grpc.BidiStreamingServer[pb.MetricsBatch, pb.MetricsBatchAck]) error {
+	s.mux.Lock()
+	index := s.streams
+	s.streams++
+	s.received = append(s.received, nil)
+	s.mux.Unlock()
+	for {
+		batch, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		s.mux.Lock()
+		s.received[index] = append(s.received[index], batch.GetSequence())
+		s.batchIDs[batch.GetSequence()] = append(s.batchIDs[batch.GetSequence()], batch.GetBatchId())
+		count := len(s.received[index])
+		s.mux.Unlock()
+		if index == 0 && count > 1 {
+			return errors.New("stream broken")
+		}
+		sequence := batch.GetSequence()
+		if err := stream.Send(pb.MetricsBatchAck_builder{Sequence: &sequence}.Build()); err != nil {
+			return err
+		}
+	}
+}
+
+func TestGrpcDriverRetransmitsUnacknowledged(t *testing.T) {
+	updates := &flakyUpdatesServer{mux: &sync.Mutex{}, batchIDs: make(map[uint64][]string)}
+	conn := dialUpdatesServer(t, updates)
+	driver := newGrpcDriver(conn, timeutils.Backoff{})
+
+	delta := int64(1)
+	metrics := []protocol.Metrics{{ID: "PollCount", MType: protocol.Counter, Delta: &delta}}
+	ctx := context.Background()
+	require.NoError(t, driver.SendUpdates(ctx, metrics))
+	require.NoError(t, driver.SendUpdates(ctx, metrics))
+	require.NoError(t, driver.SendUpdates(ctx, metrics))
+
+	updates.mux.Lock()
+	defer updates.mux.Unlock()
+	assert.Equal(t, [][]uint64{{1, 2}, {2, 3}}, updates.received)
+	// Retransmitted batch keeps its ID even though sender set no idempotency key.
+	require.Len(t, updates.batchIDs[2], 2)
+	assert.NotEmpty(t, updates.batchIDs[2][0])
+	assert.Equal(t, updates.batchIDs[2][0], updates.batchIDs[2][1])
+	assert.NotEqual(t, updates.batchIDs[1][0], updates.batchIDs[2][0])
+}
+
+// breakingUpdatesServer breaks given number of streams before acking anything.
+type breakingUpdatesServer struct {
+	pb.UnimplementedUpdateMetricsServer
+	mux    *sync.Mutex
+	breaks int
+}
+
+func (s *breakingUpdatesServer) StreamUpdates(stream grpc.BidiStreamingServer[pb.MetricsBatch, pb.MetricsBatchAck]) error {
+	for {
+		batch, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		s.mux.Lock()
+		broken := s.breaks > 0
+		s.breaks--
+		s.mux.Unlock()
+		if broken {
+			return status.Error(codes.Unavailable, "stream broken")
+		}
+		sequence := batch.GetSequence()
+		if err := stream.Send(pb.MetricsBatchAck_builder{Sequence: &sequence}.Build()); err != nil {
+			return err
+		}
+	}
+}
+
+func TestGrpcDriverReconnectBackoff(t *testing.T) {
+	backoff := timeutils.Backoff{Initial: time.Millisecond, Multiplier: 1, Attempts: 2}
+	delta := int64(1)
+	metrics := []protocol.Metrics{{ID: "PollCount", MType: protocol.Counter, Delta: &delta}}
+
+	// Streams broken within reconnect attempts do not fail the batch.
+	conn := dialUpdatesServer(t, &breakingUpdatesServer{mux: &sync.Mutex{}, breaks: 2})
+	require.NoError(t, newGrpcDriver(conn, backoff).SendUpdates(context.Background(), metrics))
+
+	conn = dialUpdatesServer(t, &breakingUpdatesServer{mux: &sync.Mutex{}, breaks: 3})
+	err := newGrpcDriver(conn, backoff).SendUpdates(context.Background(), metrics)
+	require.Error(t, err)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestGrpcDriverSendFailure(t *testing.T) {
+	// Batch exceeding send limit fails to write, stream is reopened until attempts are exhausted.
+	conn := dialUpdatesServer(
+		t,
+		&breakingUpdatesServer{mux: &sync.Mutex{}},
+		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(1)),
+	)
+	driver := newGrpcDriver(conn, timeutils.Backoff{Initial: time.Millisecond, Multiplier: 1, Attempts: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	delta := int64(1)
+	err := driver.SendUpdates(ctx, []protocol.Metrics{{ID: "PollCount", MType: protocol.Counter, Delta: &delta}})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded, "failed write must not leave batch waiting")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// dialUpdatesServer serves server over in-memory listener, options are applied to client.
+func dialUpdatesServer(t *testing.T, server pb.UpdateMetricsServer, options ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	pb.RegisterUpdateMetricsServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	options = append(
+		options,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", options...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+// verifyingUpdatesServer acks batches which carry agent metadata, timestamp, nonce and valid hash.
+type verifyingUpdatesServer struct {
+	pb.UnimplementedUpdateMetricsServer
+	hashFactory  hashing.Factory
+	unsignedAcks bool
+}
+
+func (s *verifyingUpdatesServer) StreamUpdates(stream grpc.BidiStreamingServer[pb.MetricsBatch, pb.MetricsBatchAck]) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	if len(md.Get(protocol.AgentIDMetadata)) == 0 || len(md.Get(protocol.RealIPMetadata)) == 0 {
+		return status.Error(codes.PermissionDenied, "no identity")
+	}
+	for {
+		batch, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		valid, err := hashing.Verify(s.hashFactory, batch)
+		if err != nil || !valid || batch.GetTimestamp() == "" || batch.GetNonce() == "" {
+			return status.Error(codes.Unauthenticated, "hash mismatch")
+		}
+		sequence := batch.GetSequence()
+		ack := pb.MetricsBatchAck_builder{Sequence: &sequence}.Build()
+		if !s.unsignedAcks {
+			if err := hashing.Sign(s.hashFactory, ack); err != nil {
+				return err
+			}
+		}
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+func TestGrpcDriverInterceptors(t *testing.T) {
+	hashFactory := hashing.NewHMAC("secret")
+	unary, stream := interceptors("agent", net.IPv4(10, 0, 0, 1), hashFactory, zap.NewNop())
+	conn := dialUpdatesServer(
+		t,
+		&verifyingUpdatesServer{hashFactory: hashFactory},
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	)
+	driver := newGrpcDriver(conn, timeutils.Backoff{})
+
+	value := 1.0
+	metrics := []protocol.Metrics{{ID: "cpu", MType: protocol.Gauge, Value: &value}}
+	require.NoError(t, driver.SendUpdates(context.Background(), metrics))
+
+	// Batch signed with key unknown to server is rejected.
+	conn = dialUpdatesServer(
+		t,
+		&verifyingUpdatesServer{hashFactory: hashing.NewHMAC("other")},
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	)
+	require.Error(t, newGrpcDriver(conn, timeutils.Backoff{}).SendUpdates(context.Background(), metrics))
+
+	// Unsigned ack is not trusted by agent with hash key.
+	conn = dialUpdatesServer(
+		t,
+		&verifyingUpdatesServer{hashFactory: hashFactory, unsignedAcks: true},
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	)
+	assert.ErrorIs(t, newGrpcDriver(conn, timeutils.Backoff{}).SendUpdates(context.Background(), metrics), ErrUnsignedResponse)
+}
+
+func TestNewGrpcDriverAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	hashFactory := hashing.NewHMAC("secret")
+	server := grpc.NewServer()
+	pb.RegisterUpdateMetricsServer(server, &verifyingUpdatesServer{hashFactory: hashFactory})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	driver, err := NewGrpcDriver(
+		GRPCConfig{Address: "dns:///" + listener.Addr().String()},
+		"agent",
+		net.IPv4(127, 0, 0, 1),
+		hashFactory,
+		zap.NewNop(),
+	)
+	require.NoError(t, err)
+	value := 1.0
+	require.NoError(t, driver.SendUpdates(
+		context.Background(),
+		[]protocol.Metrics{{ID: "cpu", MType: protocol.Gauge, Value: &value}},
+	))
+}
+
+func TestAckError(t *testing.T) {
+	encode := func(code codes.Code) []byte {
+		encoded, err := proto.Marshal(status.New(code, "failed").Proto())
+		require.NoError(t, err)
+		return encoded
+	}
+	sequence := uint64(1)
+
+	assert.NoError(t, ackError(pb.MetricsBatchAck_builder{Sequence: &sequence}.Build()))
+
+	rejected := ackError(pb.MetricsBatchAck_builder{
+		Sequence: &sequence,
+		Error:    ptr("failed"),
+		Status:   encode(codes.InvalidArgument),
+	}.Build())
+	require.ErrorIs(t, rejected, ErrRejected)
+	assert.Equal(t, codes.InvalidArgument, status.Code(rejected))
+
+	unavailable := ackError(pb.MetricsBatchAck_builder{
+		Sequence: &sequence,
+		Error:    ptr("failed"),
+		Status:   encode(codes.Unavailable),
+	}.Build())
+	require.Error(t, unavailable)
+	assert.NotErrorIs(t, unavailable, ErrRejected)
+
+	// Rotated key or clock skew passes later, batch stays pending.
+	unauthenticated := ackError(pb.MetricsBatchAck_builder{
+		Sequence: &sequence,
+		Error:    ptr("failed"),
+		Status:   encode(codes.Unauthenticated),
+	}.Build())
+	require.Error(t, unauthenticated)
+	assert.NotErrorIs(t, unauthenticated, ErrRejected)
+
+	// Ack without status is treated as temporary failure.
+	legacy := ackError(pb.MetricsBatchAck_builder{Sequence: &sequence, Error: ptr("failed")}.Build())
+	require.Error(t, legacy)
+	assert.NotErrorIs(t, legacy, ErrRejected)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,223 @@
+package driver
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"go-metrics-service/internal/common/hashing"
+	"go-metrics-service/internal/common/protocol"
+	"net"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrHashMismatch = errors.New("response hash mismatch")
+	// ErrUnsignedResponse means server did not sign response while agent has hash key,
+	// it may come from server which does not know the key.
+	ErrUnsignedResponse = errors.New("response is not signed")
+)
+
+// identityInterceptors attach agent ID and outbound address to every call.
+func identityInterceptors(agentID string, ip net.IP) (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	pairs := []string{protocol.AgentIDMetadata, agentID}
+	if ip != nil {
+		pairs = append(pairs, protocol.RealIPMetadata, ip.String())
+	}
+	unary := func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		return invoker(metadata.AppendToOutgoingContext(ctx, pairs...), method, req, reply, cc, opts...)
+	}
+	stream := func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		return streamer(metadata.AppendToOutgoingContext(ctx, pairs...), desc, cc, method, opts...)
+	}
+	return unary, stream
+}
+
+// hashInterceptors sign requests and verify responses, which server must sign. Unary requests, stream
+// openings and stream batches are signed with fresh timestamp and nonce, so server rejects them replayed.
+// Key ID is sent in metadata when set, for server to select the agent key.
+func hashInterceptors(hashFactory HashFactory) (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	withKeyID := func(ctx context.Context) context.Context {
+		if keyID := hashFactory.KeyID(); keyID != "" {
+			return metadata.AppendToOutgoingContext(ctx, protocol.HashKeyIDMetadata, keyID)
+		}
+		return ctx
+	}
+	unary := func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		ctx = withKeyID(ctx)
+		if message, ok := req.(proto.Message); ok {
+			timestamp := hashing.FormatTimestamp(time.Now())
+			nonce, err := hashing.NewNonce()
+			if err != nil {
+				return err //nolint:wrapcheck // already wrapped
+			}
+			sum, err := hashing.MessageRequestSum(hashFactory, timestamp, nonce, message)
+			if err != nil {
+				return err //nolint:wrapcheck // already wrapped
+			}
+			ctx = metadata.AppendToOutgoingContext(
+				ctx,
+				protocol.HashMetadata, sum,
+				protocol.TimestampMetadata, timestamp,
+				protocol.NonceMetadata, nonce,
+			)
+		}
+		var header metadata.MD
+		if err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...); err != nil {
+			return err
+		}
+		message, ok := reply.(proto.Message)
+		if !ok {
+			return nil
+		}
+		received := header.Get(protocol.HashMetadata)
+		if len(received) == 0 {
+			return ErrUnsignedResponse
+		}
+		expected, err := hashing.MessageHash(hashFactory, message)
+		if err != nil {
+			return err //nolint:wrapcheck // already wrapped
+		}
+		if !hmac.Equal([]byte(expected), []byte(received[0])) {
+			return ErrHashMismatch
+		}
+		return nil
+	}
+	stream := func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		timestamp := hashing.FormatTimestamp(time.Now())
+		nonce, err := hashing.NewNonce()
+		if err != nil {
+			return nil, err //nolint:wrapcheck // already wrapped
+		}
+		sum, err := hashing.StreamSum(hashFactory, timestamp, nonce, method)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // already wrapped
+		}
+		ctx = metadata.AppendToOutgoingContext(
+			withKeyID(ctx),
+			protocol.HashMetadata, sum,
+			protocol.TimestampMetadata, timestamp,
+			protocol.NonceMetadata, nonce,
+		)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &hashedClientStream{ClientStream: cs, hashFactory: hashFactory}, nil
+	}
+	return unary, stream
+}
+
+type hashedClientStream struct {
+	grpc.ClientStream
+	hashFactory hashing.Factory
+}
+
+func (s *hashedClientStream) SendMsg(m any) error {
+	// Resent batch is stamped again, server recognizes it by batch ID.
+	if stamped, ok := m.(hashing.StampedMessage); ok {
+		if err := hashing.Stamp(stamped, time.Now()); err != nil {
+			return err //nolint:wrapcheck // already wrapped
+		}
+	}
+	if signed, ok := m.(hashing.SignedMessage); ok {
+		if err := hashing.Sign(s.hashFactory, signed); err != nil {
+			return err //nolint:wrapcheck // already wrapped
+		}
+	}
+	return s.ClientStream.SendMsg(m) //nolint:wrapcheck // stream error is returned as is
+}
+
+func (s *hashedClientStream) RecvMsg(m any) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return err //nolint:wrapcheck // stream error is returned as is
+	}
+	signed, ok := m.(hashing.SignedMessage)
+	if !ok {
+		return nil
+	}
+	// Agent with hash key accepts only acks signed by server knowing the key.
+	if signed.GetHash() == "" {
+		return ErrUnsignedResponse
+	}
+	valid, err := hashing.Verify(s.hashFactory, signed)
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped
+	}
+	if !valid {
+		return ErrHashMismatch
+	}
+	return nil
+}
+
+// loggingInterceptors log finished unary calls and opened streams.
+func loggingInterceptors(logger *zap.Logger) (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	unary := func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		logger.Debug(
+			"Call finished",
+			zap.String("method", method),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("code", status.Code(err).String()),
+		)
+		return err
+	}
+	stream := func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		logger.Debug(
+			"Stream opened",
+			zap.String("method", method),
+			zap.String("code", status.Code(err).String()),
+		)
+		return cs, err
+	}
+	return unary, stream
+}
@@ -4,11 +4,11 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	storagePkg "go-metrics-service/internal/agent/storage"
+	"go-metrics-service/internal/common/hashing"
 	"go-metrics-service/internal/common/protocol"
 	"go-metrics-service/pkg/compression"
 	"hash"
@@ -16,6 +16,7 @@ import (
 	"net"
 	"net/http"
 	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -24,14 +25,22 @@ import (
 
 var (
 	ErrServerUnavailable = errors.New("server unavailable")
+	// ErrRejected means server refused updates as invalid, sending them again does not help.
+	ErrRejected = errors.New("updates rejected")
 )
 
 type HashFactory interface {
 	Create() hash.Hash
+	// KeyID names key on server, sent in X-Hash-Key-ID header when set.
+	KeyID() string
 }
 
 type Encoder interface {
 	Encode([]byte) ([]byte, error)
+	// Encryption names format sent in Content-Encryption header.
+	Encryption() string
+	// KeyID names server key sent in X-Key-ID header, header is omitted when empty.
+	KeyID() string
 }
 
 type HTTPDriver struct {
@@ -41,6 +50,7 @@ type HTTPDriver struct {
 	host        string
 	encoder     Encoder
 	ip          net.IP
+	agentID     string
 }
 
 func NewHTTPDriver(
@@ -50,6 +60,7 @@ func NewHTTPDriver(
 	host string,
 	encoder Encoder,
 	ip net.IP,
+	agentID string,
 ) *HTTPDriver {
 	return &HTTPDriver{
 		logger:      logger,
@@ -58,6 +69,7 @@ func NewHTTPDriver(
 		host:        host,
 		encoder:     encoder,
 		ip:          ip,
+		agentID:     agentID,
 	}
 }
 
@@ -83,15 +95,17 @@ func (s *HTTPDriver) SendUpdates(ctx context.Context, metrics []protocol.Metrics
 		R().
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Content-Encoding", "gzip").
-		SetHeader("X-Real-IP", s.ip.String())
+		SetHeader(protocol.RealIPHeader, s.ip.String()).
+		SetHeader(protocol.AgentIDHeader, s.agentID)
+
+	if key := IdempotencyKeyFromContext(ctx); key != "" {
+		req = req.SetHeader(protocol.IdempotencyKeyHeader, key)
+	}
 
 	if s.hashFactory != nil {
-		h := s.hashFactory.Create()
-		_, err = h.Write(body.Bytes())
-		if err != nil {
-			return fmt.Errorf("failed to hash: %w", err)
+		if err := s.sign(req, body.Bytes()); err != nil {
+			return err
 		}
-		req = req.SetHeader(protocol.HashHeader, hex.EncodeToString(h.Sum(nil)))
 	}
 
 	bodyBytes := body.Bytes()
@@ -102,6 +116,10 @@ func (s *HTTPDriver) SendUpdates(ctx context.Context, metrics []protocol.Metrics
 			return fmt.Errorf("failed to encode request: %w", err)
 		}
 		bodyBytes = encoded
+		req = req.SetHeader(protocol.ContentEncryptionHeader, s.encoder.Encryption())
+		if keyID := s.encoder.KeyID(); keyID != "" {
+			req = req.SetHeader(protocol.KeyIDHeader, keyID)
+		}
 	}
 
 	resp, err := req.
@@ -116,12 +134,38 @@ func (s *HTTPDriver) SendUpdates(ctx context.Context, metrics []protocol.Metrics
 		}
 		return fmt.Errorf("%w: update failed", err)
 	}
+	// Only invalid batch is dropped. Auth, replay and timestamp failures are answered with other
+	// codes and pass once key or clock is fixed, so batch stays pending.
+	if resp.StatusCode() == http.StatusBadRequest {
+		return fmt.Errorf("%w: %s responded %d", ErrRejected, s.host, resp.StatusCode())
+	}
 	if resp.StatusCode() != http.StatusOK {
 		return fmt.Errorf("failed to send updates to %s: %d", s.host, resp.StatusCode())
 	}
 	return nil
 }
 
+// sign sets hash of body bound to fresh timestamp and nonce, so server rejects the request
+// replayed out of its replay window.
+func (s *HTTPDriver) sign(req *resty.Request, body []byte) error {
+	timestamp := hashing.FormatTimestamp(time.Now())
+	nonce, err := hashing.NewNonce()
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped
+	}
+	sum, err := hashing.RequestSum(s.hashFactory, timestamp, nonce, body)
+	if err != nil {
+		return fmt.Errorf("failed to hash: %w", err)
+	}
+	req.SetHeader(protocol.HashHeader, sum).
+		SetHeader(protocol.TimestampHeader, timestamp).
+		SetHeader(protocol.NonceHeader, nonce)
+	if keyID := s.hashFactory.KeyID(); keyID != "" {
+		req.SetHeader(protocol.HashKeyIDHeader, keyID)
+	}
+	return nil
+}
+
 func (s *HTTPDriver) createURL(path string) string {
 	return fmt.Sprintf("http://%s%s", s.host, path)
 }
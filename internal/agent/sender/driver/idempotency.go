@@ -0,0 +1,37 @@
+package driver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+type contextKey int
+
+const (
+	idempotencyKey contextKey = iota
+)
+
+const idempotencyKeySize = 16
+
+// NewIdempotencyKey returns random key of updates batch.
+func NewIdempotencyKey() (string, error) {
+	key := make([]byte, idempotencyKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate idempotency key: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// WithIdempotencyKey returns context marking updates sent with it as attempts
+// to send the same batch, server applies them once. The key is sent as batch ID over gRPC.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey, key)
+}
+
+// IdempotencyKeyFromContext returns idempotency key of updates batch, empty when not set.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey).(string)
+	return key
+}
@@ -0,0 +1,270 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/pkg/circuitbreaker"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Policy decides when updates sent to several destinations are considered sent.
+type Policy string
+
+const (
+	// PolicyAll requires every destination to accept updates. Batch failed on some
+	// destinations is resent only to them.
+	PolicyAll Policy = "all"
+	// PolicyAny sends updates to every destination and succeeds when one accepted them.
+	PolicyAny Policy = "any"
+	// PolicyFailover sends updates to the first destination accepting them, in configured order.
+	PolicyFailover Policy = "failover"
+)
+
+// deliveryStateTTL bounds how long destinations which accepted batch are remembered.
+// Batch resent after that goes to every destination and is deduplicated by servers.
+const deliveryStateTTL = 24 * time.Hour
+
+var (
+	ErrUnknownPolicy = errors.New("unknown send policy")
+	// ErrPartialDelivery is returned when some destinations accepted updates and others failed,
+	// it is progress rather than outage, the rest is resent to failed destinations only.
+	ErrPartialDelivery = errors.New("updates delivered partially")
+)
+
+// ParsePolicy returns policy by its name.
+func ParsePolicy(raw string) (Policy, error) {
+	switch policy := Policy(raw); policy {
+	case PolicyAll, PolicyAny, PolicyFailover:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: '%s'", ErrUnknownPolicy, raw)
+	}
+}
+
+type UpdatesSender interface {
+	SendUpdates(ctx context.Context, metrics []protocol.Metrics) error
+}
+
+// Destination is a named server updates are sent to.
+type Destination struct {
+	Driver UpdatesSender
+	Name   string
+}
+
+type destination struct {
+	Destination
+	breaker *circuitbreaker.Breaker
+}
+
+type delivery struct {
+	done      map[int]struct{}
+	updatedAt time.Time
+}
+
+// MultiDriver sends updates to several destinations. Every destination has its own
+// circuit breaker, so unavailable destination is not called until it is probed again
+// while the others keep receiving updates.
+type MultiDriver struct {
+	logger       *zap.Logger
+	mux          *sync.Mutex
+	now          func() time.Time
+	delivered    map[string]*delivery
+	policy       Policy
+	destinations []destination
+}
+
+func NewMultiDriver(
+	policy Policy,
+	destinations []Destination,
+	breaker circuitbreaker.Config,
+	logger *zap.Logger,
+) *MultiDriver {
+	d := &MultiDriver{
+		logger:       logger,
+		mux:          &sync.Mutex{},
+		now:          time.Now,
+		delivered:    make(map[string]*delivery),
+		policy:       policy,
+		destinations: make([]destination, 0, len(destinations)),
+	}
+	for _, dst := range destinations {
+		d.destinations = append(d.destinations, destination{
+			Destination: dst,
+			breaker: circuitbreaker.New(breaker, func(from, to circuitbreaker.State) {
+				logger.Warn(
+					"destination circuit breaker state changed",
+					zap.String("destination", dst.Name),
+					zap.Stringer("from", from),
+					zap.Stringer("to", to),
+				)
+			}),
+		})
+	}
+	return d
+}
+
+func (d *MultiDriver) SendUpdates(ctx context.Context, metrics []protocol.Metrics) error {
+	switch d.policy {
+	case PolicyAny:
+		return d.sendAny(ctx, metrics)
+	case PolicyFailover:
+		return d.sendFailover(ctx, metrics)
+	default:
+		return d.sendAll(ctx, metrics)
+	}
+}
+
+func (d *MultiDriver) sendAll(ctx context.Context, metrics []protocol.Metrics) error {
+	batchID := IdempotencyKeyFromContext(ctx)
+	pending := d.pendingDestinations(batchID)
+	errs := d.sendParallel(ctx, pending, metrics)
+	failed := make([]error, 0)
+	rejected := make([]error, 0)
+	delivered := len(d.destinations) - len(pending)
+	for i, idx := range pending {
+		err := errs[i]
+		if err != nil && !errors.Is(err, ErrRejected) {
+			failed = append(failed, err)
+			continue
+		}
+		if err != nil {
+			rejected = append(rejected, err)
+		}
+		delivered++
+		d.markDelivered(batchID, idx)
+	}
+	if len(failed) > 0 && delivered > 0 {
+		return fmt.Errorf("%w: %w", ErrPartialDelivery, errors.Join(failed...))
+	}
+	if len(failed) > 0 {
+		return errors.Join(failed...)
+	}
+	d.forget(batchID)
+	return errors.Join(rejected...)
+}
+
+func (d *MultiDriver) sendAny(ctx context.Context, metrics []protocol.Metrics) error {
+	all := make([]int, len(d.destinations))
+	for i := range all {
+		all[i] = i
+	}
+	errs := d.sendParallel(ctx, all, metrics)
+	failed := make([]error, 0)
+	rejected := make([]error, 0)
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, ErrRejected):
+			rejected = append(rejected, err)
+		default:
+			failed = append(failed, err)
+		}
+	}
+	// Batch is worth retrying while some destination may still accept it.
+	if len(failed) > 0 {
+		return errors.Join(failed...)
+	}
+	return errors.Join(rejected...)
+}
+
+func (d *MultiDriver) sendFailover(ctx context.Context, metrics []protocol.Metrics) error {
+	errs := make([]error, 0, len(d.destinations))
+	for i := range d.destinations {
+		err := d.send(ctx, &d.destinations[i], metrics)
+		if err == nil || errors.Is(err, ErrRejected) {
+			return err
+		}
+		if i+1 < len(d.destinations) {
+			d.logger.Warn(
+				"destination failed, sending to next one",
+				zap.String("destination", d.destinations[i].Name),
+				zap.Error(err),
+			)
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// sendParallel sends updates to destinations with given indexes and returns errors in the same order.
+func (d *MultiDriver) sendParallel(ctx context.Context, indexes []int, metrics []protocol.Metrics) []error {
+	errs := make([]error, len(indexes))
+	wg := &sync.WaitGroup{}
+	for i, idx := range indexes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.send(ctx, &d.destinations[idx], metrics)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// send sends updates to destination through its breaker. Rejection proves destination
+// is reachable, so it does not count as breaker failure.
+func (d *MultiDriver) send(ctx context.Context, dst *destination, metrics []protocol.Metrics) error {
+	if err := dst.breaker.Allow(); err != nil {
+		return fmt.Errorf("%s: %w", dst.Name, err)
+	}
+	err := dst.Driver.SendUpdates(ctx, metrics)
+	if err != nil && !errors.Is(err, ErrRejected) {
+		dst.breaker.Failure()
+		return fmt.Errorf("%s: %w", dst.Name, err)
+	}
+	dst.breaker.Success()
+	if err != nil {
+		return fmt.Errorf("%s: %w", dst.Name, err)
+	}
+	return nil
+}
+
+// pendingDestinations returns indexes of destinations which have not accepted batch yet.
+func (d *MultiDriver) pendingDestinations(batchID string) []int {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	now := d.now()
+	for id, state := range d.delivered {
+		if now.Sub(state.updatedAt) > deliveryStateTTL {
+			delete(d.delivered, id)
+		}
+	}
+	pending := make([]int, 0, len(d.destinations))
+	state := d.delivered[batchID]
+	for i := range d.destinations {
+		if state != nil {
+			if _, ok := state.done[i]; ok {
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+	return pending
+}
+
+func (d *MultiDriver) markDelivered(batchID string, idx int) {
+	if batchID == "" {
+		return
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	state, ok := d.delivered[batchID]
+	if !ok {
+		state = &delivery{done: make(map[int]struct{})}
+		d.delivered[batchID] = state
+	}
+	state.done[idx] = struct{}{}
+	state.updatedAt = d.now()
+}
+
+func (d *MultiDriver) forget(batchID string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	delete(d.delivered, batchID)
+}
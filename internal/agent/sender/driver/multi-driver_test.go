@@ -0,0 +1,119 @@
+package driver
+
+import (
+	"context"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/pkg/circuitbreaker"
+	"go-metrics-service/pkg/timeutils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeSender struct {
+	mux   *sync.Mutex
+	err   error
+	calls int
+}
+
+func newFakeSender(err error) *fakeSender {
+	return &fakeSender{mux: &sync.Mutex{}, err: err}
+}
+
+func (s *fakeSender) SendUpdates(_ context.Context, _ []protocol.Metrics) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.calls++
+	return s.err
+}
+
+func (s *fakeSender) set(err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.err = err
+}
+
+func (s *fakeSender) count() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.calls
+}
+
+func newTestMultiDriver(policy Policy, senders ...*fakeSender) *MultiDriver {
+	destinations := make([]Destination, len(senders))
+	for i, s := range senders {
+		destinations[i] = Destination{Driver: s, Name: string(rune('a' + i))}
+	}
+	breaker := circuitbreaker.Config{
+		OpenBackoff:      timeutils.Backoff{Initial: time.Hour},
+		FailureThreshold: 2,
+	}
+	return NewMultiDriver(policy, destinations, breaker, zap.NewNop())
+}
+
+func TestMultiDriverAll(t *testing.T) {
+	primary := newFakeSender(nil)
+	secondary := newFakeSender(ErrServerUnavailable)
+	d := newTestMultiDriver(PolicyAll, primary, secondary)
+	ctx := WithIdempotencyKey(context.Background(), "batch")
+
+	err := d.SendUpdates(ctx, nil)
+	assert.ErrorIs(t, err, ErrServerUnavailable)
+	assert.ErrorIs(t, err, ErrPartialDelivery)
+	secondary.set(nil)
+	require.NoError(t, d.SendUpdates(ctx, nil))
+	assert.Equal(t, 1, primary.count(), "batch accepted by destination is not resent to it")
+	assert.Equal(t, 2, secondary.count())
+
+	require.NoError(t, d.SendUpdates(ctx, nil))
+	assert.Equal(t, 2, primary.count(), "delivery state is forgotten once every destination accepted batch")
+
+	secondary.set(ErrRejected)
+	assert.ErrorIs(t, d.SendUpdates(context.Background(), nil), ErrRejected)
+}
+
+func TestMultiDriverAny(t *testing.T) {
+	primary := newFakeSender(ErrServerUnavailable)
+	secondary := newFakeSender(nil)
+	d := newTestMultiDriver(PolicyAny, primary, secondary)
+
+	require.NoError(t, d.SendUpdates(context.Background(), nil))
+	secondary.set(ErrRejected)
+	err := d.SendUpdates(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrServerUnavailable)
+	assert.NotErrorIs(t, err, ErrRejected, "batch is retried while some destination may accept it")
+
+	// Primary breaker is open after two failures, so it is not called anymore.
+	primary.set(ErrRejected)
+	assert.ErrorIs(t, d.SendUpdates(context.Background(), nil), circuitbreaker.ErrOpen)
+	assert.Equal(t, 2, primary.count())
+}
+
+func TestMultiDriverFailover(t *testing.T) {
+	primary := newFakeSender(nil)
+	secondary := newFakeSender(nil)
+	d := newTestMultiDriver(PolicyFailover, primary, secondary)
+
+	require.NoError(t, d.SendUpdates(context.Background(), nil))
+	assert.Equal(t, 0, secondary.count())
+
+	primary.set(ErrServerUnavailable)
+	require.NoError(t, d.SendUpdates(context.Background(), nil))
+	assert.Equal(t, 1, secondary.count())
+
+	primary.set(ErrRejected)
+	assert.ErrorIs(t, d.SendUpdates(context.Background(), nil), ErrRejected)
+	assert.Equal(t, 1, secondary.count(), "rejected updates are not sent to next destination")
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("failover")
+	require.NoError(t, err)
+	assert.Equal(t, PolicyFailover, policy)
+	_, err = ParsePolicy("some")
+	assert.ErrorIs(t, err, ErrUnknownPolicy)
+}
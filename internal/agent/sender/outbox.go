@@ -0,0 +1,183 @@
+package sender
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-metrics-service/internal/agent/spool"
+	"go-metrics-service/internal/common/protocol"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// outbox keeps counter batches from the first sending attempt until server accepts them.
+// Pending returns batches in the order they were put.
+type outbox interface {
+	Put(d deltas) error
+	Pending() ([]deltas, error)
+	Remove(id string) error
+	// Evicted returns number of batches dropped by outbox limits.
+	Evicted() int64
+}
+
+type memoryEntry struct {
+	createdAt time.Time
+	batch     deltas
+	size      int64
+}
+
+// memoryOutbox keeps batches until agent stops. It has the same limits as spool:
+// the oldest batches are evicted when outbox exceeds size or age limits.
+type memoryOutbox struct {
+	logger  *zap.Logger
+	mux     *sync.Mutex
+	now     func() time.Time
+	entries []memoryEntry
+	limits  OutboxLimits
+	size    int64
+	evicted atomic.Int64
+}
+
+func newMemoryOutbox(limits OutboxLimits, logger *zap.Logger) *memoryOutbox {
+	return &memoryOutbox{
+		logger:  logger,
+		mux:     &sync.Mutex{},
+		now:     time.Now,
+		entries: make([]memoryEntry, 0),
+		limits:  limits,
+	}
+}
+
+func (o *memoryOutbox) Put(d deltas) error {
+	// Batch is accounted by its spool encoding size, so both outboxes have the same capacity.
+	payload, err := encodeDeltas(d)
+	if err != nil {
+		return err
+	}
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.entries = append(o.entries, memoryEntry{
+		createdAt: o.now(),
+		batch:     d,
+		size:      int64(len(payload)),
+	})
+	o.size += int64(len(payload))
+	o.evict()
+	return nil
+}
+
+func (o *memoryOutbox) Pending() ([]deltas, error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.evict()
+	res := make([]deltas, 0, len(o.entries))
+	for _, e := range o.entries {
+		res = append(res, e.batch)
+	}
+	return res, nil
+}
+
+func (o *memoryOutbox) Remove(id string) error {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.entries = slices.DeleteFunc(o.entries, func(e memoryEntry) bool {
+		if e.batch.id != id {
+			return false
+		}
+		o.size -= e.size
+		return true
+	})
+	return nil
+}
+
+func (o *memoryOutbox) Evicted() int64 {
+	return o.evicted.Load()
+}
+
+// evict drops the oldest batches while outbox exceeds its limits.
+func (o *memoryOutbox) evict() {
+	border := time.Time{}
+	if o.limits.MaxAge > 0 {
+		border = o.now().Add(-o.limits.MaxAge)
+	}
+	for len(o.entries) > 0 {
+		oldest := o.entries[0]
+		tooBig := o.limits.MaxBytes > 0 && o.size > o.limits.MaxBytes
+		tooOld := !border.IsZero() && oldest.createdAt.Before(border)
+		if !tooBig && !tooOld {
+			return
+		}
+		o.entries = o.entries[1:]
+		o.size -= oldest.size
+		o.evicted.Add(1)
+		o.logger.Warn(
+			"unsent batch evicted",
+			zap.String("batch", oldest.batch.id),
+			zap.Bool("size_limit", tooBig),
+			zap.Bool("age_limit", tooOld),
+		)
+	}
+}
+
+// spoolOutbox keeps batches on disk across agent restarts.
+type spoolOutbox struct {
+	spool  *spool.Spool
+	logger *zap.Logger
+}
+
+type spooledDeltas struct {
+	Counters   map[string]int64                   `json:"counters"`
+	Histograms map[string]protocol.HistogramValue `json:"histograms"`
+}
+
+func encodeDeltas(d deltas) ([]byte, error) {
+	payload, err := json.Marshal(spooledDeltas{Counters: d.counters, Histograms: d.histograms})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode batch: %w", err)
+	}
+	return payload, nil
+}
+
+func (o *spoolOutbox) Put(d deltas) error {
+	payload, err := encodeDeltas(d)
+	if err != nil {
+		return err
+	}
+	return o.spool.Put(d.id, payload) //nolint:wrapcheck // already wrapped
+}
+
+func (o *spoolOutbox) Pending() ([]deltas, error) {
+	records, err := o.spool.Pending()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped
+	}
+	res := make([]deltas, 0, len(records))
+	for _, record := range records {
+		var decoded spooledDeltas
+		if err := json.Unmarshal(record.Payload, &decoded); err != nil {
+			// Corrupted batch can never be sent, keeping it would only block the rest.
+			o.logger.Error("dropping corrupted spooled batch", zap.String("batch", record.ID), zap.Error(err))
+			if err := o.spool.Remove(record.ID); err != nil {
+				return nil, err //nolint:wrapcheck // already wrapped
+			}
+			continue
+		}
+		res = append(res, deltas{
+			id:         record.ID,
+			counters:   decoded.Counters,
+			histograms: decoded.Histograms,
+		})
+	}
+	return res, nil
+}
+
+func (o *spoolOutbox) Remove(id string) error {
+	return o.spool.Remove(id) //nolint:wrapcheck // already wrapped
+}
+
+func (o *spoolOutbox) Evicted() int64 {
+	return o.spool.Evicted()
+}
@@ -0,0 +1,47 @@
+package sender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMemoryOutboxLimits(t *testing.T) {
+	batch := func(id string) deltas {
+		return deltas{id: id, counters: map[string]int64{"PollCount": 1}}
+	}
+	payload, err := encodeDeltas(batch("a"))
+	require.NoError(t, err)
+
+	o := newMemoryOutbox(OutboxLimits{MaxBytes: 2 * int64(len(payload)), MaxAge: time.Hour}, zap.NewNop())
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	o.now = func() time.Time { return now }
+
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, o.Put(batch(id)))
+		now = now.Add(30 * time.Minute)
+	}
+	ids := func() []string {
+		pending, err := o.Pending()
+		require.NoError(t, err)
+		res := make([]string, 0, len(pending))
+		for _, d := range pending {
+			res = append(res, d.id)
+		}
+		return res
+	}
+	assert.Equal(t, []string{"b", "c"}, ids(), "the oldest batch is evicted over size limit")
+	assert.Equal(t, int64(1), o.Evicted())
+
+	now = now.Add(15 * time.Minute)
+	assert.Equal(t, []string{"c"}, ids(), "batch older than age limit is evicted")
+	assert.Equal(t, int64(2), o.Evicted())
+
+	require.NoError(t, o.Remove("c"))
+	require.NoError(t, o.Put(batch("d")))
+	require.NoError(t, o.Put(batch("e")))
+	assert.Equal(t, []string{"d", "e"}, ids(), "removed batch frees its size")
+}
@@ -2,43 +2,100 @@ package sender
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"go-metrics-service/internal/agent/sender/driver"
+	"go-metrics-service/internal/agent/spool"
 	storagePkg "go-metrics-service/internal/agent/storage"
 	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/pkg/circuitbreaker"
 	"go-metrics-service/pkg/gohelpers"
 	"go-metrics-service/pkg/timeutils"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// spoolEvictedMetricName counts batches evicted by outbox limits, in memory or in spool.
+const spoolEvictedMetricName = "SpoolEvictedBatches"
+
+// Config controls sending retries. Breaker is shared by every worker, so during
+// server outage workers stop sending until breaker lets a probe through. Breaker is nil
+// for driver guarding its destinations with own breakers, e.g. driver.MultiDriver.
+type Config struct {
+	Breaker      *circuitbreaker.Config
+	RetryBackoff timeutils.Backoff
+	// OutboxLimits bound memory outbox, spool has its own limits.
+	OutboxLimits OutboxLimits
+}
+
+// OutboxLimits bound unsent batches kept in memory. Zero limits disable corresponding eviction.
+type OutboxLimits struct {
+	MaxBytes int64
+	MaxAge   time.Duration
+}
+
 type Driver interface {
 	SendUpdates(ctx context.Context, metrics []protocol.Metrics) error
 }
 
+// deltas are accumulated values which must be sent exactly once.
+// Batch keeps its ID across retries and resends, so server applies it once.
+type deltas struct {
+	id         string
+	counters   map[string]int64
+	histograms map[string]protocol.HistogramValue
+}
+
 type Sender struct {
-	logger        *zap.Logger
-	storage       *storagePkg.Storage
-	doneCh        chan struct{}
-	countersCh    chan map[string]int64
-	gaugesCh      chan struct{}
-	attemptsDelay []time.Duration
-	driver        Driver
+	logger       *zap.Logger
+	storage      *storagePkg.Storage
+	doneCh       chan struct{}
+	countersCh   chan deltas
+	gaugesCh     chan struct{}
+	replayCh     chan struct{}
+	labels       map[string]string
+	retryBackoff timeutils.Backoff
+	breaker      *circuitbreaker.Breaker
+	driver       Driver
+	outbox       outbox
+	inFlightMux  *sync.Mutex
+	inFlight     map[string]struct{}
 }
 
 func New(
-	attemptsDelay []time.Duration,
+	cfg Config,
 	storage *storagePkg.Storage,
 	logger *zap.Logger,
 	driver Driver,
+	labels map[string]string,
+	sp *spool.Spool,
 ) *Sender {
+	var o outbox = newMemoryOutbox(cfg.OutboxLimits, logger)
+	if sp != nil {
+		o = &spoolOutbox{spool: sp, logger: logger}
+	}
+	var breaker *circuitbreaker.Breaker
+	if cfg.Breaker != nil {
+		breaker = circuitbreaker.New(*cfg.Breaker, func(from, to circuitbreaker.State) {
+			logger.Warn("sending circuit breaker state changed", zap.Stringer("from", from), zap.Stringer("to", to))
+		})
+	}
 	return &Sender{
-		storage:       storage,
-		logger:        logger,
-		doneCh:        make(chan struct{}),
-		countersCh:    make(chan map[string]int64),
-		gaugesCh:      make(chan struct{}),
-		attemptsDelay: attemptsDelay,
-		driver:        driver,
+		storage:      storage,
+		logger:       logger,
+		doneCh:       make(chan struct{}),
+		countersCh:   make(chan deltas),
+		gaugesCh:     make(chan struct{}),
+		replayCh:     make(chan struct{}, 1),
+		retryBackoff: cfg.RetryBackoff,
+		breaker:      breaker,
+		driver:       driver,
+		labels:       labels,
+		outbox:       o,
+		inFlightMux:  &sync.Mutex{},
+		inFlight:     make(map[string]struct{}),
 	}
 }
 
@@ -54,10 +111,16 @@ func (s *Sender) Start(interval time.Duration, workersCount int) chan error {
 			func() {},
 			s.gaugesCh,
 		),
+		gohelpers.StartProcess[struct{}](
+			s.doneCh,
+			s.replayPending,
+			func() {},
+			s.replayCh,
+		),
 	)
 
 	for range workersCount {
-		errChs = append(errChs, gohelpers.StartProcess[map[string]int64](
+		errChs = append(errChs, gohelpers.StartProcess[deltas](
 			s.doneCh,
 			s.sendCountersUpdate,
 			func() {},
@@ -72,33 +135,142 @@ func (s *Sender) Stop() {
 	close(s.doneCh)
 }
 
+// Schedule sends deltas accumulated since the last call and wakes up replay of undelivered batches.
+// Batch is put to outbox before the first attempt, so it survives failures and, with spool, restarts.
+// Schedule never waits for busy workers: gauges are sent on the next tick and batch not taken by
+// a worker is sent by replay, so ticker keeps collecting deltas during slow sends.
 func (s *Sender) Schedule(_ context.Context) error {
-	s.gaugesCh <- struct{}{}
-	c := s.storage.ConsumeUncommitedCounters()
-	s.countersCh <- c
+	select {
+	case s.gaugesCh <- struct{}{}:
+	default:
+		s.logger.Debug("previous gauges update is still being sent")
+	}
+	id, err := driver.NewIdempotencyKey()
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped
+	}
+	d := deltas{
+		id:         id,
+		counters:   s.storage.ConsumeUncommitedCounters(),
+		histograms: s.storage.ConsumeHistograms(),
+	}
+	// Empty batch is not sent, server would record its ID as applied batch for nothing.
+	if !d.empty() {
+		if err := s.outbox.Put(d); err != nil {
+			s.logger.Error("failed to keep batch in outbox", zap.String("batch", d.id), zap.Error(err))
+		}
+		if s.acquire(d.id) {
+			select {
+			case s.countersCh <- d:
+			default:
+				s.release(d.id)
+			}
+		}
+	}
+	s.reportEvicted()
+	select {
+	case s.replayCh <- struct{}{}:
+	default:
+	}
 	return nil
 }
 
-func (s *Sender) sendCountersUpdate(ctx context.Context, counterDeltas map[string]int64) error {
-	metricsToSend := make([]protocol.Metrics, 0, len(counterDeltas))
+// replayPending resends undelivered batches from one goroutine in the order they were put.
+// Replay stops at the first batch which is not delivered, the rest wait for the next round.
+func (s *Sender) replayPending(ctx context.Context, _ struct{}) error {
+	pending, err := s.outbox.Pending()
+	if err != nil {
+		return fmt.Errorf("failed to read undelivered batches: %w", err)
+	}
+	for _, d := range pending {
+		if !s.acquire(d.id) {
+			continue
+		}
+		if err := s.sendCountersUpdate(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d deltas) empty() bool {
+	return len(d.counters) == 0 && len(d.histograms) == 0
+}
 
-	for k, v := range counterDeltas {
+// acquire marks batch as being sent, false is returned if it is already in flight.
+func (s *Sender) acquire(id string) bool {
+	s.inFlightMux.Lock()
+	defer s.inFlightMux.Unlock()
+	if _, ok := s.inFlight[id]; ok {
+		return false
+	}
+	s.inFlight[id] = struct{}{}
+	return true
+}
+
+func (s *Sender) release(id string) {
+	s.inFlightMux.Lock()
+	defer s.inFlightMux.Unlock()
+	delete(s.inFlight, id)
+}
+
+// reportEvicted exposes count of batches dropped by outbox limits as agent counter.
+func (s *Sender) reportEvicted() {
+	if evicted := s.outbox.Evicted(); evicted > 0 {
+		s.storage.SetCounter(spoolEvictedMetricName, evicted)
+	}
+}
+
+func (s *Sender) sendCountersUpdate(ctx context.Context, d deltas) error {
+	metricsToSend := make([]protocol.Metrics, 0, len(d.counters)+len(d.histograms))
+
+	for k, v := range d.counters {
 		val := v
 		metricsToSend = append(
 			metricsToSend,
 			protocol.Metrics{
-				ID:    k,
-				MType: protocol.Counter,
-				Delta: &val,
+				ID:     k,
+				MType:  protocol.Counter,
+				Delta:  &val,
+				Labels: s.labels,
 			},
 		)
 	}
 
-	return s.sendUpdatesWithRetry(ctx, metricsToSend)
+	for k, v := range d.histograms {
+		val := v
+		metricsToSend = append(
+			metricsToSend,
+			protocol.Metrics{
+				ID:        k,
+				MType:     protocol.Histogram,
+				Histogram: &val,
+				Labels:    s.labels,
+			},
+		)
+	}
+
+	defer s.release(d.id)
+	err := s.sendUpdatesWithRetry(driver.WithIdempotencyKey(ctx, d.id), metricsToSend)
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		s.logger.Debug("batch postponed while server is unavailable", zap.String("batch", d.id))
+		return nil
+	}
+	if err != nil && !errors.Is(err, driver.ErrRejected) {
+		s.logger.Warn("batch kept for resending", zap.String("batch", d.id), zap.Int("metrics", len(metricsToSend)))
+		return err
+	}
+	if removeErr := s.outbox.Remove(d.id); removeErr != nil {
+		s.logger.Error("failed to remove delivered batch", zap.String("batch", d.id), zap.Error(removeErr))
+	}
+	return err
 }
 
+// sendGaugesUpdate sends gauges changed since the last successful send without retries.
+// Failed values stay uncommitted and are overwritten by newer ones, so only the latest
+// snapshot is sent once server is back.
 func (s *Sender) sendGaugesUpdate(ctx context.Context, _ struct{}) error {
-	return s.storage.HandleUncommitedGauges( //nolint:wrapcheck // wrapping unnecessary
+	err := s.storage.HandleUncommitedGauges(
 		func(uncommitedValues map[string]float64) error {
 			metricsToSend := make([]protocol.Metrics, 0, len(uncommitedValues))
 
@@ -107,30 +279,56 @@ func (s *Sender) sendGaugesUpdate(ctx context.Context, _ struct{}) error {
 				metricsToSend = append(
 					metricsToSend,
 					protocol.Metrics{
-						ID:    k,
-						MType: protocol.Gauge,
-						Value: &val,
+						ID:     k,
+						MType:  protocol.Gauge,
+						Value:  &val,
+						Labels: s.labels,
 					},
 				)
 			}
 
 			return s.sendUpdates(ctx, metricsToSend)
 		})
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		s.logger.Debug("gauges postponed while server is unavailable")
+		return nil
+	}
+	return err //nolint:wrapcheck // wrapping unnecessary
 }
 
+// sendUpdatesWithRetry sends deltas with idempotency key from context on every attempt,
+// so batch applied by server whose response was lost is not applied again.
+// Retries stop once breaker opens, batch stays in outbox until the next schedule.
 func (s *Sender) sendUpdatesWithRetry(ctx context.Context, metrics []protocol.Metrics) error {
 	return timeutils.Retry( //nolint:wrapcheck // wrapping unnecessary
 		ctx,
-		s.attemptsDelay,
+		s.retryBackoff.Delays(),
 		func(ctx context.Context) error {
 			return s.sendUpdates(ctx, metrics)
 		},
 		func(err error) bool {
+			if errors.Is(err, circuitbreaker.ErrOpen) {
+				return false
+			}
 			s.logger.Error("sending updates failed", zap.Error(err))
-			return true
+			return !errors.Is(err, driver.ErrRejected)
 		})
 }
 
+// sendUpdates sends metrics through breaker. Rejected request proves server is reachable
+// and partial delivery is progress, so they do not count as breaker failures.
 func (s *Sender) sendUpdates(ctx context.Context, metrics []protocol.Metrics) error {
-	return s.driver.SendUpdates(ctx, metrics)
+	if s.breaker == nil {
+		return s.driver.SendUpdates(ctx, metrics) //nolint:wrapcheck // wrapping unnecessary
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err //nolint:wrapcheck // sentinel error is checked by callers
+	}
+	err := s.driver.SendUpdates(ctx, metrics)
+	if err != nil && !errors.Is(err, driver.ErrRejected) && !errors.Is(err, driver.ErrPartialDelivery) {
+		s.breaker.Failure()
+		return err
+	}
+	s.breaker.Success()
+	return err
 }
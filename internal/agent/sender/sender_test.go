@@ -0,0 +1,178 @@
+package sender
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"go-metrics-service/internal/agent/sender/driver"
+	storagePkg "go-metrics-service/internal/agent/storage"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/pkg/circuitbreaker"
+	"go-metrics-service/pkg/timeutils"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type countingDriver struct {
+	mux     *sync.Mutex
+	err     error
+	batches []string
+	calls   int
+}
+
+func (d *countingDriver) SendUpdates(ctx context.Context, _ []protocol.Metrics) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.calls++
+	d.batches = append(d.batches, driver.IdempotencyKeyFromContext(ctx))
+	return d.err
+}
+
+func (d *countingDriver) set(err error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.err = err
+}
+
+func (d *countingDriver) count() int {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.calls
+}
+
+func TestSenderFailingDestination(t *testing.T) {
+	breaker := circuitbreaker.Config{
+		OpenBackoff:      timeutils.Backoff{Initial: time.Hour},
+		FailureThreshold: 1,
+	}
+	tests := []struct {
+		breaker *circuitbreaker.Config
+		name    string
+	}{
+		{name: "without sender breaker"},
+		{name: "with sender breaker", breaker: &breaker},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthy := &countingDriver{mux: &sync.Mutex{}}
+			failing := &countingDriver{mux: &sync.Mutex{}, err: driver.ErrServerUnavailable}
+			multiDriver := driver.NewMultiDriver(
+				driver.PolicyAll,
+				[]driver.Destination{{Driver: healthy, Name: "healthy"}, {Driver: failing, Name: "failing"}},
+				breaker,
+				zap.NewNop(),
+			)
+			storage := storagePkg.New()
+			cfg := Config{Breaker: tt.breaker, RetryBackoff: timeutils.Backoff{Attempts: 1}}
+			s := New(cfg, storage, zap.NewNop(), multiDriver, nil, nil)
+
+			const rounds = 5
+			for i := range rounds {
+				storage.SetGauge("Alloc", float64(i))
+				_ = s.sendGaugesUpdate(context.Background(), struct{}{})
+				storage.SetCounter("PollCount", 1)
+				d := deltas{id: "batch" + string(rune('0'+i)), counters: storage.ConsumeUncommitedCounters()}
+				_ = s.sendCountersUpdate(context.Background(), d)
+			}
+			// Failing destination opens only its own breaker, healthy one gets every update.
+			assert.Equal(t, 2*rounds, healthy.count())
+			assert.Equal(t, 1, failing.count())
+		})
+	}
+}
+
+func TestSenderScheduleReplay(t *testing.T) {
+	drv := &countingDriver{mux: &sync.Mutex{}, err: driver.ErrServerUnavailable}
+	storage := storagePkg.New()
+	s := New(Config{RetryBackoff: timeutils.Backoff{Attempts: 1}}, storage, zap.NewNop(), drv, nil, nil)
+
+	// No workers are started, Schedule must not wait for them.
+	for i := range 3 {
+		storage.SetCounter("PollCount", int64(i+1))
+		require.NoError(t, s.Schedule(context.Background()))
+	}
+	pending, err := s.outbox.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+
+	require.Error(t, s.replayPending(context.Background(), struct{}{}))
+	assert.Equal(t, []string{pending[0].id}, drv.batches, "replay stops at the first failed batch")
+
+	drv.set(nil)
+	require.NoError(t, s.replayPending(context.Background(), struct{}{}))
+	assert.Equal(t, []string{pending[0].id, pending[0].id, pending[1].id, pending[2].id}, drv.batches)
+	pending, err = s.outbox.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestSenderKeepsUnauthorizedBatch(t *testing.T) {
+	var (
+		calls atomic.Int32
+		total atomic.Int64
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Key rotated on server before agent got it, first request fails.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reader, err := gzip.NewReader(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		var metrics []protocol.Metrics
+		if !assert.NoError(t, json.NewDecoder(reader).Decode(&metrics)) {
+			return
+		}
+		for _, m := range metrics {
+			total.Add(*m.Delta)
+		}
+	}))
+	defer server.Close()
+
+	storage := storagePkg.New()
+	drv := driver.NewHTTPDriver(
+		zap.NewNop(), storage, nil, strings.TrimPrefix(server.URL, "http://"), nil, net.IPv4(127, 0, 0, 1), "agent",
+	)
+	s := New(Config{RetryBackoff: timeutils.Backoff{Attempts: 1}}, storage, zap.NewNop(), drv, nil, nil)
+
+	storage.SetCounter("PollCount", 2)
+	require.NoError(t, s.Schedule(context.Background()))
+	require.Error(t, s.replayPending(context.Background(), struct{}{}))
+
+	storage.SetCounter("PollCount", 5)
+	require.NoError(t, s.Schedule(context.Background()))
+	require.NoError(t, s.replayPending(context.Background(), struct{}{}))
+
+	// Deltas of the rejected batch are not lost, server gets the full counter value.
+	assert.Equal(t, int64(5), total.Load())
+	pending, err := s.outbox.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestSenderScheduleSkipsEmptyBatch(t *testing.T) {
+	storage := storagePkg.New()
+	s := New(Config{}, storage, zap.NewNop(), &countingDriver{mux: &sync.Mutex{}}, nil, nil)
+	// Buffered channel stands for idle counters worker.
+	s.countersCh = make(chan deltas, 1)
+
+	require.NoError(t, s.Schedule(context.Background()))
+	assert.Empty(t, s.countersCh, "empty batch is not sent")
+
+	storage.SetCounter("PollCount", 1)
+	require.NoError(t, s.Schedule(context.Background()))
+	require.Len(t, s.countersCh, 1)
+	assert.Equal(t, map[string]int64{"PollCount": 1}, (<-s.countersCh).counters)
+}
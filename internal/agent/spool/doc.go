@@ -0,0 +1,2 @@
+// Package spool contains on-disk outbox keeping unsent batches across agent restarts
+package spool
@@ -0,0 +1,281 @@
+package spool
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	recordExt = ".batch"
+	tmpPrefix = ".tmp-"
+	dirPerm   = 0o750
+	filePerm  = 0o600
+)
+
+// Config controls spool location and limits. Zero limits disable corresponding eviction.
+type Config struct {
+	Dir      string
+	MaxBytes int64
+	MaxAge   time.Duration
+}
+
+// Record is a batch kept in spool.
+type Record struct {
+	CreatedAt time.Time
+	ID        string
+	Payload   []byte
+}
+
+type entry struct {
+	createdAt time.Time
+	id        string
+	path      string
+	size      int64
+	seq       uint64
+}
+
+// Spool is a write-ahead outbox: every batch is written to its own file before being sent
+// and removed once delivered. Batches are kept in write order, the oldest ones are evicted
+// when spool exceeds size or age limits.
+type Spool struct {
+	logger  *zap.Logger
+	mux     *sync.Mutex
+	now     func() time.Time
+	cfg     Config
+	entries []entry
+	size    int64
+	nextSeq uint64
+	evicted atomic.Int64
+}
+
+// Open creates spool directory if needed and loads batches left by previous runs.
+func Open(cfg Config, logger *zap.Logger) (*Spool, error) {
+	if err := os.MkdirAll(cfg.Dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	files, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	s := &Spool{
+		logger:  logger,
+		mux:     &sync.Mutex{},
+		now:     time.Now,
+		cfg:     cfg,
+		entries: make([]entry, 0, len(files)),
+	}
+	for _, file := range files {
+		path := filepath.Join(cfg.Dir, file.Name())
+		if strings.HasPrefix(file.Name(), tmpPrefix) {
+			// Batch was not fully written, it was never sent either.
+			if err := os.Remove(path); err != nil {
+				logger.Warn("failed to remove incomplete spool file", zap.String("path", path), zap.Error(err))
+			}
+			continue
+		}
+		seq, id, ok := parseName(file.Name())
+		if !ok || file.IsDir() {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat spool file: %w", err)
+		}
+		s.entries = append(s.entries, entry{
+			createdAt: info.ModTime(),
+			id:        id,
+			path:      path,
+			size:      info.Size(),
+			seq:       seq,
+		})
+		s.size += info.Size()
+		s.nextSeq = max(s.nextSeq, seq+1)
+	}
+	slices.SortFunc(s.entries, func(a, b entry) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	if len(s.entries) > 0 {
+		logger.Info("spooled batches loaded", zap.Int("count", len(s.entries)), zap.Int64("bytes", s.size))
+	}
+	return s, nil
+}
+
+// Put durably writes batch to spool and evicts the oldest batches exceeding limits.
+func (s *Spool) Put(id string, payload []byte) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	seq := s.nextSeq
+	s.nextSeq++
+	name := formatName(seq, id)
+	path := filepath.Join(s.cfg.Dir, name)
+	if err := writeFileSync(s.cfg.Dir, tmpPrefix+name, name, payload); err != nil {
+		return err
+	}
+	s.entries = append(s.entries, entry{
+		createdAt: s.now(),
+		id:        id,
+		path:      path,
+		size:      int64(len(payload)),
+		seq:       seq,
+	})
+	s.size += int64(len(payload))
+	s.evict()
+	return nil
+}
+
+// Pending returns batches kept in spool in write order, batches older than age limit are evicted.
+func (s *Spool) Pending() ([]Record, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.evict()
+	records := make([]Record, 0, len(s.entries))
+	for _, e := range s.entries {
+		payload, err := os.ReadFile(e.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spooled batch: %w", err)
+		}
+		records = append(records, Record{CreatedAt: e.createdAt, ID: e.id, Payload: payload})
+	}
+	return records, nil
+}
+
+// Remove deletes delivered batch, unknown IDs are ignored.
+func (s *Spool) Remove(id string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	i := slices.IndexFunc(s.entries, func(e entry) bool { return e.id == id })
+	if i < 0 {
+		return nil
+	}
+	e := s.entries[i]
+	s.entries = slices.Delete(s.entries, i, i+1)
+	s.size -= e.size
+	if err := os.Remove(e.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove spooled batch: %w", err)
+	}
+	if err := syncDir(s.cfg.Dir); err != nil {
+		return fmt.Errorf("failed to commit spooled batch removal: %w", err)
+	}
+	return nil
+}
+
+// Evicted returns count of batches dropped because of spool limits since spool was opened.
+func (s *Spool) Evicted() int64 {
+	return s.evicted.Load()
+}
+
+// evict drops the oldest batches while spool exceeds limits. Requires mux.
+func (s *Spool) evict() {
+	border := time.Time{}
+	if s.cfg.MaxAge > 0 {
+		border = s.now().Add(-s.cfg.MaxAge)
+	}
+	removed := false
+	defer func() {
+		if !removed {
+			return
+		}
+		if err := syncDir(s.cfg.Dir); err != nil {
+			s.logger.Error("failed to commit evicted batches removal", zap.Error(err))
+		}
+	}()
+	for len(s.entries) > 0 {
+		oldest := s.entries[0]
+		tooBig := s.cfg.MaxBytes > 0 && s.size > s.cfg.MaxBytes
+		tooOld := !border.IsZero() && oldest.createdAt.Before(border)
+		if !tooBig && !tooOld {
+			return
+		}
+		removed = true
+		s.entries = s.entries[1:]
+		s.size -= oldest.size
+		s.evicted.Add(1)
+		if err := os.Remove(oldest.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Error("failed to remove evicted batch", zap.String("path", oldest.path), zap.Error(err))
+		}
+		s.logger.Warn(
+			"spooled batch evicted",
+			zap.String("batch", oldest.id),
+			zap.Bool("size_limit", tooBig),
+			zap.Bool("age_limit", tooOld),
+		)
+	}
+}
+
+// writeFileSync writes payload to temporary file and renames it, so spool never holds partial batches.
+// Directory is synced after rename, so the batch survives crash once writeFileSync returns.
+func writeFileSync(dir, tmpName, name string, payload []byte) error {
+	tmpPath := filepath.Join(dir, tmpName)
+	path := filepath.Join(dir, name)
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	_, err = file.Write(payload)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to commit spool file: %w", err)
+	}
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("failed to commit spool file: %w", err)
+	}
+	return nil
+}
+
+// syncDir flushes directory entries, making renames and removals in it durable.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open spool directory: %w", err)
+	}
+	err = file.Sync()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync spool directory: %w", err)
+	}
+	return nil
+}
+
+// formatName returns file name keeping batches ordered by sequence.
+func formatName(seq uint64, id string) string {
+	return fmt.Sprintf("%020d-%s%s", seq, id, recordExt)
+}
+
+func parseName(name string) (uint64, string, bool) {
+	base, ok := strings.CutSuffix(name, recordExt)
+	if !ok {
+		return 0, "", false
+	}
+	rawSeq, id, ok := strings.Cut(base, "-")
+	if !ok {
+		return 0, "", false
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return seq, id, true
+}
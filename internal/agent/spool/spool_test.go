@@ -0,0 +1,69 @@
+package spool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func recordIDs(t *testing.T, s *Spool) []string {
+	t.Helper()
+	records, err := s.Pending()
+	require.NoError(t, err)
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
+
+func TestSpoolReplay(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(Config{Dir: dir}, zap.NewNop())
+	require.NoError(t, err)
+	for _, id := range []string{"c", "a", "b"} {
+		require.NoError(t, s.Put(id, []byte(id+"-payload")))
+	}
+	require.NoError(t, s.Remove("a"))
+	require.NoError(t, s.Remove("missing"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, tmpPrefix+"partial"), []byte("x"), filePerm))
+
+	reopened, err := Open(Config{Dir: dir}, zap.NewNop())
+	require.NoError(t, err)
+	records, err := reopened.Pending()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "c", records[0].ID)
+	assert.Equal(t, []byte("c-payload"), records[0].Payload)
+	assert.Equal(t, "b", records[1].ID)
+	assert.NoFileExists(t, filepath.Join(dir, tmpPrefix+"partial"))
+
+	require.NoError(t, reopened.Put("d", nil))
+	assert.Equal(t, []string{"c", "b", "d"}, recordIDs(t, reopened))
+}
+
+func TestSpoolEviction(t *testing.T) {
+	s, err := Open(Config{Dir: t.TempDir(), MaxBytes: 10, MaxAge: time.Hour}, zap.NewNop())
+	require.NoError(t, err)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Put("a", []byte("1234")))
+	require.NoError(t, s.Put("b", []byte("1234")))
+	require.NoError(t, s.Put("c", []byte("1234")))
+	assert.Equal(t, []string{"b", "c"}, recordIDs(t, s))
+	assert.Equal(t, int64(1), s.Evicted())
+
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, s.Put("d", []byte("12")))
+	assert.Equal(t, []string{"b", "c", "d"}, recordIDs(t, s))
+
+	now = now.Add(45 * time.Minute)
+	assert.Equal(t, []string{"d"}, recordIDs(t, s))
+	assert.Equal(t, int64(3), s.Evicted())
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"go-metrics-service/internal/common/protocol"
 	"sync"
 )
 
@@ -10,18 +11,22 @@ type MetricsDiff struct {
 }
 
 type Storage struct {
-	gauges   map[string]*gauge
-	gMutex   *sync.RWMutex
-	counters map[string]*counter
-	cMutex   *sync.RWMutex
+	gauges     map[string]*gauge
+	gMutex     *sync.RWMutex
+	counters   map[string]*counter
+	cMutex     *sync.RWMutex
+	histograms map[string]*protocol.HistogramValue
+	hMutex     *sync.Mutex
 }
 
 func New() *Storage {
 	return &Storage{
-		gauges:   make(map[string]*gauge),
-		gMutex:   &sync.RWMutex{},
-		counters: make(map[string]*counter),
-		cMutex:   &sync.RWMutex{},
+		gauges:     make(map[string]*gauge),
+		gMutex:     &sync.RWMutex{},
+		counters:   make(map[string]*counter),
+		cMutex:     &sync.RWMutex{},
+		histograms: make(map[string]*protocol.HistogramValue),
+		hMutex:     &sync.Mutex{},
 	}
 }
 
@@ -159,3 +164,36 @@ func (s *Storage) HandleUncommitedGauges(f func(map[string]float64) error) error
 
 	return nil
 }
+
+// ObserveHistogram records values into histogram with given bucket bounds.
+// Bounds are fixed by the first observation until histogram is consumed.
+func (s *Storage) ObserveHistogram(key string, bounds []float64, values ...float64) {
+	s.hMutex.Lock()
+	defer s.hMutex.Unlock()
+
+	h, ok := s.histograms[key]
+	if !ok {
+		value := protocol.NewHistogramValue(bounds)
+		h = &value
+		s.histograms[key] = h
+	}
+	for _, v := range values {
+		h.Observe(v)
+	}
+}
+
+// ConsumeHistograms returns histograms observed since previous call and resets them.
+func (s *Storage) ConsumeHistograms() map[string]protocol.HistogramValue {
+	s.hMutex.Lock()
+	defer s.hMutex.Unlock()
+
+	res := make(map[string]protocol.HistogramValue, len(s.histograms))
+	for k, v := range s.histograms {
+		if v.Count > 0 {
+			res[k] = *v
+		}
+	}
+	s.histograms = make(map[string]*protocol.HistogramValue)
+
+	return res
+}
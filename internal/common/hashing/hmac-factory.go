@@ -9,6 +9,7 @@ import (
 
 type HMAC struct {
 	sha256Key string
+	keyID     string
 }
 
 func NewHMAC(sha256Key string) *HMAC {
@@ -17,6 +18,19 @@ func NewHMAC(sha256Key string) *HMAC {
 	}
 }
 
+// NewKeyedHMAC creates HMAC of key the server knows by keyID.
+func NewKeyedHMAC(keyID, sha256Key string) *HMAC {
+	return &HMAC{
+		sha256Key: sha256Key,
+		keyID:     keyID,
+	}
+}
+
 func (h *HMAC) Create() hash.Hash {
 	return hmac.New(sha256.New, []byte(h.sha256Key))
 }
+
+// KeyID is sent along with hash for server to select key, empty for shared key.
+func (h *HMAC) KeyID() string {
+	return h.keyID
+}
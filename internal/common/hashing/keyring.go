@@ -0,0 +1,91 @@
+package hashing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Key is HMAC secret identified by key ID. Key with ExpiresAt set is retired, it keeps
+// verifying until ExpiresAt, so agents can be moved to a new secret without downtime.
+type Key struct {
+	ExpiresAt time.Time
+	ID        string
+	Secret    string
+}
+
+type keyringEntry struct {
+	factory   *HMAC
+	expiresAt time.Time
+}
+
+// Keyring selects HMAC keys by key ID sent by agent. Key without ID is used
+// for agents configured with shared key only.
+type Keyring struct {
+	keys map[string][]keyringEntry
+	now  func() time.Time
+}
+
+func NewKeyring(keys []Key) (*Keyring, error) {
+	entries := make(map[string][]keyringEntry)
+	for _, key := range keys {
+		if key.Secret == "" {
+			return nil, fmt.Errorf("empty secret of hash key '%s'", key.ID)
+		}
+		current := slices.ContainsFunc(entries[key.ID], func(e keyringEntry) bool {
+			return e.expiresAt.IsZero()
+		})
+		if current && key.ExpiresAt.IsZero() {
+			return nil, fmt.Errorf("hash key '%s' has several secrets without expiration", key.ID)
+		}
+		entries[key.ID] = append(entries[key.ID], keyringEntry{
+			factory:   NewKeyedHMAC(key.ID, key.Secret),
+			expiresAt: key.ExpiresAt,
+		})
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no hash keys")
+	}
+	for _, list := range entries {
+		// Current secret goes first, then retired ones verifying the longest.
+		slices.SortStableFunc(list, func(a, b keyringEntry) int {
+			switch {
+			case a.expiresAt.Equal(b.expiresAt):
+				return 0
+			case a.expiresAt.IsZero():
+				return -1
+			case b.expiresAt.IsZero():
+				return 1
+			default:
+				return b.expiresAt.Compare(a.expiresAt)
+			}
+		})
+	}
+	return &Keyring{
+		keys: entries,
+		now:  time.Now,
+	}, nil
+}
+
+// Verifiers returns keys accepted for key ID, signer key first. Nothing is returned for unknown key ID.
+func (k *Keyring) Verifiers(keyID string) []Factory {
+	now := k.now()
+	factories := make([]Factory, 0, len(k.keys[keyID]))
+	for _, entry := range k.keys[keyID] {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			continue
+		}
+		factories = append(factories, entry.factory)
+	}
+	return factories
+}
+
+// Signer returns key responses to agent using key ID are signed with.
+func (k *Keyring) Signer(keyID string) (Factory, bool) {
+	verifiers := k.Verifiers(keyID)
+	if len(verifiers) == 0 {
+		return nil, false
+	}
+	return verifiers[0], true
+}
@@ -0,0 +1,78 @@
+package hashing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring(t *testing.T) {
+	now := time.Now()
+	keyring, err := NewKeyring([]Key{
+		{ID: "agent", Secret: "old", ExpiresAt: now.Add(time.Hour)},
+		{ID: "agent", Secret: "older", ExpiresAt: now.Add(time.Minute)},
+		{ID: "agent", Secret: "new"},
+		{ID: "agent", Secret: "expired", ExpiresAt: now.Add(-time.Minute)},
+		{Secret: "shared"},
+	})
+	require.NoError(t, err)
+	keyring.now = func() time.Time { return now }
+
+	secrets := func(factories []Factory) []string {
+		result := make([]string, 0, len(factories))
+		for _, factory := range factories {
+			result = append(result, factory.(*HMAC).sha256Key)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"new", "old", "older"}, secrets(keyring.Verifiers("agent")))
+	assert.Equal(t, []string{"shared"}, secrets(keyring.Verifiers("")))
+	assert.Empty(t, keyring.Verifiers("unknown"))
+
+	signer, ok := keyring.Signer("agent")
+	require.True(t, ok)
+	assert.Equal(t, "agent", signer.(*HMAC).KeyID())
+	assert.Equal(t, "new", signer.(*HMAC).sha256Key)
+	_, ok = keyring.Signer("unknown")
+	assert.False(t, ok)
+
+	// Retired keys stop verifying after grace period.
+	keyring.now = func() time.Time { return now.Add(2 * time.Hour) }
+	assert.Equal(t, []string{"new"}, secrets(keyring.Verifiers("agent")))
+}
+
+func TestNewKeyringValidation(t *testing.T) {
+	_, err := NewKeyring(nil)
+	require.Error(t, err)
+	_, err = NewKeyring([]Key{{ID: "agent"}})
+	require.Error(t, err)
+	_, err = NewKeyring([]Key{{ID: "agent", Secret: "a"}, {ID: "agent", Secret: "b"}})
+	require.Error(t, err)
+}
+
+func TestRequestSum(t *testing.T) {
+	factory := NewHMAC("secret")
+	body := []byte("body")
+	sum, err := RequestSum(factory, "1", "nonce", body)
+	require.NoError(t, err)
+
+	valid, err := MatchAny([]Factory{NewHMAC("other"), factory}, sum, func(f Factory) (string, error) {
+		return RequestSum(f, "1", "nonce", body)
+	})
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// Timestamp and nonce are signed, changing them breaks hash.
+	for _, params := range [][2]string{{"2", "nonce"}, {"1", "other"}} {
+		changed, err := RequestSum(factory, params[0], params[1], body)
+		require.NoError(t, err)
+		assert.NotEqual(t, sum, changed)
+	}
+
+	timestamp := time.UnixMilli(1_700_000_000_123)
+	parsed, err := ParseTimestamp(FormatTimestamp(timestamp))
+	require.NoError(t, err)
+	assert.True(t, timestamp.Equal(parsed))
+}
@@ -0,0 +1,102 @@
+package hashing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type Factory interface {
+	Create() hash.Hash
+}
+
+// SignedMessage is stream message carrying its own hash, as stream metadata is sent only once.
+type SignedMessage interface {
+	proto.Message
+	GetHash() string
+	SetHash(string)
+}
+
+// StampedMessage is signed stream message carrying timestamp and nonce, so receiver
+// can reject it replayed out of window or twice.
+type StampedMessage interface {
+	SignedMessage
+	GetTimestamp() string
+	SetTimestamp(string)
+	GetNonce() string
+	SetNonce(string)
+}
+
+// MessageHash returns hex encoded hash of deterministic message serialization.
+func MessageHash(factory Factory, message proto.Message) (string, error) {
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+	h := factory.Create()
+	_, err = h.Write(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// MessageRequestSum returns hex encoded hash of unary request message bound to call
+// timestamp and nonce, the same way as RequestSum binds HTTP request body.
+func MessageRequestSum(factory Factory, timestamp, nonce string, message proto.Message) (string, error) {
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return RequestSum(factory, timestamp, nonce, encoded)
+}
+
+// StreamSum returns hex encoded hash of stream method bound to timestamp and nonce. Stream
+// is signed once it is opened, before any message is sent.
+func StreamSum(factory Factory, timestamp, nonce, method string) (string, error) {
+	return RequestSum(factory, timestamp, nonce, []byte(method))
+}
+
+// Stamp sets fresh timestamp and nonce of message, it is done before signing.
+func Stamp(message StampedMessage, now time.Time) error {
+	nonce, err := NewNonce()
+	if err != nil {
+		return err
+	}
+	message.SetTimestamp(FormatTimestamp(now))
+	message.SetNonce(nonce)
+	return nil
+}
+
+// Sign sets hash of message calculated with empty hash field.
+func Sign(factory Factory, message SignedMessage) error {
+	message.SetHash("")
+	sum, err := MessageHash(factory, message)
+	if err != nil {
+		return err
+	}
+	message.SetHash(sum)
+	return nil
+}
+
+// Verify checks hash of signed message. Unsigned message fails verification,
+// receiver accepting unsigned messages checks hash presence itself.
+func Verify(factory Factory, message SignedMessage) (bool, error) {
+	return VerifyAny([]Factory{factory}, message)
+}
+
+// VerifyAny checks hash of signed message against every factory, e.g. keys in rotation.
+func VerifyAny(factories []Factory, message SignedMessage) (bool, error) {
+	received := message.GetHash()
+	if received == "" {
+		return false, nil
+	}
+	message.SetHash("")
+	defer message.SetHash(received)
+	return MatchAny(factories, received, func(factory Factory) (string, error) {
+		return MessageHash(factory, message)
+	})
+}
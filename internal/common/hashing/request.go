@@ -0,0 +1,61 @@
+package hashing
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const nonceSize = 16
+
+// RequestSum returns hex encoded hash of HTTP request body bound to request timestamp
+// and nonce, so captured request can not be replayed once the timestamp is out of window.
+func RequestSum(factory Factory, timestamp, nonce string, body []byte) (string, error) {
+	h := factory.Create()
+	if _, err := fmt.Fprintf(h, "%s\n%s\n", timestamp, nonce); err != nil {
+		return "", fmt.Errorf("failed to write request parameters: %w", err)
+	}
+	if _, err := h.Write(body); err != nil {
+		return "", fmt.Errorf("failed to write body: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// MatchAny reports whether received hash matches sum calculated with any of factories.
+func MatchAny(factories []Factory, received string, sum func(Factory) (string, error)) (bool, error) {
+	for _, factory := range factories {
+		expected, err := sum(factory)
+		if err != nil {
+			return false, err
+		}
+		if hmac.Equal([]byte(expected), []byte(received)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// NewNonce returns random hex encoded request nonce.
+func NewNonce() (string, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// FormatTimestamp formats request timestamp as unix milliseconds.
+func FormatTimestamp(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func ParseTimestamp(timestamp string) (time.Time, error) {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s': %w", timestamp, err)
+	}
+	return time.UnixMilli(ms), nil
+}
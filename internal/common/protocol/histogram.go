@@ -0,0 +1,147 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+var ErrInvalidHistogram = errors.New("invalid histogram")
+
+// DefaultQuantiles are estimated for histograms in read responses.
+var DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+
+// HistogramValue counts observations in buckets with upper Bounds.
+// Counts[i] holds observations in (Bounds[i-1], Bounds[i]],
+// the last of len(Bounds)+1 counts holds observations above the last bound.
+//
+//nolint:govet // field alignment
+type HistogramValue struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []uint64  `json:"counts"`
+	Sum    float64   `json:"sum"`
+	Count  uint64    `json:"count"`
+}
+
+// NewHistogramValue creates empty histogram with provided bucket bounds.
+func NewHistogramValue(bounds []float64) HistogramValue {
+	return HistogramValue{
+		Bounds: append([]float64(nil), bounds...),
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe records value.
+func (h *HistogramValue) Observe(value float64) {
+	i := 0
+	for i < len(h.Bounds) && value > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Sum += value
+	h.Count++
+}
+
+// Validate checks that bounds are increasing and counts match them.
+func (h *HistogramValue) Validate() error {
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return fmt.Errorf("%w: %d counts for %d bounds", ErrInvalidHistogram, len(h.Counts), len(h.Bounds))
+	}
+	for i := 1; i < len(h.Bounds); i++ {
+		if !(h.Bounds[i] > h.Bounds[i-1]) {
+			return fmt.Errorf("%w: bounds must increase", ErrInvalidHistogram)
+		}
+	}
+	total := uint64(0)
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total != h.Count {
+		return fmt.Errorf("%w: bucket counts sum %d differs from count %d", ErrInvalidHistogram, total, h.Count)
+	}
+	return nil
+}
+
+// Merge adds observations of other histogram with the same bounds.
+func (h *HistogramValue) Merge(other HistogramValue) error {
+	if len(h.Bounds) != len(other.Bounds) {
+		return fmt.Errorf("%w: bounds mismatch", ErrInvalidHistogram)
+	}
+	if len(h.Counts) != len(other.Counts) {
+		return fmt.Errorf("%w: counts mismatch", ErrInvalidHistogram)
+	}
+	for i := range h.Bounds {
+		if h.Bounds[i] != other.Bounds[i] {
+			return fmt.Errorf("%w: bounds mismatch", ErrInvalidHistogram)
+		}
+	}
+	for i := range h.Counts {
+		h.Counts[i] += other.Counts[i]
+	}
+	h.Sum += other.Sum
+	h.Count += other.Count
+	return nil
+}
+
+// Clone returns deep copy of histogram.
+func (h *HistogramValue) Clone() HistogramValue {
+	return HistogramValue{
+		Bounds: append([]float64(nil), h.Bounds...),
+		Counts: append([]uint64(nil), h.Counts...),
+		Sum:    h.Sum,
+		Count:  h.Count,
+	}
+}
+
+// Quantile estimates q-quantile interpolating linearly inside the bucket it falls into.
+// Observations above the last bound are estimated by the last bound. NaN is returned for empty histogram.
+func (h *HistogramValue) Quantile(q float64) float64 {
+	if h.Count == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	rank := q * float64(h.Count)
+	cumulative := uint64(0)
+	for i, c := range h.Counts {
+		if c == 0 || float64(cumulative+c) < rank {
+			cumulative += c
+			continue
+		}
+		if i == len(h.Bounds) {
+			break
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = h.Bounds[i-1]
+		} else if h.Bounds[0] <= 0 {
+			return h.Bounds[0]
+		}
+		upper := h.Bounds[i]
+		return lower + (upper-lower)*(rank-float64(cumulative))/float64(c)
+	}
+	if len(h.Bounds) == 0 {
+		return math.NaN()
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// Quantiles estimates DefaultQuantiles keyed like "p50", "p99".
+// Nil is returned for empty histogram.
+func (h *HistogramValue) Quantiles() map[string]float64 {
+	if h.Count == 0 {
+		return nil
+	}
+	res := make(map[string]float64, len(DefaultQuantiles))
+	for _, q := range DefaultQuantiles {
+		if v := h.Quantile(q); !math.IsNaN(v) {
+			res[QuantileName(q)] = v
+		}
+	}
+	return res
+}
+
+// QuantileName returns percentile name of quantile, e.g. "p99" for 0.99.
+func QuantileName(q float64) string {
+	const percents = 100
+	return "p" + strconv.FormatFloat(q*percents, 'f', -1, 64)
+}
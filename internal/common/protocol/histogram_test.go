@@ -0,0 +1,62 @@
+package protocol
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramObserveAndMerge(t *testing.T) {
+	h := NewHistogramValue([]float64{1, 2, 4})
+	for _, v := range []float64{0.5, 1, 1.5, 3, 10} {
+		h.Observe(v)
+	}
+	assert.Equal(t, []uint64{2, 1, 1, 1}, h.Counts)
+	assert.Equal(t, uint64(5), h.Count)
+	assert.InDelta(t, 16.0, h.Sum, 1e-9)
+	require.NoError(t, h.Validate())
+
+	other := NewHistogramValue([]float64{1, 2, 4})
+	other.Observe(1.5)
+	require.NoError(t, h.Merge(other))
+	assert.Equal(t, []uint64{2, 2, 1, 1}, h.Counts)
+	assert.Equal(t, uint64(6), h.Count)
+
+	assert.ErrorIs(t, h.Merge(NewHistogramValue([]float64{1, 3, 4})), ErrInvalidHistogram)
+	assert.ErrorIs(t, h.Merge(NewHistogramValue([]float64{1})), ErrInvalidHistogram)
+	malformed := NewHistogramValue([]float64{1, 2, 4})
+	malformed.Counts = malformed.Counts[:2]
+	assert.ErrorIs(t, h.Merge(malformed), ErrInvalidHistogram)
+	assert.Equal(t, []uint64{2, 2, 1, 1}, h.Counts)
+}
+
+func TestHistogramValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		histogram HistogramValue
+	}{
+		{name: "counts length", histogram: HistogramValue{Bounds: []float64{1}, Counts: []uint64{0}}},
+		{name: "bounds order", histogram: HistogramValue{Bounds: []float64{2, 1}, Counts: []uint64{0, 0, 0}}},
+		{name: "count mismatch", histogram: HistogramValue{Bounds: []float64{1}, Counts: []uint64{1, 0}, Count: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.histogram.Validate(), ErrInvalidHistogram)
+		})
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h := NewHistogramValue([]float64{1, 2, 4})
+	assert.True(t, math.IsNaN(h.Quantile(0.5)))
+	assert.Nil(t, h.Quantiles())
+
+	h.Counts = []uint64{2, 2, 0, 1}
+	h.Count = 5
+	assert.InDelta(t, 1.25, h.Quantile(0.5), 1e-9)
+	assert.InDelta(t, 0.5, h.Quantile(0.2), 1e-9)
+	assert.InDelta(t, 4.0, h.Quantile(0.99), 1e-9)
+	assert.Equal(t, map[string]float64{"p50": 1.25, "p90": 4, "p99": 4}, h.Quantiles())
+}
@@ -0,0 +1,115 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	HostLabel     = "host"
+	InstanceLabel = "instance"
+)
+
+var (
+	ErrInvalidLabel = errors.New("invalid label")
+	ErrInvalidID    = errors.New("invalid metric id")
+)
+
+// seriesKeyChars delimit labels in series key and cannot be part of metric ID.
+const seriesKeyChars = "{}="
+
+// SeriesKey returns key identifying metric series by its ID and label set,
+// e.g. `Alloc{host="a",instance="b"}`. Labels are sorted by name
+// and values are quoted, so equal label sets always produce equal keys.
+// Metric without labels is identified by its ID.
+func SeriesKey(id string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return id
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var builder strings.Builder
+	builder.WriteString(id)
+	builder.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(name)
+		builder.WriteByte('=')
+		builder.WriteString(strconv.Quote(labels[name]))
+	}
+	builder.WriteByte('}')
+	return builder.String()
+}
+
+// ParseSeriesKey splits series key created with SeriesKey into ID and labels.
+func ParseSeriesKey(key string) (id string, labels map[string]string, err error) {
+	start := strings.IndexByte(key, '{')
+	if start < 0 || !strings.HasSuffix(key, "}") {
+		return key, nil, nil
+	}
+	id = key[:start]
+	labels = make(map[string]string)
+	rest := key[start+1 : len(key)-1]
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return "", nil, fmt.Errorf("%w: '%s' series key", ErrInvalidLabel, key)
+		}
+		name := rest[:eq]
+		quoted, err := strconv.QuotedPrefix(rest[eq+1:])
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: '%s' series key: %w", ErrInvalidLabel, key, err)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: '%s' series key: %w", ErrInvalidLabel, key, err)
+		}
+		labels[name] = value
+		rest = strings.TrimPrefix(rest[eq+1+len(quoted):], ",")
+	}
+	return id, labels, nil
+}
+
+// ValidateID checks that metric ID is not empty and holds none of series key delimiters,
+// so series key of the metric parses back to the same ID.
+func ValidateID(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidID)
+	}
+	if strings.ContainsAny(id, seriesKeyChars) {
+		return fmt.Errorf("%w: '%s' contains one of '%s'", ErrInvalidID, id, seriesKeyChars)
+	}
+	return nil
+}
+
+// ValidateLabels checks that every label name matches [a-zA-Z_][a-zA-Z0-9_]*.
+func ValidateLabels(labels map[string]string) error {
+	for name := range labels {
+		if !isValidLabelName(name) {
+			return fmt.Errorf("%w: '%s' name", ErrInvalidLabel, name)
+		}
+	}
+	return nil
+}
+
+func isValidLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && (!isDigit || i == 0) {
+			return false
+		}
+	}
+	return true
+}
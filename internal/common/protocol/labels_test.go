@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "no labels",
+			id:       "Alloc",
+			expected: "Alloc",
+		},
+		{
+			name:     "sorted labels",
+			id:       "Alloc",
+			labels:   map[string]string{InstanceLabel: "b", HostLabel: "a"},
+			expected: `Alloc{host="a",instance="b"}`,
+		},
+		{
+			name:     "escaped value",
+			id:       "Alloc",
+			labels:   map[string]string{"path": `C:\dir "quoted", {x}`},
+			expected: `Alloc{path="C:\\dir \"quoted\", {x}"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := SeriesKey(tt.id, tt.labels)
+			assert.Equal(t, tt.expected, key)
+
+			id, labels, err := ParseSeriesKey(key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.id, id)
+			if len(tt.labels) == 0 {
+				assert.Empty(t, labels)
+			} else {
+				assert.Equal(t, tt.labels, labels)
+			}
+		})
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	assert.NoError(t, ValidateLabels(map[string]string{HostLabel: "a", "_x1": ""}))
+	assert.ErrorIs(t, ValidateLabels(map[string]string{"1x": "a"}), ErrInvalidLabel)
+	assert.ErrorIs(t, ValidateLabels(map[string]string{"a-b": "a"}), ErrInvalidLabel)
+	assert.ErrorIs(t, ValidateLabels(map[string]string{"": "a"}), ErrInvalidLabel)
+}
+
+func TestValidateID(t *testing.T) {
+	assert.NoError(t, ValidateID("cpu.usage-total"))
+	assert.ErrorIs(t, ValidateID(""), ErrInvalidID)
+	assert.ErrorIs(t, ValidateID(`cpu{host="a"}`), ErrInvalidID)
+	assert.ErrorIs(t, ValidateID("cpu}"), ErrInvalidID)
+	assert.ErrorIs(t, ValidateID("a=b"), ErrInvalidID)
+}
@@ -1,9 +1,12 @@
 // Package protocol contains types and constants used by both agent and server
 package protocol
 
+import "time"
+
 const (
-	Gauge   = "gauge"
-	Counter = "counter"
+	Gauge     = "gauge"
+	Counter   = "counter"
+	Histogram = "histogram"
 )
 
 const (
@@ -13,7 +16,52 @@ const (
 )
 
 const (
-	HashHeader = "HashSHA256"
+	FromParam        = "from"
+	ToParam          = "to"
+	StepParam        = "step"
+	AggregationParam = "agg"
+)
+
+const (
+	PrefixParam = "prefix"
+	RegexParam  = "regex"
+)
+
+const (
+	AggregationAvg      = "avg"
+	AggregationMin      = "min"
+	AggregationMax      = "max"
+	AggregationLast     = "last"
+	AggregationRate     = "rate"
+	AggregationIncrease = "increase"
+)
+
+const (
+	HashHeader    = "HashSHA256"
+	RealIPHeader  = "X-Real-IP"
+	AgentIDHeader = "X-Agent-ID"
+	StaleHeader   = "X-Metric-Stale"
+	// ContentEncryptionHeader names encryption format of request body.
+	ContentEncryptionHeader = "Content-Encryption"
+	// KeyIDHeader names server key the request body is encrypted with.
+	KeyIDHeader = "X-Key-ID"
+	// HashKeyIDHeader names HMAC key the request is signed with.
+	HashKeyIDHeader = "X-Hash-Key-ID"
+	// TimestampHeader and NonceHeader are signed along with request body.
+	TimestampHeader = "X-Timestamp"
+	NonceHeader     = "X-Nonce"
+	// IdempotencyKeyHeader is kept by agent across retries of the same batch.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// AgentIDMetadata is gRPC metadata key carrying agent ID.
+	AgentIDMetadata = "x-agent-id"
+	// HashMetadata is gRPC metadata key carrying HMAC of unary request or response.
+	HashMetadata   = "hashsha256"
+	RealIPMetadata = "x-real-ip"
+	// HashKeyIDMetadata is gRPC metadata key naming HMAC key of the call.
+	HashKeyIDMetadata = "x-hash-key-id"
+	// TimestampMetadata and NonceMetadata are signed along with unary request message.
+	TimestampMetadata = "x-timestamp"
+	NonceMetadata     = "x-nonce"
 )
 
 const (
@@ -22,14 +70,46 @@ const (
 	GetMetricURL              = "/value/"
 	UpdateMetricPathParamsURL = "/update/{" + TypeParam + "}/{" + KeyParam + "}/{" + ValueParam + "}"
 	GetMetricPathParamsURL    = "/value/{" + TypeParam + "}/{" + KeyParam + "}"
+	GetMetricHistoryURL       = "/history/{" + TypeParam + "}/{" + KeyParam + "}"
+	DeleteMetricPathParamsURL = "/value/{" + TypeParam + "}/{" + KeyParam + "}"
+	DeleteMetricsURL          = "/value/"
 	PingURL                   = "/ping"
+	PrometheusMetricsURL      = "/metrics"
+	AlertsURL                 = "/alerts"
+	AgentsURL                 = "/agents"
 	GetAllMetricsURL          = "/"
 )
 
 //nolint:govet // field alignment
 type Metrics struct {
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
-	Delta *int64   `json:"delta,omitempty"`
-	Value *float64 `json:"value,omitempty"`
+	ID     string            `json:"id"`
+	MType  string            `json:"type"`
+	Delta  *int64            `json:"delta,omitempty"`
+	Value  *float64          `json:"value,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Histogram carries observations recorded since previous update for histogram metrics
+	// and merged state in read responses.
+	Histogram *HistogramValue `json:"histogram,omitempty"`
+	// Quantiles are estimated from histogram in read responses.
+	Quantiles map[string]float64 `json:"quantiles,omitempty"`
+	// Stale is set in read responses for series not updated for staleness TTL.
+	Stale bool `json:"stale,omitempty"`
+}
+
+// SeriesKey returns storage key of metric series.
+func (m *Metrics) SeriesKey() string {
+	return SeriesKey(m.ID, m.Labels)
+}
+
+// DeleteResult lists series keys removed by bulk delete.
+type DeleteResult struct {
+	Deleted []string `json:"deleted"`
+}
+
+// MetricsSample is a metric value observed by server at Timestamp.
+//
+//nolint:govet // field alignment
+type MetricsSample struct {
+	Metrics
+	Timestamp time.Time `json:"timestamp"`
 }
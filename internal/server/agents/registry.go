@@ -0,0 +1,132 @@
+// Package agents keeps track of agents reporting metrics to server
+package agents
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type contextKey int
+
+const (
+	identityKey contextKey = iota
+)
+
+// Identity describes agent sending current request.
+type Identity struct {
+	ID string
+	IP string
+}
+
+// WithIdentity returns context carrying agent identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+// IdentityFromContext returns agent identity if request came from identified agent.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey).(Identity)
+	return identity, ok && identity.ID != ""
+}
+
+// Agent is a known agent summary.
+//
+//nolint:govet // field alignment
+type Agent struct {
+	ID           string    `json:"id"`
+	IP           string    `json:"ip,omitempty"`
+	LastSeen     time.Time `json:"last_seen"`
+	MetricsCount int       `json:"metrics_count"`
+	Alive        bool      `json:"alive"`
+}
+
+// entry keeps time every series was last reported by agent.
+type entry struct {
+	series map[string]time.Time
+	agent  Agent
+}
+
+type Registry struct {
+	mux        *sync.Mutex
+	entries    map[string]*entry
+	now        func() time.Time
+	staleAfter time.Duration
+	purgeAfter time.Duration
+}
+
+// New creates registry considering agents not seen for staleAfter dead. Zero staleAfter keeps every agent alive.
+// Series not reported for purgeAfter are not counted and agents not seen for purgeAfter are forgotten,
+// the same way as server purges series. Zero purgeAfter keeps them forever.
+func New(staleAfter, purgeAfter time.Duration) *Registry {
+	return &Registry{
+		mux:        &sync.Mutex{},
+		entries:    make(map[string]*entry),
+		now:        time.Now,
+		staleAfter: staleAfter,
+		purgeAfter: purgeAfter,
+	}
+}
+
+// Observe records that agent reported given series.
+func (r *Registry) Observe(identity Identity, seriesKeys []string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	now := r.now()
+	r.prune(now)
+	e, ok := r.entries[identity.ID]
+	if !ok {
+		e = &entry{
+			agent:  Agent{ID: identity.ID},
+			series: make(map[string]time.Time),
+		}
+		r.entries[identity.ID] = e
+	}
+	if identity.IP != "" {
+		e.agent.IP = identity.IP
+	}
+	e.agent.LastSeen = now
+	for _, key := range seriesKeys {
+		e.series[key] = now
+	}
+	e.agent.MetricsCount = len(e.series)
+}
+
+// Agents returns known agents sorted by ID.
+func (r *Registry) Agents() []Agent {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	now := r.now()
+	r.prune(now)
+	res := make([]Agent, 0, len(r.entries))
+	for _, e := range r.entries {
+		agent := e.agent
+		agent.Alive = r.staleAfter <= 0 || now.Sub(agent.LastSeen) < r.staleAfter
+		res = append(res, agent)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// prune forgets series and agents not reported since purge border.
+func (r *Registry) prune(now time.Time) {
+	if r.purgeAfter <= 0 {
+		return
+	}
+	border := now.Add(-r.purgeAfter)
+	for id, e := range r.entries {
+		if e.agent.LastSeen.Before(border) {
+			delete(r.entries, id)
+			continue
+		}
+		for key, reportedAt := range e.series {
+			if reportedAt.Before(border) {
+				delete(e.series, key)
+			}
+		}
+		e.agent.MetricsCount = len(e.series)
+	}
+}
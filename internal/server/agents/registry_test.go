@@ -0,0 +1,53 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := New(time.Minute, 0)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	registry.now = func() time.Time { return now }
+
+	registry.Observe(Identity{ID: "b", IP: "10.0.0.2"}, []string{"Alloc", "PollCount"})
+	registry.Observe(Identity{ID: "a"}, []string{"Alloc"})
+	now = now.Add(time.Minute)
+	registry.Observe(Identity{ID: "b"}, []string{"Alloc", "RandomValue"})
+
+	assert.Equal(t, []Agent{
+		{ID: "a", LastSeen: now.Add(-time.Minute), MetricsCount: 1, Alive: false},
+		{ID: "b", IP: "10.0.0.2", LastSeen: now, MetricsCount: 3, Alive: true},
+	}, registry.Agents())
+}
+
+func TestRegistryPrune(t *testing.T) {
+	registry := New(time.Minute, time.Hour)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	registry.now = func() time.Time { return now }
+
+	registry.Observe(Identity{ID: "a"}, []string{"Alloc"})
+	registry.Observe(Identity{ID: "b"}, []string{"Alloc", "PollCount"})
+	now = now.Add(30 * time.Minute)
+	registry.Observe(Identity{ID: "b"}, []string{"Alloc"})
+	now = now.Add(45 * time.Minute)
+
+	assert.Equal(t, []Agent{
+		{ID: "b", LastSeen: now.Add(-45 * time.Minute), MetricsCount: 1, Alive: false},
+	}, registry.Agents())
+}
+
+func TestIdentityFromContext(t *testing.T) {
+	_, ok := IdentityFromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = IdentityFromContext(WithIdentity(context.Background(), Identity{IP: "10.0.0.1"}))
+	assert.False(t, ok)
+
+	identity, ok := IdentityFromContext(WithIdentity(context.Background(), Identity{ID: "a"}))
+	assert.True(t, ok)
+	assert.Equal(t, "a", identity.ID)
+}
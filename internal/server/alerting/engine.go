@@ -0,0 +1,238 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/pkg/gohelpers"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	StateInactive = "inactive"
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+type Config struct {
+	Rules              []Rule
+	EvaluationInterval time.Duration
+}
+
+type Repository interface {
+	GetAll(ctx context.Context) (map[string]any, error)
+}
+
+// Notifier receives alert copies on every state change. Implementations must not block.
+type Notifier interface {
+	Notify(alert Alert)
+}
+
+// Alert is a rule evaluation state.
+//
+//nolint:govet // field alignment
+type Alert struct {
+	Name       string     `json:"name"`
+	Expr       string     `json:"expr"`
+	State      string     `json:"state"`
+	Value      float64    `json:"value"`
+	ActiveAt   *time.Time `json:"active_at,omitempty"`
+	FiredAt    *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+type observation struct {
+	timestamp time.Time
+	value     float64
+}
+
+type Engine struct {
+	repository Repository
+	notifier   Notifier
+	logger     *zap.Logger
+	doneCh     chan struct{}
+	mux        *sync.RWMutex
+	alerts     []Alert
+	prevValues map[string]observation
+	now        func() time.Time
+	cfg        Config
+}
+
+func NewEngine(cfg Config, repository Repository, notifier Notifier, logger *zap.Logger) *Engine {
+	alerts := make([]Alert, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		alerts[i] = Alert{
+			Name:  rule.Name,
+			Expr:  rule.Expr,
+			State: StateInactive,
+		}
+	}
+	return &Engine{
+		cfg:        cfg,
+		repository: repository,
+		notifier:   notifier,
+		logger:     logger,
+		doneCh:     make(chan struct{}),
+		mux:        &sync.RWMutex{},
+		alerts:     alerts,
+		prevValues: make(map[string]observation),
+		now:        time.Now,
+	}
+}
+
+func (e *Engine) Start() chan error {
+	return gohelpers.StartTickerProcess(e.doneCh, e.Evaluate, e.cfg.EvaluationInterval)
+}
+
+func (e *Engine) Stop() {
+	close(e.doneCh)
+}
+
+// Evaluate checks every rule against current repository values and updates alert states.
+func (e *Engine) Evaluate(ctx context.Context) error {
+	values, err := e.repository.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
+	}
+	now := e.now()
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	for i := range e.cfg.Rules {
+		rule := &e.cfg.Rules[i]
+		value, ok := e.ruleValue(rule, values, now)
+		e.transit(&e.alerts[i], rule, ok && rule.holds(value), value, now)
+	}
+	for key, raw := range values {
+		if value, ok := toFloat(raw); ok {
+			e.prevValues[key] = observation{timestamp: now, value: value}
+		}
+	}
+	return nil
+}
+
+// ruleValue evaluates rule against every series of rule metric, e.g. series of every agent.
+// Value of the first series holding the rule is returned, so alert fires when any series
+// holds it, otherwise value of the first evaluated series is returned.
+func (e *Engine) ruleValue(rule *Rule, values map[string]any, now time.Time) (float64, bool) {
+	keys := make([]string, 0, 1)
+	for key := range values {
+		id, _, err := protocol.ParseSeriesKey(key)
+		if err == nil && id == rule.Metric {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var result float64
+	found := false
+	for _, key := range keys {
+		value, ok := e.seriesValue(rule, key, values[key], now)
+		if !ok {
+			continue
+		}
+		if rule.holds(value) {
+			return value, true
+		}
+		if !found {
+			result, found = value, true
+		}
+	}
+	return result, found
+}
+
+func (e *Engine) seriesValue(rule *Rule, key string, raw any, now time.Time) (float64, bool) {
+	value, ok := toFloat(raw)
+	if !ok {
+		return 0, false
+	}
+	if rule.Function != FunctionRate {
+		return value, true
+	}
+	prev, ok := e.prevValues[key]
+	if !ok {
+		return 0, false
+	}
+	elapsed := now.Sub(prev.timestamp).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return (value - prev.value) / elapsed, true
+}
+
+func (e *Engine) transit(alert *Alert, rule *Rule, active bool, value float64, now time.Time) {
+	prevState := alert.State
+	alert.Value = value
+	switch {
+	case active && (alert.State == StateInactive || alert.State == StateResolved):
+		alert.ActiveAt = &now
+		alert.FiredAt = nil
+		alert.ResolvedAt = nil
+		alert.State = StatePending
+		if rule.For <= 0 {
+			alert.FiredAt = &now
+			alert.State = StateFiring
+		}
+	case active && alert.State == StatePending:
+		if now.Sub(*alert.ActiveAt) >= rule.For {
+			alert.FiredAt = &now
+			alert.State = StateFiring
+		}
+	case !active && alert.State == StatePending:
+		alert.ActiveAt = nil
+		alert.State = StateInactive
+	case !active && alert.State == StateFiring:
+		alert.ResolvedAt = &now
+		alert.State = StateResolved
+	}
+	if prevState != alert.State {
+		e.logger.Info(
+			"alert state changed",
+			zap.String("name", alert.Name),
+			zap.String("from", prevState),
+			zap.String("to", alert.State),
+			zap.Float64("value", value),
+		)
+		if e.notifier != nil {
+			e.notifier.Notify(*alert)
+		}
+	}
+}
+
+// Alerts returns copy of every rule state.
+func (e *Engine) Alerts() []Alert {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+	res := make([]Alert, len(e.alerts))
+	copy(res, e.alerts)
+	return res
+}
+
+// FiringAlerts returns copy of currently firing alerts.
+func (e *Engine) FiringAlerts() []Alert {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+	res := make([]Alert, 0)
+	for _, alert := range e.alerts {
+		if alert.State == StateFiring {
+			res = append(res, alert)
+		}
+	}
+	return res
+}
+
+func toFloat(raw any) (float64, bool) {
+	switch v := raw.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
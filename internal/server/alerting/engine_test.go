@@ -0,0 +1,94 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type staticRepository struct {
+	values map[string]any
+}
+
+func (r *staticRepository) GetAll(_ context.Context) (map[string]any, error) {
+	return r.values, nil
+}
+
+func TestEngineStates(t *testing.T) {
+	heapRule, err := ParseRule("HighHeap", "HeapAlloc > 100 for 2m")
+	require.NoError(t, err)
+	pollRule, err := ParseRule("NoPolls", "rate(PollCount) == 0")
+	require.NoError(t, err)
+
+	repository := &staticRepository{values: map[string]any{
+		"HeapAlloc": 200.0,
+		"PollCount": int64(10),
+	}}
+	engine := NewEngine(Config{Rules: []Rule{heapRule, pollRule}}, repository, nil, zap.NewNop())
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+
+	evaluate := func() []Alert {
+		require.NoError(t, engine.Evaluate(context.Background()))
+		return engine.Alerts()
+	}
+
+	alerts := evaluate()
+	assert.Equal(t, StatePending, alerts[0].State)
+	assert.Equal(t, StateInactive, alerts[1].State, "rate is unknown on first evaluation")
+
+	now = now.Add(time.Minute)
+	alerts = evaluate()
+	assert.Equal(t, StatePending, alerts[0].State)
+	assert.Equal(t, StateFiring, alerts[1].State)
+
+	now = now.Add(time.Minute)
+	repository.values["PollCount"] = int64(20)
+	alerts = evaluate()
+	assert.Equal(t, StateFiring, alerts[0].State)
+	assert.Equal(t, StateResolved, alerts[1].State)
+	assert.Len(t, engine.FiringAlerts(), 1)
+
+	now = now.Add(time.Minute)
+	repository.values["HeapAlloc"] = 50.0
+	repository.values["PollCount"] = int64(30)
+	alerts = evaluate()
+	assert.Equal(t, StateResolved, alerts[0].State)
+	require.NotNil(t, alerts[0].ResolvedAt)
+	assert.Empty(t, engine.FiringAlerts())
+}
+
+func TestEngineLabelledSeries(t *testing.T) {
+	heapRule, err := ParseRule("HighHeap", "HeapAlloc > 100")
+	require.NoError(t, err)
+	pollRule, err := ParseRule("NoPolls", "rate(PollCount) == 0")
+	require.NoError(t, err)
+
+	repository := &staticRepository{values: map[string]any{
+		`HeapAlloc{instance="a"}`: 50.0,
+		`HeapAlloc{instance="b"}`: 200.0,
+		`PollCount{instance="a"}`: int64(10),
+		`PollCount{instance="b"}`: int64(10),
+		"HeapAllocTotal":          500.0,
+	}}
+	engine := NewEngine(Config{Rules: []Rule{heapRule, pollRule}}, repository, nil, zap.NewNop())
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+
+	require.NoError(t, engine.Evaluate(context.Background()))
+	alerts := engine.Alerts()
+	assert.Equal(t, StateFiring, alerts[0].State)
+	assert.InDelta(t, 200.0, alerts[0].Value, 0)
+
+	now = now.Add(time.Minute)
+	repository.values[`HeapAlloc{instance="b"}`] = 50.0
+	repository.values[`PollCount{instance="a"}`] = int64(20)
+	require.NoError(t, engine.Evaluate(context.Background()))
+	alerts = engine.Alerts()
+	assert.Equal(t, StateResolved, alerts[0].State)
+	assert.Equal(t, StateFiring, alerts[1].State, "series of agent b stopped polling")
+}
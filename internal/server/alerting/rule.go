@@ -0,0 +1,98 @@
+// Package alerting contains alerting rules parsing and evaluation
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FunctionNone = ""
+	FunctionRate = "rate"
+)
+
+var ErrInvalidRule = errors.New("invalid rule")
+
+var operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// Rule is a threshold condition over one metric, it is checked for every series of the metric
+// ID regardless of labels and holds when it holds for any of them.
+// Expression format is `[rate(]Metric[)] OP Threshold [for Duration]`,
+// for example `HeapAlloc > 1e9 for 2m` or `rate(PollCount) == 0 for 1m`.
+type Rule struct {
+	Name      string
+	Expr      string
+	Metric    string
+	Function  string
+	Operator  string
+	Threshold float64
+	For       time.Duration
+}
+
+// ParseRule parses rule expression.
+func ParseRule(name, expr string) (Rule, error) {
+	rule := Rule{
+		Name: name,
+		Expr: expr,
+	}
+	condition := strings.TrimSpace(expr)
+	if i := strings.LastIndex(condition, " for "); i >= 0 {
+		d, err := time.ParseDuration(strings.TrimSpace(condition[i+len(" for "):]))
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: '%s' duration: %w", ErrInvalidRule, expr, err)
+		}
+		rule.For = d
+		condition = strings.TrimSpace(condition[:i])
+	}
+	opIndex := -1
+	for _, op := range operators {
+		if i := strings.Index(condition, op); i >= 0 {
+			opIndex = i
+			rule.Operator = op
+			break
+		}
+	}
+	if opIndex < 0 {
+		return Rule{}, fmt.Errorf("%w: '%s' has no comparison operator", ErrInvalidRule, expr)
+	}
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(condition[opIndex+len(rule.Operator):]), 64)
+	if err != nil {
+		return Rule{}, fmt.Errorf("%w: '%s' threshold: %w", ErrInvalidRule, expr, err)
+	}
+	rule.Threshold = threshold
+	operand := strings.TrimSpace(condition[:opIndex])
+	if strings.HasPrefix(operand, FunctionRate+"(") && strings.HasSuffix(operand, ")") {
+		rule.Function = FunctionRate
+		operand = strings.TrimSpace(operand[len(FunctionRate)+1 : len(operand)-1])
+	}
+	if operand == "" || strings.ContainsAny(operand, " ()") {
+		return Rule{}, fmt.Errorf("%w: '%s' metric name", ErrInvalidRule, expr)
+	}
+	rule.Metric = operand
+	if rule.Name == "" {
+		rule.Name = expr
+	}
+	return rule, nil
+}
+
+func (r *Rule) holds(value float64) bool {
+	switch r.Operator {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	case "==":
+		return value == r.Threshold
+	case "!=":
+		return value != r.Threshold
+	default:
+		return false
+	}
+}
@@ -0,0 +1,72 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected Rule
+	}{
+		{
+			name: "threshold with duration",
+			expr: "HeapAlloc > 1e9 for 2m",
+			expected: Rule{
+				Metric:    "HeapAlloc",
+				Operator:  ">",
+				Threshold: 1e9,
+				For:       2 * time.Minute,
+			},
+		},
+		{
+			name: "rate",
+			expr: "rate(PollCount) == 0 for 1m",
+			expected: Rule{
+				Metric:    "PollCount",
+				Function:  FunctionRate,
+				Operator:  "==",
+				Threshold: 0,
+				For:       time.Minute,
+			},
+		},
+		{
+			name: "without duration",
+			expr: "RandomValue <= -0.5",
+			expected: Rule{
+				Metric:    "RandomValue",
+				Operator:  "<=",
+				Threshold: -0.5,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule("", tt.expr)
+			require.NoError(t, err)
+			tt.expected.Name = tt.expr
+			tt.expected.Expr = tt.expr
+			assert.Equal(t, tt.expected, rule)
+		})
+	}
+}
+
+func TestParseInvalidRule(t *testing.T) {
+	for _, expr := range []string{
+		"HeapAlloc",
+		"HeapAlloc > abc",
+		"HeapAlloc > 1 for forever",
+		"> 1",
+		"rate(PollCount > 1",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseRule("test", expr)
+			assert.ErrorIs(t, err, ErrInvalidRule)
+		})
+	}
+}
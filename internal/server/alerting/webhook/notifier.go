@@ -0,0 +1,242 @@
+// Package webhook delivers alert state changes to webhook receivers
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-service/internal/server/alerting"
+	"go-metrics-service/pkg/gohelpers"
+	"go-metrics-service/pkg/timeutils"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+var errReceiverRejected = errors.New("receiver rejected notification")
+
+type Config struct {
+	Receivers     []ReceiverConfig
+	RetryAttempts []time.Duration
+	TickInterval  time.Duration
+}
+
+// ReceiverConfig describes one webhook receiver.
+// Alerts changed within GroupWait are delivered in one payload.
+// Still firing alerts are delivered again every RepeatInterval.
+type ReceiverConfig struct {
+	URL            string
+	GroupWait      time.Duration
+	RepeatInterval time.Duration
+}
+
+// Payload is a JSON body posted to receivers.
+//
+//nolint:govet // field alignment
+type Payload struct {
+	Alerts []alerting.Alert `json:"alerts"`
+	SentAt time.Time        `json:"sent_at"`
+}
+
+type delivery struct {
+	state  string
+	sentAt time.Time
+}
+
+type receiver struct {
+	cfg            ReceiverConfig
+	pending        map[string]alerting.Alert
+	firstPendingAt time.Time
+	delivered      map[string]delivery
+}
+
+type Notifier struct {
+	logger    *zap.Logger
+	client    *resty.Client
+	doneCh    chan struct{}
+	mux       *sync.Mutex
+	firing    map[string]alerting.Alert
+	receivers []*receiver
+	now       func() time.Time
+	cfg       Config
+}
+
+func New(cfg Config, logger *zap.Logger) *Notifier {
+	receivers := make([]*receiver, len(cfg.Receivers))
+	for i, rc := range cfg.Receivers {
+		receivers[i] = &receiver{
+			cfg:       rc,
+			pending:   make(map[string]alerting.Alert),
+			delivered: make(map[string]delivery),
+		}
+	}
+	return &Notifier{
+		cfg:       cfg,
+		logger:    logger,
+		client:    resty.New(),
+		doneCh:    make(chan struct{}),
+		mux:       &sync.Mutex{},
+		firing:    make(map[string]alerting.Alert),
+		receivers: receivers,
+		now:       time.Now,
+	}
+}
+
+func (n *Notifier) Start() chan error {
+	return gohelpers.StartTickerProcess(n.doneCh, n.Flush, n.cfg.TickInterval)
+}
+
+func (n *Notifier) Stop() {
+	close(n.doneCh)
+}
+
+// Notify queues firing and resolved alerts for delivery.
+func (n *Notifier) Notify(alert alerting.Alert) {
+	if alert.State != alerting.StateFiring && alert.State != alerting.StateResolved {
+		return
+	}
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	if alert.State == alerting.StateFiring {
+		n.firing[alert.Name] = alert
+	} else {
+		delete(n.firing, alert.Name)
+	}
+	now := n.now()
+	for _, r := range n.receivers {
+		if last, ok := r.delivered[alert.Name]; ok && last.state == alert.State {
+			delete(r.pending, alert.Name)
+			continue
+		}
+		if len(r.pending) == 0 {
+			r.firstPendingAt = now
+		}
+		r.pending[alert.Name] = alert
+	}
+}
+
+// Flush delivers every receiver batch whose grouping window elapsed
+// along with still firing alerts due for repeat. Receivers are flushed concurrently,
+// so slow receiver does not delay the others.
+func (n *Notifier) Flush(ctx context.Context) error {
+	errs := make([]error, len(n.receivers))
+	wg := &sync.WaitGroup{}
+	for i, r := range n.receivers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = n.flushReceiver(ctx, r)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) flushReceiver(ctx context.Context, r *receiver) error {
+	batch := n.collect(r)
+	if len(batch) == 0 {
+		return nil
+	}
+	err := n.send(ctx, r.cfg.URL, batch)
+	if err == nil {
+		n.markDelivered(r, batch)
+		return nil
+	}
+	// Receiver refusing payload refuses it again, only transient failures are retried.
+	if errors.Is(err, errReceiverRejected) {
+		n.logger.Error("notification dropped", zap.String("url", r.cfg.URL), zap.Int("alerts", len(batch)))
+	} else {
+		n.requeue(r, batch)
+	}
+	return fmt.Errorf("failed to notify '%s': %w", r.cfg.URL, err)
+}
+
+func (n *Notifier) collect(r *receiver) []alerting.Alert {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	now := n.now()
+	batch := make(map[string]alerting.Alert)
+	if len(r.pending) > 0 && now.Sub(r.firstPendingAt) >= r.cfg.GroupWait {
+		for name, alert := range r.pending {
+			batch[name] = alert
+		}
+		r.pending = make(map[string]alerting.Alert)
+	}
+	if r.cfg.RepeatInterval > 0 {
+		for name, alert := range n.firing {
+			last, ok := r.delivered[name]
+			if ok && last.state == alerting.StateFiring && now.Sub(last.sentAt) >= r.cfg.RepeatInterval {
+				batch[name] = alert
+			}
+		}
+	}
+	res := make([]alerting.Alert, 0, len(batch))
+	for _, alert := range batch {
+		res = append(res, alert)
+	}
+	return res
+}
+
+func (n *Notifier) requeue(r *receiver, batch []alerting.Alert) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	if len(r.pending) == 0 {
+		r.firstPendingAt = n.now()
+	}
+	for _, alert := range batch {
+		if _, ok := r.pending[alert.Name]; !ok {
+			r.pending[alert.Name] = alert
+		}
+	}
+}
+
+func (n *Notifier) markDelivered(r *receiver, batch []alerting.Alert) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	now := n.now()
+	for _, alert := range batch {
+		r.delivered[alert.Name] = delivery{
+			state:  alert.State,
+			sentAt: now,
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, url string, alerts []alerting.Alert) error {
+	body, err := json.Marshal(Payload{
+		Alerts: alerts,
+		SentAt: n.now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return timeutils.Retry( //nolint:wrapcheck // wrapping unnecessary
+		ctx,
+		n.cfg.RetryAttempts,
+		func(ctx context.Context) error {
+			resp, err := n.client.R().
+				SetContext(ctx).
+				SetHeader("Content-Type", "application/json").
+				SetBody(body).
+				Post(url)
+			if err != nil {
+				return fmt.Errorf("%w: post failed", err)
+			}
+			if resp.StatusCode() >= http.StatusBadRequest && resp.StatusCode() < http.StatusInternalServerError {
+				return fmt.Errorf("%w: %d", errReceiverRejected, resp.StatusCode())
+			}
+			if resp.StatusCode() >= http.StatusMultipleChoices {
+				return fmt.Errorf("unexpected status code: %d", resp.StatusCode())
+			}
+			return nil
+		},
+		func(err error) bool {
+			n.logger.Error("webhook delivery failed", zap.String("url", url), zap.Error(err))
+			return !errors.Is(err, errReceiverRejected)
+		},
+	)
+}
@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"go-metrics-service/internal/server/alerting"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type receiverStub struct {
+	server     *httptest.Server
+	mux        *sync.Mutex
+	payloads   []Payload
+	failures   int
+	rejections int
+}
+
+func newReceiverStub(t *testing.T, failures int) *receiverStub {
+	t.Helper()
+	stub := &receiverStub{
+		mux:      &sync.Mutex{},
+		failures: failures,
+	}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mux.Lock()
+		defer stub.mux.Unlock()
+		if stub.failures > 0 {
+			stub.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if stub.rejections > 0 {
+			stub.rejections--
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var payload Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		stub.payloads = append(stub.payloads, payload)
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func (s *receiverStub) received() []Payload {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]Payload(nil), s.payloads...)
+}
+
+func newTestNotifier(url string, now *time.Time) *Notifier {
+	n := New(
+		Config{
+			Receivers: []ReceiverConfig{{
+				URL:            url,
+				GroupWait:      10 * time.Second,
+				RepeatInterval: time.Hour,
+			}},
+			RetryAttempts: []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond},
+		},
+		zap.NewNop(),
+	)
+	n.now = func() time.Time { return *now }
+	return n
+}
+
+func TestNotifierGroupingDedupAndRepeat(t *testing.T) {
+	stub := newReceiverStub(t, 0)
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	n := newTestNotifier(stub.server.URL, &now)
+	ctx := context.Background()
+
+	n.Notify(alerting.Alert{Name: "pending", State: alerting.StatePending})
+	n.Notify(alerting.Alert{Name: "first", State: alerting.StateFiring})
+	require.NoError(t, n.Flush(ctx))
+	assert.Empty(t, stub.received(), "grouping window has not elapsed")
+
+	now = now.Add(5 * time.Second)
+	n.Notify(alerting.Alert{Name: "second", State: alerting.StateFiring})
+	now = now.Add(5 * time.Second)
+	require.NoError(t, n.Flush(ctx))
+	require.Len(t, stub.received(), 1)
+	assert.Len(t, stub.received()[0].Alerts, 2)
+
+	n.Notify(alerting.Alert{Name: "first", State: alerting.StateFiring})
+	now = now.Add(time.Minute)
+	require.NoError(t, n.Flush(ctx))
+	assert.Len(t, stub.received(), 1, "duplicate firing notification must be skipped")
+
+	n.Notify(alerting.Alert{Name: "second", State: alerting.StateResolved})
+	now = now.Add(time.Hour)
+	require.NoError(t, n.Flush(ctx))
+	received := stub.received()
+	require.Len(t, received, 2)
+	states := make(map[string]string)
+	for _, alert := range received[1].Alerts {
+		states[alert.Name] = alert.State
+	}
+	assert.Equal(t, map[string]string{
+		"first":  alerting.StateFiring,
+		"second": alerting.StateResolved,
+	}, states, "resolved alert is delivered with repeated still firing one")
+}
+
+func TestNotifierRetry(t *testing.T) {
+	stub := newReceiverStub(t, 2)
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	n := newTestNotifier(stub.server.URL, &now)
+
+	n.Notify(alerting.Alert{Name: "first", State: alerting.StateFiring})
+	now = now.Add(time.Minute)
+	require.NoError(t, n.Flush(context.Background()))
+	require.Len(t, stub.received(), 1)
+	assert.Equal(t, "first", stub.received()[0].Alerts[0].Name)
+}
+
+func TestNotifierDropsRejected(t *testing.T) {
+	stub := newReceiverStub(t, 0)
+	stub.rejections = 1
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	n := newTestNotifier(stub.server.URL, &now)
+
+	n.Notify(alerting.Alert{Name: "first", State: alerting.StateFiring})
+	now = now.Add(time.Minute)
+	require.ErrorIs(t, n.Flush(context.Background()), errReceiverRejected)
+
+	n.Notify(alerting.Alert{Name: "second", State: alerting.StateFiring})
+	now = now.Add(time.Minute)
+	require.NoError(t, n.Flush(context.Background()))
+	received := stub.received()
+	require.Len(t, received, 1)
+	require.Len(t, received[0].Alerts, 1)
+	assert.Equal(t, "second", received[0].Alerts[0].Name, "rejected notification is not sent again")
+}
+
+func TestNotifierFlushesReceiversConcurrently(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := newReceiverStub(t, 0)
+
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	n := New(
+		Config{Receivers: []ReceiverConfig{{URL: slow.URL}, {URL: fast.server.URL}}, RetryAttempts: []time.Duration{0}},
+		zap.NewNop(),
+	)
+	n.now = func() time.Time { return now }
+	n.Notify(alerting.Alert{Name: "first", State: alerting.StateFiring})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = n.Flush(ctx)
+	}()
+	assert.Eventually(t, func() bool { return len(fast.received()) == 1 }, time.Second, 5*time.Millisecond,
+		"slow receiver delays the others")
+}
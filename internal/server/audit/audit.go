@@ -0,0 +1,97 @@
+// Package audit contains audit trail of administrative actions
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	ActionDelete = "delete"
+)
+
+type (
+	requesterKey struct{}
+	reportedKey  struct{}
+)
+
+// Event describes one administrative action.
+//
+//nolint:govet // field alignment
+type Event struct {
+	Time        time.Time `json:"time"`
+	Action      string    `json:"action"`
+	RequesterIP string    `json:"requester_ip"`
+	// ReportedIP is address claimed by client in request header, it is not verified.
+	ReportedIP string `json:"reported_ip,omitempty"`
+	// Filter describes how affected keys were selected.
+	Filter string   `json:"filter,omitempty"`
+	Keys   []string `json:"keys"`
+}
+
+// Trail writes events to server log and, when writer is set, as JSON lines to writer.
+type Trail struct {
+	writer io.Writer
+	logger *zap.Logger
+	mux    *sync.Mutex
+}
+
+func New(writer io.Writer, logger *zap.Logger) *Trail {
+	return &Trail{
+		writer: writer,
+		logger: logger,
+		mux:    &sync.Mutex{},
+	}
+}
+
+// Record stores event. Failures are logged and never interrupt the audited action.
+func (t *Trail) Record(event Event) {
+	t.logger.Info(
+		"audit",
+		zap.String("action", event.Action),
+		zap.String("requester_ip", event.RequesterIP),
+		zap.String("reported_ip", event.ReportedIP),
+		zap.String("filter", event.Filter),
+		zap.Strings("keys", event.Keys),
+	)
+	if t.writer == nil {
+		return
+	}
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		t.logger.Error("failed to encode audit event", zap.Error(err))
+		return
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if _, err := t.writer.Write(append(encoded, '\n')); err != nil {
+		t.logger.Error("failed to write audit event", zap.Error(err))
+	}
+}
+
+// WithRequesterIP puts address of the client requesting action into context.
+func WithRequesterIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, ip)
+}
+
+// RequesterIPFromContext returns address put with WithRequesterIP.
+func RequesterIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(requesterKey{}).(string)
+	return ip
+}
+
+// WithReportedIP puts address claimed by the client into context.
+func WithReportedIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, reportedKey{}, ip)
+}
+
+// ReportedIPFromContext returns address put with WithReportedIP.
+func ReportedIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(reportedKey{}).(string)
+	return ip
+}
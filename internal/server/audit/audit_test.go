@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTrailRecord(t *testing.T) {
+	var buffer bytes.Buffer
+	trail := New(&buffer, zap.NewNop())
+	event := Event{
+		Time:        time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Action:      ActionDelete,
+		RequesterIP: "10.0.0.1",
+		ReportedIP:  "192.168.0.7",
+		Filter:      "prefix=cpu_",
+		Keys:        []string{"cpu_idle", "cpu_usage"},
+	}
+
+	trail.Record(event)
+	trail.Record(event)
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var decoded Event
+	require.NoError(t, json.Unmarshal(lines[0], &decoded))
+	assert.Equal(t, event, decoded)
+}
+
+func TestTrailRecordWithoutWriter(t *testing.T) {
+	assert.NotPanics(t, func() {
+		New(nil, zap.NewNop()).Record(Event{Action: ActionDelete})
+	})
+}
+
+func TestRequesterIP(t *testing.T) {
+	assert.Empty(t, RequesterIPFromContext(context.Background()))
+	ctx := WithRequesterIP(context.Background(), "192.168.0.1")
+	assert.Equal(t, "192.168.0.1", RequesterIPFromContext(ctx))
+	assert.Empty(t, ReportedIPFromContext(ctx))
+	ctx = WithReportedIP(ctx, "10.0.0.1")
+	assert.Equal(t, "10.0.0.1", ReportedIPFromContext(ctx))
+	assert.Equal(t, "192.168.0.1", RequesterIPFromContext(ctx))
+}
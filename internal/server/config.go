@@ -11,4 +11,13 @@ type Config struct {
 	ShutdownTimeout time.Duration
 	NeedRestore     bool
 	TrustedSubnet   string
+	// HashReplayWindow is maximum age of signed request timestamp.
+	HashReplayWindow time.Duration
+	// NonceCacheSize bounds number of remembered nonces of signed requests.
+	NonceCacheSize int
+	// IdempotencyTTL is how long responses are kept for retried requests, 0 disables idempotency keys.
+	// Batch updates are not kept, their key is batch ID applied once by controller.
+	IdempotencyTTL time.Duration
+	// IdempotencyCacheSize bounds number of kept responses.
+	IdempotencyCacheSize int
 }
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+)
+
+// batchLocks lets only one copy of batch be applied at a time, so duplicate waits for outcome of the first copy.
+type batchLocks struct {
+	mux      sync.Mutex
+	inFlight map[string]chan struct{}
+}
+
+func newBatchLocks() *batchLocks {
+	return &batchLocks{
+		inFlight: make(map[string]chan struct{}),
+	}
+}
+
+// lock waits until no other copy of batch is being applied and returns func releasing the batch.
+func (l *batchLocks) lock(ctx context.Context, key string) (func(), error) {
+	for {
+		l.mux.Lock()
+		done, busy := l.inFlight[key]
+		if !busy {
+			done = make(chan struct{})
+			l.inFlight[key] = done
+			l.mux.Unlock()
+			return func() {
+				l.mux.Lock()
+				delete(l.inFlight, key)
+				l.mux.Unlock()
+				close(done)
+			}, nil
+		}
+		l.mux.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err() //nolint:wrapcheck // unnecessary
+		}
+	}
+}
@@ -6,15 +6,32 @@ import (
 	"errors"
 	"fmt"
 	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/audit"
+	"go-metrics-service/internal/server/data"
 	"go-metrics-service/internal/server/logic"
+	"slices"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// Config tunes how controller stores reported metrics.
+type Config struct {
+	// InstanceLabel keeps series of every agent apart under instance label with agent ID,
+	// otherwise agents reporting the same metric update one series.
+	InstanceLabel bool
+}
+
 type Controller struct {
-	l  *zap.Logger
-	tm TransactionManager
-	s  Service
+	cfg Config
+	l   *zap.Logger
+	tm  TransactionManager
+	s   Service
+	a   AgentsRegistry
+	au  Auditor
+	p   Publisher
+	b   *batchLocks
 }
 
 type Service interface {
@@ -22,6 +39,31 @@ type Service interface {
 	UpdateGauges(ctx context.Context, diffs []logic.GaugeDiff) error
 	UpdateCounter(ctx context.Context, diff logic.CounterDiff) error
 	UpdateCounters(ctx context.Context, diffs []logic.CounterDiff) error
+	UpdateHistogram(ctx context.Context, diff logic.HistogramDiff) error
+	UpdateHistograms(ctx context.Context, diffs []logic.HistogramDiff) error
+	UpdateBatch(
+		ctx context.Context,
+		counters []logic.CounterDiff,
+		gauges []logic.GaugeDiff,
+		histograms []logic.HistogramDiff,
+	) error
+	DeleteMetric(ctx context.Context, metricType, key string) error
+	DeleteMetrics(ctx context.Context, filter *logic.DeleteFilter) ([]string, error)
+	BatchApplied(ctx context.Context, key string) (bool, error)
+	MarkBatch(ctx context.Context, key string) (bool, error)
+}
+
+type AgentsRegistry interface {
+	Observe(identity agents.Identity, seriesKeys []string)
+}
+
+// Publisher receives metrics updates applied by controller. Implementations must not block.
+type Publisher interface {
+	Publish(metrics []protocol.Metrics)
+}
+
+type Auditor interface {
+	Record(event audit.Event)
 }
 
 type TransactionManager interface {
@@ -31,18 +73,54 @@ type TransactionManager interface {
 var (
 	ErrNonExistentType = errors.New("non-existent type")
 	ErrWrongValueType  = errors.New("wrong value type")
+	// errBatchApplied rolls back batch which was applied concurrently by another server sharing the database.
+	errBatchApplied = errors.New("batch already applied")
 )
 
-func NewController(tm TransactionManager, gs Service, l *zap.Logger) *Controller {
+// MetricError reports position of metric which failed batch update.
+type MetricError struct {
+	Err   error
+	Index int
+}
+
+func (e *MetricError) Error() string {
+	return fmt.Sprintf("metric %d: %v", e.Index, e.Err)
+}
+
+func (e *MetricError) Unwrap() error {
+	return e.Err
+}
+
+func NewController(
+	cfg Config,
+	tm TransactionManager,
+	gs Service,
+	a AgentsRegistry,
+	au Auditor,
+	p Publisher,
+	l *zap.Logger,
+) *Controller {
 	return &Controller{
-		l:  l,
-		tm: tm,
-		s:  gs,
+		cfg: cfg,
+		l:   l,
+		tm:  tm,
+		s:   gs,
+		a:   a,
+		au:  au,
+		p:   p,
+		b:   newBatchLocks(),
 	}
 }
 
 func (c *Controller) Update(ctx context.Context, metric protocol.Metrics) error {
-	return c.tm.DoWithTransaction(ctx, func(ctx context.Context) error { //nolint:wrapcheck // unnecessary
+	if err := validateSeries(metric); err != nil {
+		return err
+	}
+	identity, identified := agents.IdentityFromContext(ctx)
+	if identified && c.cfg.InstanceLabel {
+		metric = withInstance(metric, identity.ID)
+	}
+	err := c.tm.DoWithTransaction(ctx, func(ctx context.Context) error {
 		switch metric.MType {
 		case protocol.Gauge:
 			if metric.Value == nil {
@@ -51,7 +129,7 @@ func (c *Controller) Update(ctx context.Context, metric protocol.Metrics) error
 			if err := c.s.UpdateGauge(
 				ctx,
 				logic.GaugeDiff{
-					Key:      metric.ID,
+					Key:      metric.SeriesKey(),
 					NewValue: *metric.Value,
 				},
 			); err != nil {
@@ -64,61 +142,194 @@ func (c *Controller) Update(ctx context.Context, metric protocol.Metrics) error
 			if err := c.s.UpdateCounter(
 				ctx,
 				logic.CounterDiff{
-					Key:   metric.ID,
+					Key:   metric.SeriesKey(),
 					Delta: *metric.Delta,
 				},
 			); err != nil {
 				return fmt.Errorf("change counter: %w", err)
 			}
+		case protocol.Histogram:
+			if metric.Histogram == nil {
+				return ErrWrongValueType
+			}
+			if err := c.s.UpdateHistogram(
+				ctx,
+				logic.HistogramDiff{
+					Key:   metric.SeriesKey(),
+					Delta: *metric.Histogram,
+				},
+			); err != nil {
+				return fmt.Errorf("merge histogram: %w", err)
+			}
 		default:
 			return ErrNonExistentType
 		}
 		return nil
 	})
+	if err != nil {
+		return err //nolint:wrapcheck // unnecessary
+	}
+	if identified {
+		c.a.Observe(identity, []string{metric.SeriesKey()})
+	}
+	c.p.Publish([]protocol.Metrics{metric})
+	return nil
 }
 
 func (c *Controller) UpdateMany(ctx context.Context, metrics []protocol.Metrics) error {
-	return c.tm.DoWithTransaction(ctx, func(ctx context.Context) error { //nolint:wrapcheck // unnecessary
+	identity, identified := agents.IdentityFromContext(ctx)
+	seriesKeys := make([]string, 0, len(metrics))
+	applied := make([]protocol.Metrics, 0, len(metrics))
+	batchKey, hasBatch := batchKeyFromContext(ctx, identity.ID)
+	// Empty batch changes nothing, so its ID is not recorded.
+	hasBatch = hasBatch && len(metrics) > 0
+	if hasBatch {
+		unlock, err := c.b.lock(ctx, batchKey)
+		if err != nil {
+			return fmt.Errorf("waiting for batch failed: %w", err)
+		}
+		defer unlock()
+	}
+	duplicate := false
+	err := c.tm.DoWithTransaction(ctx, func(ctx context.Context) error {
+		if hasBatch {
+			applied, err := c.s.BatchApplied(ctx, batchKey)
+			if err != nil {
+				return fmt.Errorf("check batch failed: %w", err)
+			}
+			if applied {
+				duplicate = true
+				return nil
+			}
+		}
 		counterDiffs := make([]logic.CounterDiff, 0)
 		gaugeDiffs := make([]logic.GaugeDiff, 0)
-		for _, metric := range metrics {
+		histogramDiffs := make([]logic.HistogramDiff, 0)
+		seriesTypes := make(map[string]string, len(metrics))
+		for i, metric := range metrics {
+			if err := validateSeries(metric); err != nil {
+				return &MetricError{Err: err, Index: i}
+			}
+			if identified && c.cfg.InstanceLabel {
+				metric = withInstance(metric, identity.ID)
+			}
+			seriesKey := metric.SeriesKey()
+			if mType, ok := seriesTypes[seriesKey]; ok && mType != metric.MType {
+				return &MetricError{Err: data.ErrWrongType, Index: i}
+			}
+			seriesTypes[seriesKey] = metric.MType
+			seriesKeys = append(seriesKeys, seriesKey)
+			applied = append(applied, metric)
 			switch metric.MType {
 			case protocol.Gauge:
 				if metric.Value == nil {
-					return ErrWrongValueType
+					return &MetricError{Err: ErrWrongValueType, Index: i}
 				}
 				gaugeDiffs = append(
 					gaugeDiffs,
 					logic.GaugeDiff{
-						Key:      metric.ID,
+						Key:      seriesKey,
 						NewValue: *metric.Value,
 					},
 				)
 			case protocol.Counter:
 				if metric.Delta == nil {
-					return ErrWrongValueType
+					return &MetricError{Err: ErrWrongValueType, Index: i}
 				}
 				counterDiffs = append(
 					counterDiffs,
 					logic.CounterDiff{
-						Key:   metric.ID,
+						Key:   seriesKey,
 						Delta: *metric.Delta,
 					},
 				)
+			case protocol.Histogram:
+				if metric.Histogram == nil {
+					return &MetricError{Err: ErrWrongValueType, Index: i}
+				}
+				histogramDiffs = append(
+					histogramDiffs,
+					logic.HistogramDiff{
+						Key:   seriesKey,
+						Delta: *metric.Histogram,
+					},
+				)
 			default:
-				return ErrNonExistentType
+				return &MetricError{Err: ErrNonExistentType, Index: i}
 			}
 		}
-		err := c.s.UpdateCounters(ctx, counterDiffs)
-		if err != nil {
-			return fmt.Errorf("update counters failed: %w", err)
+		if err := c.s.UpdateBatch(ctx, counterDiffs, gaugeDiffs, histogramDiffs); err != nil {
+			return fmt.Errorf("update batch failed: %w", err)
 		}
-		err = c.s.UpdateGauges(ctx, gaugeDiffs)
+		if !hasBatch {
+			return nil
+		}
+		fresh, err := c.s.MarkBatch(ctx, batchKey)
 		if err != nil {
-			return fmt.Errorf("update gauges failed: %w", err)
+			return fmt.Errorf("mark batch failed: %w", err)
+		}
+		if !fresh {
+			return errBatchApplied
 		}
 		return nil
 	})
+	if errors.Is(err, errBatchApplied) {
+		duplicate, err = true, nil
+	}
+	if err != nil {
+		return withMetricIndex(err, seriesKeys)
+	}
+	if duplicate {
+		c.l.Info("batch already applied", zap.String("batch", batchKey))
+		return nil
+	}
+	if identified {
+		c.a.Observe(identity, seriesKeys)
+	}
+	c.p.Publish(applied)
+	return nil
+}
+
+// validateSeries rejects metric whose ID or labels would make ambiguous series key.
+func validateSeries(metric protocol.Metrics) error {
+	if err := protocol.ValidateID(metric.ID); err != nil {
+		return err //nolint:wrapcheck // unnecessary
+	}
+	return protocol.ValidateLabels(metric.Labels) //nolint:wrapcheck // unnecessary
+}
+
+// batchKeyFromContext returns key of the batch being applied scoped by agent ID.
+func batchKeyFromContext(ctx context.Context, agentID string) (string, bool) {
+	batchID, ok := data.BatchIDFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return agentID + "/" + batchID, true
+}
+
+// withMetricIndex points error caused by particular series to position of its metric in batch.
+func withMetricIndex(err error, seriesKeys []string) error {
+	var metricErr *MetricError
+	var keyErr *data.KeyError
+	if errors.As(err, &metricErr) || !errors.As(err, &keyErr) {
+		return err
+	}
+	index := slices.Index(seriesKeys, keyErr.Key)
+	if index < 0 {
+		return err
+	}
+	return &MetricError{Err: err, Index: index}
+}
+
+// withInstance puts reporting agent ID into instance label so that every agent gets its own series.
+func withInstance(metric protocol.Metrics, instance string) protocol.Metrics {
+	labels := make(map[string]string, len(metric.Labels)+1)
+	for name, value := range metric.Labels {
+		labels[name] = value
+	}
+	labels[protocol.InstanceLabel] = instance
+	metric.Labels = labels
+	return metric
 }
 
 func (c *Controller) SetGauge(ctx context.Context, key string, value float64) error {
@@ -146,3 +357,53 @@ func (c *Controller) ChangeCounter(ctx context.Context, key string, delta int64)
 		)
 	})
 }
+
+// Delete removes one series and records deletion to audit trail.
+func (c *Controller) Delete(ctx context.Context, metric protocol.Metrics) error {
+	if err := validateSeries(metric); err != nil {
+		return err
+	}
+	switch metric.MType {
+	case protocol.Gauge, protocol.Counter, protocol.Histogram:
+	default:
+		return ErrNonExistentType
+	}
+	key := metric.SeriesKey()
+	err := c.tm.DoWithTransaction(ctx, func(ctx context.Context) error {
+		return c.s.DeleteMetric(ctx, metric.MType, key) //nolint:wrapcheck // unnecessary
+	})
+	if err != nil {
+		return err //nolint:wrapcheck // unnecessary
+	}
+	c.au.Record(audit.Event{
+		Time:        time.Now(),
+		Action:      audit.ActionDelete,
+		RequesterIP: audit.RequesterIPFromContext(ctx),
+		ReportedIP:  audit.ReportedIPFromContext(ctx),
+		Filter:      "type=" + metric.MType,
+		Keys:        []string{key},
+	})
+	return nil
+}
+
+// DeleteMatching removes every series matching filter and records deletion to audit trail.
+func (c *Controller) DeleteMatching(ctx context.Context, filter *logic.DeleteFilter) ([]string, error) {
+	var deleted []string
+	err := c.tm.DoWithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		deleted, err = c.s.DeleteMetrics(ctx, filter)
+		return err //nolint:wrapcheck // unnecessary
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // unnecessary
+	}
+	c.au.Record(audit.Event{
+		Time:        time.Now(),
+		Action:      audit.ActionDelete,
+		RequesterIP: audit.RequesterIPFromContext(ctx),
+		ReportedIP:  audit.ReportedIPFromContext(ctx),
+		Filter:      filter.String(),
+		Keys:        deleted,
+	})
+	return deleted, nil
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/audit"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/internal/server/data/repositories/memrepository"
+	"go-metrics-service/internal/server/data/storages"
+	"go-metrics-service/internal/server/data/storages/memstorage"
+	"go-metrics-service/internal/server/logic"
+	"go-metrics-service/internal/server/watch"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+// stallingService holds first batch update until released and fails it.
+type stallingService struct {
+	*logic.Service
+	started chan struct{}
+	release chan struct{}
+	calls   int
+}
+
+func (s *stallingService) UpdateBatch(
+	ctx context.Context,
+	counters []logic.CounterDiff,
+	gauges []logic.GaugeDiff,
+	histograms []logic.HistogramDiff,
+) error {
+	s.calls++
+	if s.calls == 1 {
+		close(s.started)
+		<-s.release
+		return errUnavailable
+	}
+	return s.Service.UpdateBatch(ctx, counters, gauges, histograms) //nolint:wrapcheck // unnecessary
+}
+
+func setupController(t *testing.T, s func(*logic.Service) Service) (*Controller, *memrepository.MemRepository) {
+	t.Helper()
+	logger := zap.NewNop()
+	repository := memrepository.New(memstorage.New(logger), data.HistoryConfig{}, logger)
+	controller := NewController(
+		Config{},
+		storages.NewDummyTransactionsManager(),
+		s(logic.NewService(repository, logger)),
+		agents.New(0, 0),
+		audit.New(nil, logger),
+		watch.New(0),
+		logger,
+	)
+	return controller, repository
+}
+
+func batchContext(batchID string) context.Context {
+	ctx := agents.WithIdentity(context.Background(), agents.Identity{ID: "agent"})
+	return data.WithBatchID(ctx, batchID)
+}
+
+func counter(id string, delta int64) protocol.Metrics {
+	return protocol.Metrics{ID: id, MType: protocol.Counter, Delta: &delta}
+}
+
+func gauge(id string, value float64) protocol.Metrics {
+	return protocol.Metrics{ID: id, MType: protocol.Gauge, Value: &value}
+}
+
+func TestUpdateManyDuplicateWaits(t *testing.T) {
+	service := &stallingService{started: make(chan struct{}), release: make(chan struct{})}
+	controller, repository := setupController(t, func(s *logic.Service) Service {
+		service.Service = s
+		return service
+	})
+	ctx := batchContext("batch")
+	batch := []protocol.Metrics{counter("Hits", 3)}
+
+	first := make(chan error, 1)
+	go func() { first <- controller.UpdateMany(ctx, batch) }()
+	<-service.started
+	duplicate := make(chan error, 1)
+	go func() { duplicate <- controller.UpdateMany(ctx, batch) }()
+	assert.Never(t, func() bool { return len(duplicate) > 0 }, 50*time.Millisecond, 5*time.Millisecond)
+
+	close(service.release)
+	require.ErrorIs(t, <-first, errUnavailable)
+	require.NoError(t, <-duplicate)
+	value, err := repository.GetCounter(ctx, "Hits")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), value)
+
+	require.NoError(t, controller.UpdateMany(ctx, batch))
+	value, err = repository.GetCounter(ctx, "Hits")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), value)
+}
+
+func TestUpdateManyRejectedBatch(t *testing.T) {
+	controller, repository := setupController(t, func(s *logic.Service) Service { return s })
+	ctx := batchContext("batch")
+	require.NoError(t, repository.SetCounter(ctx, "Mixed", 1))
+
+	tests := []struct {
+		name    string
+		err     error
+		metrics []protocol.Metrics
+		index   int
+	}{
+		{
+			name:    "stored under other type",
+			metrics: []protocol.Metrics{counter("Hits", 1), gauge("Mixed", 2)},
+			index:   1,
+			err:     data.ErrWrongType,
+		},
+		{
+			name:    "id holding series key delimiter",
+			metrics: []protocol.Metrics{counter("Hits", 1), gauge(`cpu{host="a"}`, 2)},
+			index:   1,
+			err:     protocol.ErrInvalidID,
+		},
+		{
+			name:    "reported under two types",
+			metrics: []protocol.Metrics{counter("Hits", 1), gauge("Hits", 2)},
+			index:   1,
+			err:     data.ErrWrongType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := controller.UpdateMany(ctx, tt.metrics)
+			require.ErrorIs(t, err, tt.err)
+			var metricErr *MetricError
+			require.ErrorAs(t, err, &metricErr)
+			assert.Equal(t, tt.index, metricErr.Index)
+			_, err = repository.GetCounter(ctx, "Hits")
+			assert.ErrorIs(t, err, data.ErrNotFound)
+		})
+	}
+
+	require.NoError(t, controller.UpdateMany(ctx, []protocol.Metrics{counter("Hits", 1)}))
+	value, err := repository.GetCounter(ctx, "Hits")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+}
+
+func TestUpdateManyEmptyBatchNotRecorded(t *testing.T) {
+	controller, repository := setupController(t, func(s *logic.Service) Service { return s })
+	ctx := batchContext("batch")
+
+	require.NoError(t, controller.UpdateMany(ctx, nil))
+	recorded, err := repository.HasBatch(ctx, "agent/batch")
+	require.NoError(t, err)
+	assert.False(t, recorded)
+}
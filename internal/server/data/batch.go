@@ -0,0 +1,21 @@
+package data
+
+import "context"
+
+type contextKey int
+
+const (
+	batchIDKey contextKey = iota
+)
+
+// WithBatchID returns context carrying ID of the batch being applied.
+// Batches with the same ID from the same agent are applied once.
+func WithBatchID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, batchIDKey, id)
+}
+
+// BatchIDFromContext returns batch ID if request carried one.
+func BatchIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(batchIDKey).(string)
+	return id, ok && id != ""
+}
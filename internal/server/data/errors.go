@@ -7,3 +7,17 @@ var (
 	ErrWrongType = errors.New("wrong type")
 	ErrNotFound  = errors.New("not found")
 )
+
+// KeyError reports series key operation failed on.
+type KeyError struct {
+	Err error
+	Key string
+}
+
+func (e *KeyError) Error() string {
+	return e.Err.Error() + ": " + e.Key
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,48 @@
+package data
+
+import "time"
+
+// ExpiryConfig controls series staleness.
+// Series not updated for StaleAfter are reported stale, series not updated for PurgeAfter are deleted.
+// IDs of applied batches are kept for BatchRetention to deduplicate retried batches.
+// Zero durations disable corresponding behaviour.
+type ExpiryConfig struct {
+	StaleAfter     time.Duration
+	PurgeAfter     time.Duration
+	BatchRetention time.Duration
+	// HistoryRetention is age of history samples deleted by sweep, zero keeps them forever.
+	HistoryRetention time.Duration
+	SweepInterval    time.Duration
+}
+
+// IsStale reports whether series updated at updatedAt is stale at provided moment.
+func (c ExpiryConfig) IsStale(updatedAt, now time.Time) bool {
+	return c.StaleAfter > 0 && now.Sub(updatedAt) >= c.StaleAfter
+}
+
+// PurgeBorder returns the oldest update time of series kept at provided moment.
+// Zero time is returned when series are never purged.
+func (c ExpiryConfig) PurgeBorder(now time.Time) time.Time {
+	if c.PurgeAfter <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-c.PurgeAfter)
+}
+
+// BatchBorder returns the oldest apply time of batch IDs kept at provided moment.
+// Zero time is returned when batch IDs are never purged.
+func (c ExpiryConfig) BatchBorder(now time.Time) time.Time {
+	if c.BatchRetention <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-c.BatchRetention)
+}
+
+// HistoryBorder returns the oldest timestamp of history samples kept at provided moment.
+// Zero time is returned when samples are never purged.
+func (c ExpiryConfig) HistoryBorder(now time.Time) time.Time {
+	if c.HistoryRetention <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-c.HistoryRetention)
+}
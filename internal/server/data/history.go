@@ -0,0 +1,26 @@
+package data
+
+import "time"
+
+// HistoryConfig enables time-series history keeping in repositories.
+// Zero Retention keeps samples forever.
+type HistoryConfig struct {
+	Enabled   bool
+	Retention time.Duration
+}
+
+// Sample is a metric value accepted by server at Timestamp.
+// Value is int64 for counters and float64 for gauges.
+type Sample struct {
+	Timestamp time.Time
+	Value     any
+}
+
+// RetentionBorder returns the oldest timestamp still kept for provided moment.
+// Zero time is returned when samples never expire.
+func (c HistoryConfig) RetentionBorder(now time.Time) time.Time {
+	if c.Retention <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-c.Retention)
+}
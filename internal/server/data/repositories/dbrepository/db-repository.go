@@ -4,9 +4,13 @@ package dbrepository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/data"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -20,16 +24,18 @@ type DBStorage interface {
 type DBRepository struct {
 	storage DBStorage
 	logger  *zap.Logger
+	history data.HistoryConfig
 }
 
 const (
 	dbQueryFailedMsg = "database query failed: %w"
 )
 
-func New(storage DBStorage, logger *zap.Logger) *DBRepository {
+func New(storage DBStorage, history data.HistoryConfig, logger *zap.Logger) *DBRepository {
 	return &DBRepository{
 		storage: storage,
 		logger:  logger,
+		history: history,
 	}
 }
 
@@ -83,14 +89,69 @@ func (r *DBRepository) SetCounter(ctx context.Context, key string, value int64)
 		insert into metrics (key, counter_value)
 		values ($1, $2)
 		on conflict (key)
-			do update set counter_value = $2;`
+			do update set counter_value = $2, updated_at = now();`
 	_, err := r.storage.Exec(ctx, query, key, value)
 	if err != nil {
 		return fmt.Errorf("setting counter failed: %w", err)
 	}
+	return r.appendHistory(ctx, "counter_value", map[string]any{key: value})
+}
+
+func (r *DBRepository) GetHistogram(ctx context.Context, key string) (protocol.HistogramValue, error) {
+	const query = `select histogram_value from metrics where key=$1`
+	row, err := r.storage.QueryRow(ctx, query, key)
+	if err != nil {
+		return protocol.HistogramValue{}, fmt.Errorf(dbQueryFailedMsg, err)
+	}
+	var raw sql.NullString
+	err = row.Scan(&raw)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return protocol.HistogramValue{}, data.ErrNotFound
+	default:
+		return protocol.HistogramValue{}, fmt.Errorf(dbQueryFailedMsg, err)
+	}
+	if !raw.Valid {
+		return protocol.HistogramValue{}, &data.KeyError{Err: data.ErrWrongType, Key: key}
+	}
+	return decodeHistogram(raw.String)
+}
+
+// SetHistogram replaces histogram value. Histograms are not kept in history.
+func (r *DBRepository) SetHistogram(ctx context.Context, key string, value protocol.HistogramValue) error {
+	return r.SetHistograms(ctx, map[string]protocol.HistogramValue{key: value})
+}
+
+func (r *DBRepository) SetHistograms(ctx context.Context, values map[string]protocol.HistogramValue) error {
+	if len(values) == 0 {
+		return nil
+	}
+	const query = `
+		insert into metrics (key, histogram_value)
+		values ($1, $2)
+		on conflict (key)
+			do update set histogram_value = $2, updated_at = now();`
+	for key, value := range values {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("encoding histogram failed: %w", err)
+		}
+		if _, err := r.storage.Exec(ctx, query, key, string(encoded)); err != nil {
+			return fmt.Errorf("setting histogram failed: %w", err)
+		}
+	}
 	return nil
 }
 
+func decodeHistogram(raw string) (protocol.HistogramValue, error) {
+	var res protocol.HistogramValue
+	if err := json.Unmarshal([]byte(raw), &res); err != nil {
+		return protocol.HistogramValue{}, fmt.Errorf("decoding histogram failed: %w", err)
+	}
+	return res, nil
+}
+
 func (r *DBRepository) SetCounters(ctx context.Context, values map[string]int64) error {
 	genericValues := make(map[string]any, len(values))
 	for key, value := range values {
@@ -115,7 +176,7 @@ func (r *DBRepository) setMany(ctx context.Context, dbFieldName string, values m
 		insert into metrics (key, %s)
 		values %s
 		on conflict (key)
-		    do update set %s = excluded.%s`
+		    do update set %s = excluded.%s, updated_at = now()`
 	const firstArgNumber = 1
 	const argsIsRow = 2
 	query := fmt.Sprintf(
@@ -133,25 +194,108 @@ func (r *DBRepository) setMany(ctx context.Context, dbFieldName string, values m
 	if err != nil {
 		return fmt.Errorf("setting values failed: %w", err)
 	}
+	return r.appendHistory(ctx, dbFieldName, values)
+}
+
+func (r *DBRepository) appendHistory(ctx context.Context, dbFieldName string, values map[string]any) error {
+	if !r.history.Enabled || len(values) == 0 {
+		return nil
+	}
+	const queryPattern = `
+		insert into metrics_history (key, ts, %s)
+		values %s`
+	const firstArgNumber = 1
+	const argsIsRow = 3
+	query := fmt.Sprintf(
+		queryPattern,
+		dbFieldName,
+		formatValuesRows(firstArgNumber, argsIsRow, len(values)),
+	)
+	now := time.Now()
+	args := make([]any, 0, len(values)*argsIsRow)
+	for key, value := range values {
+		args = append(args, key, now, value)
+	}
+	if _, err := r.storage.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("appending history failed: %w", err)
+	}
 	return nil
 }
 
+// DeleteHistoryBefore deletes history samples older than border, it is run by periodic sweep
+// so that writes are not slowed down by retention.
+func (r *DBRepository) DeleteHistoryBefore(ctx context.Context, border time.Time) (int, error) {
+	const query = `delete from metrics_history where ts < $1`
+	res, err := r.storage.Exec(ctx, query, border)
+	if err != nil {
+		return 0, fmt.Errorf("deleting history failed: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("deleting history failed: %w", err)
+	}
+	return int(deleted), nil
+}
+
 func (r *DBRepository) SetGauge(ctx context.Context, key string, value float64) error {
 	const query = `
 		insert into metrics (key, gauge_value)
 		values ($1, $2)
 		on conflict (key)
-			do update set gauge_value = $2;`
+			do update set gauge_value = $2, updated_at = now();`
 	_, err := r.storage.Exec(ctx, query, key, value)
 	if err != nil {
 		return fmt.Errorf("setting gauge failed: %w", err)
 	}
-	return nil
+	return r.appendHistory(ctx, "gauge_value", map[string]any{key: value})
+}
+
+func (r *DBRepository) GetType(ctx context.Context, key string) (string, error) {
+	const query = `
+		select case
+			when counter_value is not null then $2::text
+			when gauge_value is not null then $3::text
+			when histogram_value is not null then $4::text
+			else ''
+		end
+		from metrics
+		where key=$1`
+	row, err := r.storage.QueryRow(ctx, query, key, protocol.Counter, protocol.Gauge, protocol.Histogram)
+	if err != nil {
+		return "", fmt.Errorf(dbQueryFailedMsg, err)
+	}
+	var metricType string
+	err = row.Scan(&metricType)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return "", data.ErrNotFound
+	default:
+		return "", fmt.Errorf(dbQueryFailedMsg, err)
+	}
+	if metricType == "" {
+		return "", &data.KeyError{Err: data.ErrWrongType, Key: key}
+	}
+	return metricType, nil
 }
 
 func (r *DBRepository) GetAll(ctx context.Context) (map[string]any, error) {
-	query := `select key, gauge_value, counter_value from metrics`
-	rows, err := r.storage.Query(ctx, query) //nolint:sqlclosecheck // rows are closed below
+	query := `select key, gauge_value, counter_value, histogram_value from metrics`
+	return r.queryMetrics(ctx, query)
+}
+
+// GetPage returns at most limit series whose key starts with prefix and follows after in key order.
+func (r *DBRepository) GetPage(ctx context.Context, prefix, after string, limit int) (map[string]any, error) {
+	const query = `
+		select key, gauge_value, counter_value, histogram_value from metrics
+		where key > $1 and starts_with(key, $2)
+		order by key
+		limit $3`
+	return r.queryMetrics(ctx, query, after, prefix, limit)
+}
+
+func (r *DBRepository) queryMetrics(ctx context.Context, query string, args ...any) (map[string]any, error) {
+	rows, err := r.storage.Query(ctx, query, args...) //nolint:sqlclosecheck // rows are closed below
 	if err != nil {
 		return nil, fmt.Errorf(dbQueryFailedMsg, err)
 	}
@@ -161,18 +305,16 @@ func (r *DBRepository) GetAll(ctx context.Context) (map[string]any, error) {
 			r.logger.Error("failed to close database rows", zap.Error(err))
 		}
 	}(rows)
-	if rows.Err() != nil {
-		return nil, fmt.Errorf(dbQueryFailedMsg, rows.Err())
-	}
 	type metric struct {
-		gaugeValue   *float64
-		counterValue *int64
-		key          string
+		gaugeValue     *float64
+		counterValue   *int64
+		histogramValue *string
+		key            string
 	}
 	res := make(map[string]any)
 	for rows.Next() {
 		var m metric
-		if err := rows.Scan(&m.key, &m.gaugeValue, &m.counterValue); err != nil {
+		if err := rows.Scan(&m.key, &m.gaugeValue, &m.counterValue, &m.histogramValue); err != nil {
 			return nil, fmt.Errorf(dbQueryFailedMsg, err)
 		}
 		switch {
@@ -180,13 +322,165 @@ func (r *DBRepository) GetAll(ctx context.Context) (map[string]any, error) {
 			res[m.key] = *m.counterValue
 		case m.gaugeValue != nil:
 			res[m.key] = *m.gaugeValue
+		case m.histogramValue != nil:
+			h, err := decodeHistogram(*m.histogramValue)
+			if err != nil {
+				return nil, err
+			}
+			res[m.key] = h
 		default:
 			r.logger.Error("null value read", zap.String("key", m.key))
 		}
 	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(dbQueryFailedMsg, rows.Err())
+	}
 	return res, nil
 }
 
+func (r *DBRepository) GetHistory(ctx context.Context, key string, from, to time.Time) ([]data.Sample, error) {
+	has, err := r.Has(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, data.ErrNotFound
+	}
+	const query = `
+		select ts, gauge_value, counter_value from metrics_history
+		where key=$1 and ts between $2 and $3
+		order by ts`
+	rows, err := r.storage.Query(ctx, query, key, from, to) //nolint:sqlclosecheck // rows are closed below
+	if err != nil {
+		return nil, fmt.Errorf(dbQueryFailedMsg, err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.logger.Error("failed to close database rows", zap.Error(err))
+		}
+	}(rows)
+	res := make([]data.Sample, 0)
+	for rows.Next() {
+		var ts time.Time
+		var gaugeValue *float64
+		var counterValue *int64
+		if err := rows.Scan(&ts, &gaugeValue, &counterValue); err != nil {
+			return nil, fmt.Errorf(dbQueryFailedMsg, err)
+		}
+		switch {
+		case counterValue != nil:
+			res = append(res, data.Sample{Timestamp: ts, Value: *counterValue})
+		case gaugeValue != nil:
+			res = append(res, data.Sample{Timestamp: ts, Value: *gaugeValue})
+		default:
+			r.logger.Error("null history value read", zap.String("key", key))
+		}
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(dbQueryFailedMsg, rows.Err())
+	}
+	return res, nil
+}
+
+func (r *DBRepository) GetUpdatedAt(ctx context.Context, key string) (time.Time, error) {
+	const query = `select updated_at from metrics where key=$1`
+	row, err := r.storage.QueryRow(ctx, query, key)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(dbQueryFailedMsg, err)
+	}
+	var updated time.Time
+	err = row.Scan(&updated)
+	switch {
+	case err == nil:
+		return updated, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return time.Time{}, data.ErrNotFound
+	default:
+		return time.Time{}, fmt.Errorf(dbQueryFailedMsg, err)
+	}
+}
+
+func (r *DBRepository) GetAllUpdatedAt(ctx context.Context) (map[string]time.Time, error) {
+	const query = `select key, updated_at from metrics`
+	rows, err := r.storage.Query(ctx, query) //nolint:sqlclosecheck // rows are closed below
+	if err != nil {
+		return nil, fmt.Errorf(dbQueryFailedMsg, err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.logger.Error("failed to close database rows", zap.Error(err))
+		}
+	}(rows)
+	res := make(map[string]time.Time)
+	for rows.Next() {
+		var key string
+		var updated time.Time
+		if err := rows.Scan(&key, &updated); err != nil {
+			return nil, fmt.Errorf(dbQueryFailedMsg, err)
+		}
+		res[key] = updated
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(dbQueryFailedMsg, rows.Err())
+	}
+	return res, nil
+}
+
+func (r *DBRepository) DeleteUpdatedBefore(ctx context.Context, border time.Time) (int, error) {
+	const query = `delete from metrics where updated_at < $1`
+	res, err := r.storage.Exec(ctx, query, border)
+	if err != nil {
+		return 0, fmt.Errorf("deleting stale metrics failed: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("deleting stale metrics failed: %w", err)
+	}
+	const historyQuery = `
+		delete from metrics_history h
+		where not exists (select 1 from metrics m where m.key = h.key)`
+	if _, err := r.storage.Exec(ctx, historyQuery); err != nil {
+		return 0, fmt.Errorf("deleting stale history failed: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// Delete removes metrics with given keys along with their history and returns keys actually removed.
+func (r *DBRepository) Delete(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return []string{}, nil
+	}
+	const query = `delete from metrics where key = any($1) returning key`
+	rows, err := r.storage.Query(ctx, query, keys) //nolint:sqlclosecheck // rows are closed below
+	if err != nil {
+		return nil, fmt.Errorf("deleting metrics failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.logger.Error("failed to close database rows", zap.Error(err))
+		}
+	}(rows)
+	deleted := make([]string, 0, len(keys))
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf(dbQueryFailedMsg, err)
+		}
+		deleted = append(deleted, key)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(dbQueryFailedMsg, rows.Err())
+	}
+	const historyQuery = `delete from metrics_history where key = any($1)`
+	if _, err := r.storage.Exec(ctx, historyQuery, keys); err != nil {
+		return nil, fmt.Errorf("deleting history failed: %w", err)
+	}
+	return deleted, nil
+}
+
 func formatValuesRows(firstNumber, valuesCount, rowsCount int) string {
 	currentNum := firstNumber
 	rows := make([]string, rowsCount)
@@ -206,3 +500,45 @@ func formatRow(firstNumber, valuesCount int) string {
 	}
 	return fmt.Sprintf("(%s)", strings.Join(values, ","))
 }
+
+// MarkBatch records batch as applied and returns false if it was recorded before.
+// Mark is written within the caller transaction, so concurrent duplicate waits for the first one to finish
+// and gets false once it is committed.
+func (r *DBRepository) MarkBatch(ctx context.Context, key string, at time.Time) (bool, error) {
+	const query = `insert into applied_batches (key, applied_at) values ($1, $2) on conflict (key) do nothing`
+	res, err := r.storage.Exec(ctx, query, key, at)
+	if err != nil {
+		return false, fmt.Errorf("marking batch failed: %w", err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("marking batch failed: %w", err)
+	}
+	return inserted == 1, nil
+}
+
+func (r *DBRepository) HasBatch(ctx context.Context, key string) (bool, error) {
+	const query = `select exists(select 1 from applied_batches where key = $1)`
+	row, err := r.storage.QueryRow(ctx, query, key)
+	if err != nil {
+		return false, fmt.Errorf("checking batch failed: %w", err)
+	}
+	var applied bool
+	if err := row.Scan(&applied); err != nil {
+		return false, fmt.Errorf("checking batch failed: %w", err)
+	}
+	return applied, nil
+}
+
+func (r *DBRepository) DeleteBatchesBefore(ctx context.Context, border time.Time) (int, error) {
+	const query = `delete from applied_batches where applied_at < $1`
+	res, err := r.storage.Exec(ctx, query, border)
+	if err != nil {
+		return 0, fmt.Errorf("deleting applied batches failed: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("deleting applied batches failed: %w", err)
+	}
+	return int(deleted), nil
+}
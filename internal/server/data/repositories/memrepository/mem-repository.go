@@ -3,7 +3,9 @@ package memrepository
 
 import (
 	"context"
+	"go-metrics-service/internal/common/protocol"
 	"go-metrics-service/internal/server/data"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -11,12 +13,24 @@ import (
 type MemStorage interface {
 	Get(key string) (val any, ok bool)
 	GetAll() map[string]any
+	GetPage(prefix, after string, limit int) map[string]any
 	Set(key string, value any)
+	AppendSample(key string, sample data.Sample, notBefore time.Time)
+	GetSamples(key string, from, to time.Time) []data.Sample
+	DeleteSamplesBefore(border time.Time) int
+	GetUpdated(key string) (updated time.Time, ok bool)
+	GetAllUpdated() map[string]time.Time
+	DeleteUpdatedBefore(border time.Time) []string
+	Delete(keys ...string) []string
+	MarkBatch(key string, at time.Time) bool
+	HasBatch(key string) bool
+	DeleteBatchesBefore(border time.Time) int
 }
 
 type MemRepository struct {
 	storage MemStorage
 	logger  *zap.Logger
+	history data.HistoryConfig
 }
 
 func (r *MemRepository) SetCounters(ctx context.Context, values map[string]int64) error {
@@ -39,10 +53,11 @@ func (r *MemRepository) SetGauges(ctx context.Context, values map[string]float64
 	return nil
 }
 
-func New(storage MemStorage, logger *zap.Logger) *MemRepository {
+func New(storage MemStorage, history data.HistoryConfig, logger *zap.Logger) *MemRepository {
 	return &MemRepository{
 		storage: storage,
 		logger:  logger,
+		history: history,
 	}
 }
 
@@ -66,16 +81,43 @@ func getInternal[T any](r *MemRepository, key string, defaultValue T) (T, error)
 	}
 	res, ok := val.(T)
 	if !ok {
-		return defaultValue, data.ErrWrongType
+		return defaultValue, &data.KeyError{Err: data.ErrWrongType, Key: key}
 	}
 	return res, nil
 }
 
+func (r *MemRepository) GetHistogram(_ context.Context, key string) (protocol.HistogramValue, error) {
+	res, err := getInternal[protocol.HistogramValue](r, key, protocol.HistogramValue{})
+	if err != nil {
+		return res, err
+	}
+	return res.Clone(), nil
+}
+
+func (r *MemRepository) SetHistogram(_ context.Context, key string, value protocol.HistogramValue) error {
+	if err := checkType[protocol.HistogramValue](r, key); err != nil {
+		return err
+	}
+	// Histograms are not kept in history, samples hold scalar values only.
+	r.storage.Set(key, value.Clone())
+	return nil
+}
+
+func (r *MemRepository) SetHistograms(ctx context.Context, values map[string]protocol.HistogramValue) error {
+	for k, v := range values {
+		err := r.SetHistogram(ctx, k, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *MemRepository) SetCounter(_ context.Context, key string, value int64) error {
 	if err := checkType[int64](r, key); err != nil {
 		return err
 	}
-	r.storage.Set(key, value)
+	r.set(key, value)
 	return nil
 }
 
@@ -83,17 +125,33 @@ func (r *MemRepository) SetGauge(_ context.Context, key string, value float64) e
 	if err := checkType[float64](r, key); err != nil {
 		return err
 	}
-	r.storage.Set(key, value)
+	r.set(key, value)
 	return nil
 }
 
+func (r *MemRepository) set(key string, value any) {
+	r.storage.Set(key, value)
+	if !r.history.Enabled {
+		return
+	}
+	now := time.Now()
+	r.storage.AppendSample(
+		key,
+		data.Sample{
+			Timestamp: now,
+			Value:     value,
+		},
+		r.history.RetentionBorder(now),
+	)
+}
+
 func checkType[T any](r *MemRepository, key string) error {
 	if val, ok := r.storage.Get(key); ok {
 		switch val.(type) {
 		case T:
 			return nil
 		default:
-			return data.ErrWrongType
+			return &data.KeyError{Err: data.ErrWrongType, Key: key}
 		}
 	}
 	return nil
@@ -102,3 +160,67 @@ func checkType[T any](r *MemRepository, key string) error {
 func (r *MemRepository) GetAll(_ context.Context) (map[string]any, error) {
 	return r.storage.GetAll(), nil
 }
+
+func (r *MemRepository) GetPage(_ context.Context, prefix, after string, limit int) (map[string]any, error) {
+	return r.storage.GetPage(prefix, after, limit), nil
+}
+
+func (r *MemRepository) GetHistory(_ context.Context, key string, from, to time.Time) ([]data.Sample, error) {
+	if _, ok := r.storage.Get(key); !ok {
+		return nil, data.ErrNotFound
+	}
+	return r.storage.GetSamples(key, from, to), nil
+}
+
+func (r *MemRepository) GetUpdatedAt(_ context.Context, key string) (time.Time, error) {
+	updated, ok := r.storage.GetUpdated(key)
+	if !ok {
+		return time.Time{}, data.ErrNotFound
+	}
+	return updated, nil
+}
+
+func (r *MemRepository) GetAllUpdatedAt(_ context.Context) (map[string]time.Time, error) {
+	return r.storage.GetAllUpdated(), nil
+}
+
+func (r *MemRepository) DeleteUpdatedBefore(_ context.Context, border time.Time) (int, error) {
+	return len(r.storage.DeleteUpdatedBefore(border)), nil
+}
+
+func (r *MemRepository) GetType(_ context.Context, key string) (string, error) {
+	val, ok := r.storage.Get(key)
+	if !ok {
+		return "", data.ErrNotFound
+	}
+	switch val.(type) {
+	case int64:
+		return protocol.Counter, nil
+	case float64:
+		return protocol.Gauge, nil
+	case protocol.HistogramValue:
+		return protocol.Histogram, nil
+	default:
+		return "", &data.KeyError{Err: data.ErrWrongType, Key: key}
+	}
+}
+
+func (r *MemRepository) Delete(_ context.Context, keys []string) ([]string, error) {
+	return r.storage.Delete(keys...), nil
+}
+
+func (r *MemRepository) MarkBatch(_ context.Context, key string, at time.Time) (bool, error) {
+	return r.storage.MarkBatch(key, at), nil
+}
+
+func (r *MemRepository) HasBatch(_ context.Context, key string) (bool, error) {
+	return r.storage.HasBatch(key), nil
+}
+
+func (r *MemRepository) DeleteHistoryBefore(_ context.Context, border time.Time) (int, error) {
+	return r.storage.DeleteSamplesBefore(border), nil
+}
+
+func (r *MemRepository) DeleteBatchesBefore(_ context.Context, border time.Time) (int, error) {
+	return r.storage.DeleteBatchesBefore(border), nil
+}
@@ -21,11 +21,34 @@ const (
 	setupDatabaseRequest = `
 		create table if not exists metrics
 		(
-			key           varchar(63) not null primary key,
+			key           text not null primary key,
+			gauge_value   double precision null,
+			counter_value bigint null
+		);
+		create table if not exists metrics_history
+		(
+			key           text not null,
+			ts            timestamptz not null,
 			gauge_value   double precision null,
 			counter_value bigint null
 			check ((counter_value is null) != (gauge_value is null))
-		);`
+		);
+		alter table metrics alter column key type text;
+		alter table metrics add column if not exists updated_at timestamptz not null default now();
+		alter table metrics add column if not exists histogram_value jsonb null;
+		alter table metrics drop constraint if exists metrics_check;
+		alter table metrics drop constraint if exists metrics_value_check;
+		alter table metrics add constraint metrics_value_check
+			check (num_nonnulls(gauge_value, counter_value, histogram_value) = 1);
+		alter table metrics_history alter column key type text;
+		create index if not exists metrics_history_key_ts_idx on metrics_history (key, ts);
+		create index if not exists metrics_history_ts_idx on metrics_history (ts);
+		create table if not exists applied_batches
+		(
+			key        text not null primary key,
+			applied_at timestamptz not null default now()
+		);
+		create index if not exists applied_batches_applied_at_idx on applied_batches (applied_at);`
 )
 
 var errNoTransaction = errors.New("no transaction")
@@ -5,13 +5,23 @@ import (
 	"compress/gzip"
 	"encoding/gob"
 	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/data"
 	"go-metrics-service/pkg/compression"
 	"io"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+func init() {
+	// Histograms are stored as interface values and need registration for gob encoding.
+	gob.Register(protocol.HistogramValue{})
+}
+
 type MemStorage struct {
 	data   rawData
 	mux    *sync.Mutex
@@ -19,13 +29,19 @@ type MemStorage struct {
 }
 
 type rawData struct {
-	Values map[string]any
+	Values  map[string]any
+	History map[string][]data.Sample
+	Updated map[string]time.Time
+	Batches map[string]time.Time
 }
 
 func New(logger *zap.Logger) *MemStorage {
 	return &MemStorage{
 		data: rawData{
-			Values: make(map[string]any),
+			Values:  make(map[string]any),
+			History: make(map[string][]data.Sample),
+			Updated: make(map[string]time.Time),
+			Batches: make(map[string]time.Time),
 		},
 		mux:    &sync.Mutex{},
 		logger: logger,
@@ -45,9 +61,28 @@ func LoadFrom(reader io.Reader, logger *zap.Logger) (*MemStorage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decompress data: %w", err)
 	}
+	if readData.History == nil {
+		readData.History = make(map[string][]data.Sample)
+	}
+	if readData.Updated == nil {
+		readData.Updated = make(map[string]time.Time)
+	}
+	if readData.Batches == nil {
+		readData.Batches = make(map[string]time.Time)
+	}
+	// Values saved without update time are treated as updated on load.
+	loadedAt := time.Now()
+	for key := range readData.Values {
+		if _, ok := readData.Updated[key]; !ok {
+			readData.Updated[key] = loadedAt
+		}
+	}
 	return &MemStorage{
 		data: rawData{
-			Values: readData.Values,
+			Values:  readData.Values,
+			History: readData.History,
+			Updated: readData.Updated,
+			Batches: readData.Batches,
 		},
 		mux:    &sync.Mutex{},
 		logger: logger,
@@ -59,7 +94,10 @@ func (s *MemStorage) SaveTo(writer io.Writer) error {
 	defer s.mux.Unlock()
 	err := compression.GzipCompress(
 		rawData{
-			Values: s.data.Values,
+			Values:  s.data.Values,
+			History: s.data.History,
+			Updated: s.data.Updated,
+			Batches: s.data.Batches,
 		},
 		func(writer io.Writer) compression.Encoder {
 			return gob.NewEncoder(writer)
@@ -89,8 +127,166 @@ func (s *MemStorage) GetAll() map[string]any {
 	}
 	return dataCopy
 }
+
+// GetPage returns copy of at most limit values whose key starts with prefix and follows after in key order.
+func (s *MemStorage) GetPage(prefix, after string, limit int) map[string]any {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	keys := make([]string, 0)
+	for k := range s.data.Values {
+		if k > after && strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	page := make(map[string]any, len(keys))
+	for _, k := range keys {
+		page[k] = s.data.Values[k]
+	}
+	return page
+}
+
 func (s *MemStorage) Set(key string, value any) {
 	s.mux.Lock()
 	defer s.mux.Unlock()
 	s.data.Values[key] = value
+	s.data.Updated[key] = time.Now()
+}
+
+// GetUpdated returns time of the last key update.
+func (s *MemStorage) GetUpdated(key string) (updated time.Time, ok bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	updated, ok = s.data.Updated[key]
+	return
+}
+
+// GetAllUpdated returns copy of every key last update time.
+func (s *MemStorage) GetAllUpdated() map[string]time.Time {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	res := make(map[string]time.Time, len(s.data.Updated))
+	for k, v := range s.data.Updated {
+		res[k] = v
+	}
+	return res
+}
+
+// DeleteUpdatedBefore removes keys not updated since border along with their history.
+func (s *MemStorage) DeleteUpdatedBefore(border time.Time) []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	deleted := make([]string, 0)
+	for key, updated := range s.data.Updated {
+		if !updated.Before(border) {
+			continue
+		}
+		delete(s.data.Values, key)
+		delete(s.data.History, key)
+		delete(s.data.Updated, key)
+		deleted = append(deleted, key)
+	}
+	return deleted
+}
+
+// Delete removes keys along with their history and returns keys actually removed.
+func (s *MemStorage) Delete(keys ...string) []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	deleted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := s.data.Values[key]; !ok {
+			continue
+		}
+		delete(s.data.Values, key)
+		delete(s.data.History, key)
+		delete(s.data.Updated, key)
+		deleted = append(deleted, key)
+	}
+	return deleted
+}
+
+// AppendSample adds sample to key history and drops samples older than notBefore.
+func (s *MemStorage) AppendSample(key string, sample data.Sample, notBefore time.Time) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	samples := append(s.data.History[key], sample)
+	firstKept := 0
+	for firstKept < len(samples) && samples[firstKept].Timestamp.Before(notBefore) {
+		firstKept++
+	}
+	// Pruned samples are removed from backing array, reslicing would keep them alive.
+	s.data.History[key] = slices.Delete(samples, 0, firstKept)
+}
+
+// DeleteSamplesBefore drops samples older than border from history of every key,
+// including keys not written anymore, and returns number of dropped samples.
+func (s *MemStorage) DeleteSamplesBefore(border time.Time) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	deleted := 0
+	for key, samples := range s.data.History {
+		firstKept := 0
+		for firstKept < len(samples) && samples[firstKept].Timestamp.Before(border) {
+			firstKept++
+		}
+		deleted += firstKept
+		if firstKept == len(samples) {
+			delete(s.data.History, key)
+			continue
+		}
+		s.data.History[key] = slices.Delete(samples, 0, firstKept)
+	}
+	return deleted
+}
+
+// GetSamples returns copy of key history within [from, to] interval.
+func (s *MemStorage) GetSamples(key string, from, to time.Time) []data.Sample {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	res := make([]data.Sample, 0)
+	for _, sample := range s.data.History[key] {
+		if sample.Timestamp.Before(from) || sample.Timestamp.After(to) {
+			continue
+		}
+		res = append(res, sample)
+	}
+	return res
+}
+
+// MarkBatch records batch as applied at provided moment and returns false if it was recorded before.
+func (s *MemStorage) MarkBatch(key string, at time.Time) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if _, ok := s.data.Batches[key]; ok {
+		return false
+	}
+	s.data.Batches[key] = at
+	return true
+}
+
+// HasBatch reports whether batch was recorded as applied.
+func (s *MemStorage) HasBatch(key string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	_, ok := s.data.Batches[key]
+	return ok
+}
+
+// DeleteBatchesBefore forgets batches applied before border and returns their count.
+func (s *MemStorage) DeleteBatchesBefore(border time.Time) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	deleted := 0
+	for key, applied := range s.data.Batches {
+		if !applied.Before(border) {
+			continue
+		}
+		delete(s.data.Batches, key)
+		deleted++
+	}
+	return deleted
 }
@@ -2,8 +2,10 @@ package memstorage
 
 import (
 	"fmt"
+	"go-metrics-service/internal/server/data"
 	"math"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -92,3 +94,98 @@ func TestGetNonExistingValue(t *testing.T) {
 	_, ok := memStorage.Get("non_existing_key")
 	assert.False(t, ok)
 }
+
+func TestSamplesRetentionAndRange(t *testing.T) {
+	memStorage := New(zap.NewNop())
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		memStorage.AppendSample(
+			"test_key",
+			data.Sample{
+				Timestamp: start.Add(time.Duration(i) * time.Minute),
+				Value:     float64(i),
+			},
+			start.Add(time.Minute),
+		)
+	}
+
+	all := memStorage.GetSamples("test_key", start, start.Add(time.Hour))
+	assert.Len(t, all, 4)
+	assert.Equal(t, float64(1), all[0].Value)
+
+	ranged := memStorage.GetSamples("test_key", start.Add(2*time.Minute), start.Add(3*time.Minute))
+	assert.Len(t, ranged, 2)
+	assert.Equal(t, float64(2), ranged[0].Value)
+	assert.Equal(t, float64(3), ranged[1].Value)
+
+	assert.Empty(t, memStorage.GetSamples("non_existing_key", start, start.Add(time.Hour)))
+	assertPrunedReleased(t, memStorage.data.History["test_key"])
+}
+
+// assertPrunedReleased checks that backing array of history does not keep pruned samples.
+func assertPrunedReleased(t *testing.T, samples []data.Sample) {
+	t.Helper()
+	for _, sample := range samples[len(samples):cap(samples)] {
+		assert.Zero(t, sample)
+	}
+}
+
+func TestGetPage(t *testing.T) {
+	memStorage := New(zap.NewNop())
+	for _, key := range []string{"cpu_idle", `cpu{host="a"}`, `cpu{host="b"}`, "mem_free"} {
+		memStorage.Set(key, 1.5)
+	}
+
+	assert.Equal(t, map[string]any{"cpu_idle": 1.5, `cpu{host="a"}`: 1.5}, memStorage.GetPage("cpu", "", 2))
+	assert.Equal(t, map[string]any{`cpu{host="b"}`: 1.5}, memStorage.GetPage("cpu", `cpu{host="a"}`, 2))
+	assert.Empty(t, memStorage.GetPage("disk", "", 2))
+}
+
+func TestDeleteSamplesBefore(t *testing.T) {
+	memStorage := New(zap.NewNop())
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		sample := data.Sample{Timestamp: start.Add(time.Duration(i) * time.Minute), Value: int64(i)}
+		memStorage.AppendSample("written_key", sample, time.Time{})
+	}
+	memStorage.AppendSample("abandoned_key", data.Sample{Timestamp: start, Value: 1.5}, time.Time{})
+
+	assert.Equal(t, 2, memStorage.DeleteSamplesBefore(start.Add(time.Minute)))
+	assert.Len(t, memStorage.GetSamples("written_key", start, start.Add(time.Hour)), 2)
+	assert.NotContains(t, memStorage.data.History, "abandoned_key")
+	assertPrunedReleased(t, memStorage.data.History["written_key"])
+}
+
+func TestDeleteUpdatedBefore(t *testing.T) {
+	memStorage := New(zap.NewNop())
+	memStorage.Set("old_key", int64(1))
+	memStorage.AppendSample("old_key", data.Sample{Timestamp: time.Now(), Value: int64(1)}, time.Time{})
+	border := time.Now().Add(time.Millisecond)
+	memStorage.data.Updated["new_key"] = border.Add(time.Second)
+	memStorage.data.Values["new_key"] = 2.5
+
+	updated, ok := memStorage.GetUpdated("old_key")
+	assert.True(t, ok)
+	assert.True(t, updated.Before(border))
+
+	assert.Equal(t, []string{"old_key"}, memStorage.DeleteUpdatedBefore(border))
+	_, ok = memStorage.Get("old_key")
+	assert.False(t, ok)
+	assert.Empty(t, memStorage.GetSamples("old_key", time.Time{}, time.Now()))
+	assert.Equal(t, map[string]any{"new_key": 2.5}, memStorage.GetAll())
+	assert.Len(t, memStorage.GetAllUpdated(), 1)
+}
+
+func TestBatches(t *testing.T) {
+	memStorage := New(zap.NewNop())
+	appliedAt := time.Now()
+	assert.False(t, memStorage.HasBatch("agent/batch"))
+	assert.True(t, memStorage.MarkBatch("agent/batch", appliedAt))
+	assert.False(t, memStorage.MarkBatch("agent/batch", appliedAt))
+	assert.True(t, memStorage.HasBatch("agent/batch"))
+	assert.True(t, memStorage.MarkBatch("agent/new", appliedAt.Add(time.Hour)))
+
+	assert.Equal(t, 1, memStorage.DeleteBatchesBefore(appliedAt.Add(time.Minute)))
+	assert.True(t, memStorage.MarkBatch("agent/batch", appliedAt))
+	assert.False(t, memStorage.MarkBatch("agent/new", appliedAt))
+}
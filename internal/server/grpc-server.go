@@ -3,10 +3,16 @@ package server
 import (
 	"fmt"
 	"go-metrics-service/internal/server/grpcservers"
+	"go-metrics-service/internal/server/interceptors"
+	"go-metrics-service/internal/server/middleware"
+	"go-metrics-service/pkg/tlshelpers"
 	pb "go-metrics-service/proto"
 	"net"
+	"time"
 
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var _ pb.UpdateMetricsServer = (*GRPCServer)(nil)
@@ -15,27 +21,111 @@ type GRPCServer struct {
 	pb.UnimplementedUpdateMetricsServer
 	cfg        GRPCConfig
 	controller GRPCController
+	alerts     GRPCAlertsProvider
+	repository GRPCRepository
+	watcher    GRPCWatcher
 	server     *grpc.Server
 }
 
 type GRPCController interface {
 	grpcservers.Controller
+	grpcservers.MetricDeleter
+}
+
+type GRPCAlertsProvider interface {
+	grpcservers.AlertsProvider
+}
+
+type GRPCRepository interface {
+	grpcservers.MetricsRepository
+}
+
+type GRPCWatcher interface {
+	grpcservers.Watcher
 }
 
 type GRPCConfig struct {
-	Port uint16
+	// Address is host:port to listen on, empty host listens on all interfaces.
+	Address string
+	// CertPem and KeyPem enable TLS, plaintext is served when they are empty.
+	CertPem []byte
+	KeyPem  []byte
+	// ClientCAPem enables verification of client certificates.
+	ClientCAPem []byte
+	// TrustedSubnet restricts callers by peer address when set.
+	TrustedSubnet string
+	// HashReplayWindow is maximum age of signed call timestamp.
+	HashReplayWindow time.Duration
+	// NonceCacheSize bounds number of remembered nonces of signed calls.
+	NonceCacheSize int
+}
+
+// signedMethods change metrics, they accept only signed calls when hash keys are configured,
+// the same as HTTP routes changing metrics. Reads are served unsigned.
+var signedMethods = []string{
+	pb.UpdateMetrics_UpdateMetrics_FullMethodName,
+	pb.UpdateMetrics_StreamUpdates_FullMethodName,
+	pb.Metrics_DeleteMetrics_FullMethodName,
 }
 
-func NewGRPC(cfg GRPCConfig, controller GRPCController) *GRPCServer {
+func NewGRPC(
+	cfg GRPCConfig,
+	controller GRPCController,
+	alerts GRPCAlertsProvider,
+	repository GRPCRepository,
+	watcher GRPCWatcher,
+	hashKeys interceptors.HashKeys,
+	logger *zap.Logger,
+) (*GRPCServer, error) {
+	options := make([]grpc.ServerOption, 0)
+	if cfg.CertPem != nil {
+		tlsConfig, err := tlshelpers.NewServerConfig(cfg.CertPem, cfg.KeyPem, cfg.ClientCAPem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure grpc tls: %w", err)
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	loggerInterceptor := interceptors.NewLogger(logger)
+	unary := []grpc.UnaryServerInterceptor{loggerInterceptor.Unary()}
+	stream := []grpc.StreamServerInterceptor{loggerInterceptor.Stream()}
+	if cfg.TrustedSubnet != "" {
+		subnetFilter, err := interceptors.NewSubnetFilter(logger, cfg.TrustedSubnet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create subnet filter: %w", err)
+		}
+		unary = append(unary, subnetFilter.Unary())
+		stream = append(stream, subnetFilter.Stream())
+	}
+	if hashKeys != nil {
+		hashInterceptor := interceptors.NewHash(
+			logger,
+			hashKeys,
+			cfg.HashReplayWindow,
+			middleware.NewNonceCache(cfg.NonceCacheSize),
+			signedMethods,
+		)
+		unary = append(unary, hashInterceptor.Unary())
+		stream = append(stream, hashInterceptor.Stream())
+	}
+	options = append(
+		options,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+
 	return &GRPCServer{
 		controller: controller,
-		server:     grpc.NewServer(),
+		alerts:     alerts,
+		repository: repository,
+		watcher:    watcher,
+		server:     grpc.NewServer(options...),
 		cfg:        cfg,
-	}
+	}, nil
 }
 
 func (s *GRPCServer) Run() error {
-	listen, err := net.Listen("tcp", fmt.Sprintf(":%v", s.cfg.Port))
+	listen, err := net.Listen("tcp", s.cfg.Address)
 	if err != nil {
 		return fmt.Errorf("failed to start listen: %w", err)
 	}
@@ -43,6 +133,8 @@ func (s *GRPCServer) Run() error {
 	ums := grpcservers.NewUpdateMetricsServer(s.controller)
 
 	pb.RegisterUpdateMetricsServer(s.server, ums)
+	pb.RegisterAlertsServer(s.server, grpcservers.NewAlertsServer(s.alerts))
+	pb.RegisterMetricsServer(s.server, grpcservers.NewMetricsServer(s.controller, s.repository, s.watcher))
 
 	if err := s.server.Serve(listen); err != nil {
 		return fmt.Errorf("failed to serve: %w", err)
@@ -0,0 +1,26 @@
+package grpcservers
+
+import (
+	"context"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/interceptors"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// withAgentIdentity puts identity of the agent calling method into context.
+func withAgentIdentity(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	ids := md.Get(protocol.AgentIDMetadata)
+	if len(ids) == 0 || ids[0] == "" {
+		return ctx
+	}
+	return agents.WithIdentity(ctx, agents.Identity{
+		ID: ids[0],
+		IP: interceptors.PeerIP(ctx),
+	})
+}
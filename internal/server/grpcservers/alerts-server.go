@@ -0,0 +1,56 @@
+package grpcservers
+
+import (
+	"context"
+	"go-metrics-service/internal/server/alerting"
+	pb "go-metrics-service/proto"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ pb.AlertsServer = (*AlertsServer)(nil)
+
+type AlertsServer struct {
+	pb.UnimplementedAlertsServer
+	provider AlertsProvider
+}
+
+type AlertsProvider interface {
+	FiringAlerts() []alerting.Alert
+}
+
+func NewAlertsServer(provider AlertsProvider) *AlertsServer {
+	return &AlertsServer{
+		provider: provider,
+	}
+}
+
+func (s AlertsServer) ListFiringAlerts(
+	_ context.Context,
+	_ *pb.ListFiringAlertsRequest,
+) (*pb.ListFiringAlertsResponse, error) {
+	alerts := s.provider.FiringAlerts()
+	res := make([]*pb.Alert, len(alerts))
+	for i, alert := range alerts {
+		res[i] = ConvertAlert(&alert)
+	}
+	return pb.ListFiringAlertsResponse_builder{
+		Alerts: res,
+	}.Build(), nil
+}
+
+func ConvertAlert(alert *alerting.Alert) *pb.Alert {
+	builder := pb.Alert_builder{
+		Name:  &alert.Name,
+		Expr:  &alert.Expr,
+		State: &alert.State,
+		Value: &alert.Value,
+	}
+	if alert.ActiveAt != nil {
+		builder.ActiveAt = timestamppb.New(*alert.ActiveAt)
+	}
+	if alert.FiredAt != nil {
+		builder.FiredAt = timestamppb.New(*alert.FiredAt)
+	}
+	return builder.Build()
+}
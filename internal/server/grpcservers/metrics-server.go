@@ -0,0 +1,292 @@
+package grpcservers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/audit"
+	"go-metrics-service/internal/server/interceptors"
+	"go-metrics-service/internal/server/logic"
+	"go-metrics-service/internal/server/watch"
+	pb "go-metrics-service/proto"
+	"regexp"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+var _ pb.MetricsServer = (*MetricsServer)(nil)
+
+type MetricsServer struct {
+	pb.UnimplementedMetricsServer
+	deleter    MetricDeleter
+	repository MetricsRepository
+	watcher    Watcher
+}
+
+type MetricsRepository interface {
+	GetGauge(ctx context.Context, key string) (float64, error)
+	GetCounter(ctx context.Context, key string) (int64, error)
+	GetHistogram(ctx context.Context, key string) (protocol.HistogramValue, error)
+	GetPage(ctx context.Context, prefix, after string, limit int) (map[string]any, error)
+}
+
+type Watcher interface {
+	Subscribe(prefix string) (subscription *watch.Subscription, cancel func())
+}
+
+type MetricDeleter interface {
+	Delete(ctx context.Context, metric protocol.Metrics) error
+	DeleteMatching(ctx context.Context, filter *logic.DeleteFilter) ([]string, error)
+}
+
+func NewMetricsServer(deleter MetricDeleter, repository MetricsRepository, watcher Watcher) *MetricsServer {
+	return &MetricsServer{
+		deleter:    deleter,
+		repository: repository,
+		watcher:    watcher,
+	}
+}
+
+func (s MetricsServer) GetMetric(ctx context.Context, request *pb.GetMetricRequest) (*pb.GetMetricResponse, error) {
+	var response pb.GetMetricResponse
+
+	metric := protocol.Metrics{
+		ID:     request.GetId(),
+		MType:  request.GetType(),
+		Labels: request.GetLabels(),
+	}
+	key := metric.SeriesKey()
+	switch metric.MType {
+	case protocol.Gauge:
+		value, err := s.repository.GetGauge(ctx, key)
+		if err != nil {
+			response.SetError(err.Error())
+			return &response, nil
+		}
+		metric.Value = &value
+	case protocol.Counter:
+		delta, err := s.repository.GetCounter(ctx, key)
+		if err != nil {
+			response.SetError(err.Error())
+			return &response, nil
+		}
+		metric.Delta = &delta
+	case protocol.Histogram:
+		histogram, err := s.repository.GetHistogram(ctx, key)
+		if err != nil {
+			response.SetError(err.Error())
+			return &response, nil
+		}
+		metric.Histogram = &histogram
+	default:
+		return nil, errors.New("unknown metric type " + metric.MType)
+	}
+
+	converted, err := ConvertToProto(&metric)
+	if err != nil {
+		return nil, err
+	}
+	response.SetMetric(converted)
+	return &response, nil
+}
+
+func (s MetricsServer) ListMetrics(
+	ctx context.Context,
+	request *pb.ListMetricsRequest,
+) (*pb.ListMetricsResponse, error) {
+	pageSize := int(request.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, fmt.Errorf("invalid page size %d", pageSize)
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	if err := validatePrefix(request.GetPrefix()); err != nil {
+		return nil, err
+	}
+	// One series more than page size tells whether next page exists.
+	values, err := s.repository.GetPage(ctx, request.GetPrefix(), request.GetPageToken(), pageSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var response pb.ListMetricsResponse
+	if len(keys) > pageSize {
+		keys = keys[:pageSize]
+		response.SetNextPageToken(keys[pageSize-1])
+	}
+	metrics := make([]*pb.Metric, 0, len(keys))
+	for _, key := range keys {
+		metric, err := metricFromValue(key, values[key])
+		if err != nil {
+			return nil, err
+		}
+		converted, err := ConvertToProto(&metric)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, converted)
+	}
+	response.SetMetrics(metrics)
+	return &response, nil
+}
+
+func (s MetricsServer) WatchMetrics(request *pb.WatchMetricsRequest, stream grpc.ServerStreamingServer[pb.Metric]) error {
+	if err := validatePrefix(request.GetPrefix()); err != nil {
+		return err
+	}
+	subscription, cancel := s.watcher.Subscribe(request.GetPrefix())
+	defer cancel()
+	// Headers tell client that updates published from now on will be delivered.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return fmt.Errorf("failed to send header: %w", err)
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case metric, ok := <-subscription.Updates():
+			if !ok {
+				if err := subscription.Err(); !errors.Is(err, watch.ErrClosed) {
+					return err //nolint:wrapcheck // unnecessary
+				}
+				return nil
+			}
+			converted, err := ConvertToProto(&metric)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(converted); err != nil {
+				return fmt.Errorf("failed to send update: %w", err)
+			}
+		}
+	}
+}
+
+func (s MetricsServer) DeleteMetrics(
+	ctx context.Context,
+	request *pb.DeleteMetricsRequest,
+) (*pb.DeleteMetricsResponse, error) {
+	var response pb.DeleteMetricsResponse
+
+	switch request.GetType() {
+	case "", protocol.Gauge, protocol.Counter, protocol.Histogram:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "%v: %s", ErrUnknownType, request.GetType())
+	}
+
+	ctx = audit.WithRequesterIP(ctx, interceptors.PeerIP(ctx))
+
+	if request.GetId() != "" {
+		metric := protocol.Metrics{
+			ID:     request.GetId(),
+			MType:  request.GetType(),
+			Labels: request.GetLabels(),
+		}
+		if err := s.deleter.Delete(ctx, metric); err != nil {
+			return nil, deleteStatus(err).Err()
+		}
+		response.SetDeleted([]string{metric.SeriesKey()})
+		return &response, nil
+	}
+
+	filter := &logic.DeleteFilter{
+		Type:   request.GetType(),
+		Prefix: request.GetPrefix(),
+	}
+	if request.GetRegex() != "" {
+		pattern, err := regexp.Compile(request.GetRegex())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid regex: %v", err)
+		}
+		filter.Pattern = pattern
+	}
+	deleted, err := s.deleter.DeleteMatching(ctx, filter)
+	if err != nil {
+		return nil, deleteStatus(err).Err()
+	}
+	response.SetDeleted(deleted)
+	return &response, nil
+}
+
+// validatePrefix rejects prefix holding series key delimiters. Listing and watching match prefix
+// against metric ID only, which is the same as matching series key as IDs cannot hold delimiters.
+func validatePrefix(prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+	if err := protocol.ValidateID(prefix); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid prefix: %v", err)
+	}
+	return nil
+}
+
+// metricFromValue restores metric from series key and stored value.
+func metricFromValue(key string, value any) (protocol.Metrics, error) {
+	id, labels, err := protocol.ParseSeriesKey(key)
+	if err != nil {
+		return protocol.Metrics{}, fmt.Errorf("failed to parse series key: %w", err)
+	}
+	metric := protocol.Metrics{
+		ID:     id,
+		MType:  logic.MetricType(value),
+		Labels: labels,
+	}
+	switch v := value.(type) {
+	case int64:
+		metric.Delta = &v
+	case float64:
+		metric.Value = &v
+	case protocol.HistogramValue:
+		metric.Histogram = &v
+	default:
+		return protocol.Metrics{}, fmt.Errorf("unexpected value type %T of '%s'", value, key)
+	}
+	return metric, nil
+}
+
+// ConvertToProto converts metric to its protobuf representation.
+func ConvertToProto(m *protocol.Metrics) (*pb.Metric, error) {
+	builder := pb.Metric_builder{
+		Id:     &m.ID,
+		Labels: m.Labels,
+	}
+	switch {
+	case m.MType == protocol.Gauge && m.Value != nil:
+		builder.Type = pb.Metric_GAUGE.Enum()
+		builder.Value = m.Value
+	case m.MType == protocol.Counter && m.Delta != nil:
+		builder.Type = pb.Metric_COUNTER.Enum()
+		builder.Delta = m.Delta
+	case m.MType == protocol.Histogram && m.Histogram != nil:
+		builder.Type = pb.Metric_HISTOGRAM.Enum()
+		builder.Histogram = pb.Histogram_builder{
+			Bounds: m.Histogram.Bounds,
+			Counts: m.Histogram.Counts,
+			Sum:    &m.Histogram.Sum,
+			Count:  &m.Histogram.Count,
+		}.Build()
+	default:
+		return nil, errors.New("unknown metric type " + m.MType)
+	}
+	return builder.Build(), nil
+}
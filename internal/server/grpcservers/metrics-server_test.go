@@ -0,0 +1,214 @@
+package grpcservers
+
+import (
+	"context"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/audit"
+	"go-metrics-service/internal/server/controllers"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/internal/server/data/repositories/memrepository"
+	"go-metrics-service/internal/server/data/storages"
+	"go-metrics-service/internal/server/data/storages/memstorage"
+	"go-metrics-service/internal/server/logic"
+	"go-metrics-service/internal/server/watch"
+	pb "go-metrics-service/proto"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func setupMetricsClient(t *testing.T) (pb.MetricsClient, *controllers.Controller) {
+	t.Helper()
+	logger := zap.NewNop()
+	repository := memrepository.New(memstorage.New(logger), data.HistoryConfig{}, logger)
+	hub := watch.New(16)
+	controller := controllers.NewController(
+		controllers.Config{},
+		storages.NewDummyTransactionsManager(),
+		logic.NewService(repository, logger),
+		agents.New(0, 0),
+		audit.New(nil, logger),
+		hub,
+		logger,
+	)
+
+	conn := dialServer(t, func(server *grpc.Server) {
+		pb.RegisterMetricsServer(server, NewMetricsServer(controller, repository, hub))
+	})
+	t.Cleanup(hub.Close)
+	return pb.NewMetricsClient(conn), controller
+}
+
+func TestMetricsServerGetAndList(t *testing.T) {
+	client, controller := setupMetricsClient(t)
+	ctx := context.Background()
+
+	delta := int64(3)
+	value := 1.5
+	require.NoError(t, controller.UpdateMany(ctx, []protocol.Metrics{
+		{ID: "PollCount", MType: protocol.Counter, Delta: &delta},
+		{ID: "cpu", MType: protocol.Gauge, Value: &value, Labels: map[string]string{"host": "a"}},
+		{ID: "cpu", MType: protocol.Gauge, Value: &value, Labels: map[string]string{"host": "b"}},
+		{ID: "cpu_idle", MType: protocol.Gauge, Value: &value},
+	}))
+
+	got, err := client.GetMetric(ctx, pb.GetMetricRequest_builder{
+		Type:   ptr(protocol.Gauge),
+		Id:     ptr("cpu"),
+		Labels: map[string]string{"host": "b"},
+	}.Build())
+	require.NoError(t, err)
+	assert.Empty(t, got.GetError())
+	assert.InDelta(t, value, got.GetMetric().GetValue(), 0)
+	assert.Equal(t, map[string]string{"host": "b"}, got.GetMetric().GetLabels())
+
+	missing, err := client.GetMetric(ctx, pb.GetMetricRequest_builder{
+		Type: ptr(protocol.Counter),
+		Id:   ptr("missing"),
+	}.Build())
+	require.NoError(t, err)
+	assert.NotEmpty(t, missing.GetError())
+
+	ids := make([]string, 0)
+	token := ""
+	pages := 0
+	for {
+		page, err := client.ListMetrics(ctx, pb.ListMetricsRequest_builder{
+			Prefix:    ptr("cpu"),
+			PageSize:  ptr(int32(2)),
+			PageToken: &token,
+		}.Build())
+		require.NoError(t, err)
+		pages++
+		for _, metric := range page.GetMetrics() {
+			ids = append(ids, protocol.SeriesKey(metric.GetId(), metric.GetLabels()))
+		}
+		token = page.GetNextPageToken()
+		if token == "" {
+			break
+		}
+	}
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{"cpu_idle", `cpu{host="a"}`, `cpu{host="b"}`}, ids)
+
+	_, err = client.ListMetrics(ctx, pb.ListMetricsRequest_builder{Prefix: ptr(`cpu{host="a"}`)}.Build())
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	watch, err := client.WatchMetrics(ctx, pb.WatchMetricsRequest_builder{Prefix: ptr("cpu{")}.Build())
+	require.NoError(t, err)
+	_, err = watch.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMetricsServerDelete(t *testing.T) {
+	client, controller := setupMetricsClient(t)
+	ctx := context.Background()
+
+	delta := int64(3)
+	require.NoError(t, controller.Update(ctx, protocol.Metrics{ID: "PollCount", MType: protocol.Counter, Delta: &delta}))
+
+	tests := []struct {
+		request *pb.DeleteMetricsRequest
+		name    string
+		code    codes.Code
+	}{
+		{
+			name:    "unknown type",
+			request: pb.DeleteMetricsRequest_builder{Type: ptr("summary"), Id: ptr("PollCount")}.Build(),
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "wrong type",
+			request: pb.DeleteMetricsRequest_builder{Type: ptr(protocol.Gauge), Id: ptr("PollCount")}.Build(),
+			code:    codes.FailedPrecondition,
+		},
+		{
+			name:    "missing",
+			request: pb.DeleteMetricsRequest_builder{Type: ptr(protocol.Counter), Id: ptr("missing")}.Build(),
+			code:    codes.NotFound,
+		},
+		{
+			name:    "empty filter",
+			request: pb.DeleteMetricsRequest_builder{}.Build(),
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "invalid regex",
+			request: pb.DeleteMetricsRequest_builder{Regex: ptr("(")}.Build(),
+			code:    codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.DeleteMetrics(ctx, tt.request)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+
+	deleted, err := client.DeleteMetrics(
+		ctx,
+		pb.DeleteMetricsRequest_builder{Type: ptr(protocol.Counter), Id: ptr("PollCount")}.Build(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PollCount"}, deleted.GetDeleted())
+}
+
+func TestMetricsServerWatch(t *testing.T) {
+	client, controller := setupMetricsClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.WatchMetrics(ctx, pb.WatchMetricsRequest_builder{Prefix: ptr("cpu")}.Build())
+	require.NoError(t, err)
+	// Header arrives once server subscribed, updates published later are not lost.
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	value := 0.5
+	delta := int64(1)
+	require.NoError(t, controller.Update(ctx, protocol.Metrics{ID: "PollCount", MType: protocol.Counter, Delta: &delta}))
+	require.NoError(t, controller.Update(ctx, protocol.Metrics{ID: "cpu", MType: protocol.Gauge, Value: &value}))
+
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "cpu", update.GetId())
+	assert.Equal(t, pb.Metric_GAUGE, update.GetType())
+	assert.InDelta(t, value, update.GetValue(), 0)
+}
+
+// dialServer serves services registered by register over in-memory listener.
+func dialServer(t *testing.T, register func(server *grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	register(server)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
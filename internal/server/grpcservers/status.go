@@ -0,0 +1,89 @@
+package grpcservers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/controllers"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/internal/server/logic"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+var ErrUnknownType = errors.New("unknown type")
+
+// updateStatus maps update failure to gRPC status. Metric rejected by batch
+// validation is pointed to by its index in request values.
+// Failures not caused by request are reported as Unavailable, they come from storage.
+func updateStatus(err error) *status.Status {
+	var code codes.Code
+	switch {
+	case errors.Is(err, controllers.ErrWrongValueType),
+		errors.Is(err, controllers.ErrNonExistentType),
+		errors.Is(err, ErrUnknownType),
+		errors.Is(err, protocol.ErrInvalidLabel),
+		errors.Is(err, protocol.ErrInvalidID),
+		errors.Is(err, protocol.ErrInvalidHistogram):
+		code = codes.InvalidArgument
+	case errors.Is(err, data.ErrWrongType):
+		code = codes.FailedPrecondition
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err)
+	default:
+		code = codes.Unavailable
+	}
+	st := status.New(code, err.Error())
+
+	var metricErr *controllers.MetricError
+	if !errors.As(err, &metricErr) {
+		return st
+	}
+	field := fmt.Sprintf("values[%d]", metricErr.Index)
+	var details protoadapt.MessageV1
+	switch code {
+	case codes.InvalidArgument:
+		details = &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: field, Description: metricErr.Err.Error()},
+			},
+		}
+	case codes.FailedPrecondition:
+		details = &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{
+				{Type: "TYPE", Subject: field, Description: metricErr.Err.Error()},
+			},
+		}
+	default:
+		return st
+	}
+	withDetails, err := st.WithDetails(details)
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// deleteStatus maps deletion failure to gRPC status.
+func deleteStatus(err error) *status.Status {
+	switch {
+	case errors.Is(err, data.ErrNotFound):
+		return status.New(codes.NotFound, err.Error())
+	case errors.Is(err, data.ErrWrongType):
+		return status.New(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, controllers.ErrNonExistentType),
+		errors.Is(err, ErrUnknownType),
+		errors.Is(err, protocol.ErrInvalidLabel),
+		errors.Is(err, protocol.ErrInvalidID),
+		errors.Is(err, logic.ErrEmptyFilter):
+		return status.New(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err)
+	default:
+		return status.New(codes.Unavailable, err.Error())
+	}
+}
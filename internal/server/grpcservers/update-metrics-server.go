@@ -3,8 +3,15 @@ package grpcservers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/controllers"
+	"go-metrics-service/internal/server/data"
 	pb "go-metrics-service/proto"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 )
 
 var _ pb.UpdateMetricsServer = (*UpdateMetricsServer)(nil)
@@ -25,19 +32,52 @@ func NewUpdateMetricsServer(controller Controller) *UpdateMetricsServer {
 }
 
 func (s UpdateMetricsServer) UpdateMetrics(ctx context.Context, request *pb.UpdateMetricsRequest) (*pb.UpdateMetricsResponse, error) {
-	var response pb.UpdateMetricsResponse
-
 	metrics, err := ConvertMetrics(request.GetValues())
 	if err != nil {
-		return nil, err
+		return nil, updateStatus(err).Err() //nolint:wrapcheck // status error is returned as is
 	}
 
+	ctx = data.WithBatchID(withAgentIdentity(ctx), request.GetBatchId())
 	err = s.controller.UpdateMany(ctx, metrics)
 	if err != nil {
-		response.SetError(err.Error())
+		return nil, updateStatus(err).Err() //nolint:wrapcheck // status error is returned as is
 	}
 
-	return &response, nil
+	return &pb.UpdateMetricsResponse{}, nil
+}
+
+// StreamUpdates applies batches in order of arrival and acknowledges every batch by its sequence.
+// Batch failing to apply is acknowledged with error and does not break the stream.
+func (s UpdateMetricsServer) StreamUpdates(stream grpc.BidiStreamingServer[pb.MetricsBatch, pb.MetricsBatchAck]) error {
+	ctx := withAgentIdentity(stream.Context())
+	for {
+		batch, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to receive batch: %w", err)
+		}
+		sequence := batch.GetSequence()
+		ack := pb.MetricsBatchAck_builder{
+			Sequence: &sequence,
+		}.Build()
+		metrics, err := ConvertMetrics(batch.GetValues())
+		if err == nil {
+			err = s.controller.UpdateMany(data.WithBatchID(ctx, batch.GetBatchId()), metrics)
+		}
+		if err != nil {
+			ack.SetError(err.Error())
+			encoded, err := proto.Marshal(updateStatus(err).Proto())
+			if err != nil {
+				return fmt.Errorf("failed to encode status: %w", err)
+			}
+			ack.SetStatus(encoded)
+		}
+		if err := stream.Send(ack); err != nil {
+			return fmt.Errorf("failed to send ack: %w", err)
+		}
+	}
 }
 
 func ConvertMetrics(ms []*pb.Metric) ([]protocol.Metrics, error) {
@@ -45,7 +85,7 @@ func ConvertMetrics(ms []*pb.Metric) ([]protocol.Metrics, error) {
 	for i, value := range ms {
 		m, err := ConvertMetric(value)
 		if err != nil {
-			return nil, err
+			return nil, &controllers.MetricError{Err: err, Index: i}
 		}
 		metrics[i] = m
 	}
@@ -57,20 +97,35 @@ func ConvertMetric(m *pb.Metric) (protocol.Metrics, error) {
 	case pb.Metric_COUNTER:
 		delta := m.GetDelta()
 		return protocol.Metrics{
-			ID:    m.GetId(),
-			MType: protocol.Counter,
-			Value: nil,
-			Delta: &delta,
+			ID:     m.GetId(),
+			MType:  protocol.Counter,
+			Value:  nil,
+			Delta:  &delta,
+			Labels: m.GetLabels(),
 		}, nil
 	case pb.Metric_GAUGE:
 		value := m.GetValue()
+		return protocol.Metrics{
+			ID:     m.GetId(),
+			MType:  protocol.Gauge,
+			Value:  &value,
+			Delta:  nil,
+			Labels: m.GetLabels(),
+		}, nil
+	case pb.Metric_HISTOGRAM:
+		h := m.GetHistogram()
 		return protocol.Metrics{
 			ID:    m.GetId(),
-			MType: protocol.Gauge,
-			Value: &value,
-			Delta: nil,
+			MType: protocol.Histogram,
+			Histogram: &protocol.HistogramValue{
+				Bounds: h.GetBounds(),
+				Counts: h.GetCounts(),
+				Sum:    h.GetSum(),
+				Count:  h.GetCount(),
+			},
+			Labels: m.GetLabels(),
 		}, nil
 	default:
-		return protocol.Metrics{}, errors.New("unknown type " + m.GetType().String())
+		return protocol.Metrics{}, fmt.Errorf("%w %s", ErrUnknownType, m.GetType())
 	}
 }
@@ -0,0 +1,208 @@
+package grpcservers
+
+import (
+	"context"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/audit"
+	"go-metrics-service/internal/server/controllers"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/internal/server/data/repositories/memrepository"
+	"go-metrics-service/internal/server/data/storages"
+	"go-metrics-service/internal/server/data/storages/memstorage"
+	"go-metrics-service/internal/server/logic"
+	"go-metrics-service/internal/server/watch"
+	pb "go-metrics-service/proto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func setupUpdateMetricsClient(t *testing.T) (pb.UpdateMetricsClient, *memrepository.MemRepository) {
+	t.Helper()
+	logger := zap.NewNop()
+	repository := memrepository.New(memstorage.New(logger), data.HistoryConfig{}, logger)
+	controller := controllers.NewController(
+		controllers.Config{},
+		storages.NewDummyTransactionsManager(),
+		logic.NewService(repository, logger),
+		agents.New(0, 0),
+		audit.New(nil, logger),
+		watch.New(0),
+		logger,
+	)
+	conn := dialServer(t, func(server *grpc.Server) {
+		pb.RegisterUpdateMetricsServer(server, NewUpdateMetricsServer(controller))
+	})
+	return pb.NewUpdateMetricsClient(conn), repository
+}
+
+func gaugeMetric(id string, value float64) *pb.Metric {
+	return pb.Metric_builder{Id: &id, Type: ptr(pb.Metric_GAUGE), Value: &value}.Build()
+}
+
+func counterMetric(id string, delta int64) *pb.Metric {
+	return pb.Metric_builder{Id: &id, Type: ptr(pb.Metric_COUNTER), Delta: &delta}.Build()
+}
+
+func TestUpdateMetricsServerStatus(t *testing.T) {
+	client, _ := setupUpdateMetricsClient(t)
+	ctx := context.Background()
+
+	_, err := client.UpdateMetrics(ctx, pb.UpdateMetricsRequest_builder{
+		Values: []*pb.Metric{gaugeMetric("cpu", 1)},
+	}.Build())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		values []*pb.Metric
+		code   codes.Code
+		field  string
+	}{
+		{
+			name: "unknown type",
+			values: []*pb.Metric{
+				gaugeMetric("mem", 1),
+				pb.Metric_builder{Id: ptr("bad"), Type: ptr(pb.Metric_Type(42))}.Build(),
+			},
+			code:  codes.InvalidArgument,
+			field: "values[1]",
+		},
+		{
+			name: "invalid label",
+			values: []*pb.Metric{
+				pb.Metric_builder{
+					Id:     ptr("mem"),
+					Type:   ptr(pb.Metric_GAUGE),
+					Value:  ptr(1.0),
+					Labels: map[string]string{"1bad": "x"},
+				}.Build(),
+			},
+			code:  codes.InvalidArgument,
+			field: "values[0]",
+		},
+		{
+			name:   "type conflict",
+			values: []*pb.Metric{counterMetric("PollCount", 1), counterMetric("cpu", 1)},
+			code:   codes.FailedPrecondition,
+			field:  "values[1]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.UpdateMetrics(ctx, pb.UpdateMetricsRequest_builder{Values: tt.values}.Build())
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, tt.field, violatedField(t, st))
+		})
+	}
+}
+
+func TestUpdateMetricsServerStream(t *testing.T) {
+	client, _ := setupUpdateMetricsClient(t)
+	stream, err := client.StreamUpdates(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, stream.Send(pb.MetricsBatch_builder{
+		Sequence: ptr(uint64(7)),
+		Values:   []*pb.Metric{gaugeMetric("cpu", 2.5)},
+	}.Build()))
+	require.NoError(t, stream.Send(pb.MetricsBatch_builder{
+		Sequence: ptr(uint64(8)),
+		Values:   []*pb.Metric{counterMetric("PollCount", 1), counterMetric("cpu", 1)},
+	}.Build()))
+
+	ack, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), ack.GetSequence())
+	assert.Empty(t, ack.GetError())
+	assert.Empty(t, ack.GetStatus())
+
+	ack, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), ack.GetSequence())
+	assert.NotEmpty(t, ack.GetError())
+	var encoded spb.Status
+	require.NoError(t, proto.Unmarshal(ack.GetStatus(), &encoded))
+	st := status.FromProto(&encoded)
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	assert.Equal(t, "values[1]", violatedField(t, st))
+
+	require.NoError(t, stream.CloseSend())
+}
+
+func TestUpdateMetricsServerBatchDedup(t *testing.T) {
+	client, repository := setupUpdateMetricsClient(t)
+	ctx := context.Background()
+
+	request := pb.UpdateMetricsRequest_builder{
+		Values:  []*pb.Metric{counterMetric("PollCount", 2)},
+		BatchId: ptr("batch-1"),
+	}.Build()
+	_, err := client.UpdateMetrics(ctx, request)
+	require.NoError(t, err)
+	_, err = client.UpdateMetrics(ctx, request)
+	require.NoError(t, err)
+	value, err := repository.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+
+	stream, err := client.StreamUpdates(ctx)
+	require.NoError(t, err)
+	for i, batchID := range []string{"batch-1", "batch-2", "batch-2"} {
+		require.NoError(t, stream.Send(pb.MetricsBatch_builder{
+			Sequence: ptr(uint64(i)),
+			Values:   []*pb.Metric{counterMetric("PollCount", 3)},
+			BatchId:  &batchID,
+		}.Build()))
+		ack, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Empty(t, ack.GetError())
+	}
+	require.NoError(t, stream.CloseSend())
+	value, err = repository.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), value)
+
+	failing := pb.UpdateMetricsRequest_builder{
+		Values:  []*pb.Metric{counterMetric("Fails", 1), gaugeMetric("Fails", 1)},
+		BatchId: ptr("batch-3"),
+	}.Build()
+	_, err = client.UpdateMetrics(ctx, failing)
+	require.Error(t, err)
+	_, err = client.UpdateMetrics(ctx, failing)
+	require.Error(t, err, "failed batch must not be marked as applied")
+}
+
+func violatedField(t *testing.T, st *status.Status) string {
+	t.Helper()
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			require.Len(t, d.GetFieldViolations(), 1)
+			return d.GetFieldViolations()[0].GetField()
+		case *errdetails.PreconditionFailure:
+			require.Len(t, d.GetViolations(), 1)
+			return d.GetViolations()[0].GetSubject()
+		}
+	}
+	return ""
+}
+
+func TestUpdateStatusUnavailable(t *testing.T) {
+	st := updateStatus(&controllers.MetricError{Err: assert.AnError, Index: 3})
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Empty(t, st.Details())
+	assert.Equal(t, codes.Canceled, updateStatus(context.Canceled).Code())
+	assert.Equal(t, codes.InvalidArgument, updateStatus(protocol.ErrInvalidLabel).Code())
+}
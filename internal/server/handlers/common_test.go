@@ -23,12 +23,17 @@ type handlerTestData struct {
 	handlerSetup   handlerSetup
 	body           string
 	pathParams     map[string]string
+	query          string
 	expectedStatus int
 	expectedBody   string
 }
 
 func createResponseAndRequest(data *handlerTestData) (w *httptest.ResponseRecorder, r *http.Request) {
-	r = httptest.NewRequest(data.handlerSetup.method, data.handlerSetup.url, bytes.NewBufferString(data.body))
+	target := data.handlerSetup.url
+	if data.query != "" {
+		target += "?" + data.query
+	}
+	r = httptest.NewRequest(data.handlerSetup.method, target, bytes.NewBufferString(data.body))
 	w = httptest.NewRecorder()
 	if data.pathParams != nil {
 		rctx := chi.NewRouteContext()
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/audit"
+	"go-metrics-service/internal/server/data"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type DeleteMetricHandler struct {
+	deleter MetricDeleter
+	logger  *zap.Logger
+}
+
+func NewDeleteMetric(deleter MetricDeleter, logger *zap.Logger) *DeleteMetricHandler {
+	return &DeleteMetricHandler{
+		deleter: deleter,
+		logger:  logger,
+	}
+}
+
+func (h *DeleteMetricHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestLogger := NewRequestLogger(h.logger, r)
+	defer closeBody(r.Body, requestLogger)
+	metric := protocol.Metrics{
+		ID:    chi.URLParam(r, protocol.KeyParam),
+		MType: chi.URLParam(r, protocol.TypeParam),
+	}
+	if metric.ID == "" {
+		requestLogger.Debug("empty key requested")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch metric.MType {
+	case protocol.Gauge, protocol.Counter, protocol.Histogram:
+	default:
+		requestLogger.Debug("delete failed", zap.Error(ErrNonExistentType))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var err error
+	metric.Labels, err = labelsFromQuery(r)
+	if err != nil {
+		requestLogger.Debug("invalid labels", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	const errDelete = "delete failed"
+	ctx := withRequester(r)
+	if err := h.deleter.Delete(ctx, metric); err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotFound):
+			requestLogger.Debug(errDelete, zap.Error(err))
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case errors.Is(err, data.ErrWrongType),
+			errors.Is(err, protocol.ErrInvalidLabel),
+			errors.Is(err, protocol.ErrInvalidID):
+			requestLogger.Debug(errDelete, zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		default:
+			requestLogger.Error(errDelete, zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// withRequester puts connection address of the client into request context for audit.
+// Address from agent header can be forged, so it is kept only as reported one.
+func withRequester(r *http.Request) context.Context {
+	ctx := audit.WithReportedIP(r.Context(), r.Header.Get(protocol.RealIPHeader))
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return audit.WithRequesterIP(ctx, r.RemoteAddr)
+	}
+	return audit.WithRequesterIP(ctx, host)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/audit"
+	"go-metrics-service/internal/testutils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteMetric(t *testing.T) {
+	serverContext := testutils.NewServerContext()
+	updateMetricHandlerSetup := handlerSetup{
+		handler: NewUpdateMetric(serverContext.Controller, serverContext.Logger),
+		method:  http.MethodPost,
+		url:     protocol.UpdateMetricURL,
+	}
+	deleteMetricHandlerSetup := handlerSetup{
+		handler: NewDeleteMetric(serverContext.Controller, serverContext.Logger),
+		method:  http.MethodDelete,
+		url:     protocol.DeleteMetricPathParamsURL,
+	}
+	deleteMetricsHandlerSetup := handlerSetup{
+		handler: NewDeleteMetrics(serverContext.Controller, serverContext.Logger),
+		method:  http.MethodDelete,
+		url:     protocol.DeleteMetricsURL,
+	}
+	getMetricHandlerSetup := handlerSetup{
+		handler: NewGetMetricValue(
+			serverContext.Repository,
+			serverContext.Repository,
+			serverContext.Repository,
+			serverContext.Expiry,
+			serverContext.Logger,
+		),
+		method: http.MethodPost,
+		url:    protocol.GetMetricURL,
+	}
+
+	tests := []handlerTestData{
+		{
+			testName:       "set counter",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           testutils.TCreateCounterDeltaJSON(t, "test_counter", 5),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:     "delete with wrong type",
+			handlerSetup: deleteMetricHandlerSetup,
+			pathParams: map[string]string{
+				protocol.TypeParam: protocol.Gauge,
+				protocol.KeyParam:  "test_counter",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:     "delete non-existent type",
+			handlerSetup: deleteMetricHandlerSetup,
+			pathParams: map[string]string{
+				protocol.TypeParam: "non_existent_type",
+				protocol.KeyParam:  "test_counter",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:     "delete counter",
+			handlerSetup: deleteMetricHandlerSetup,
+			pathParams: map[string]string{
+				protocol.TypeParam: protocol.Counter,
+				protocol.KeyParam:  "test_counter",
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:     "delete deleted",
+			handlerSetup: deleteMetricHandlerSetup,
+			pathParams: map[string]string{
+				protocol.TypeParam: protocol.Counter,
+				protocol.KeyParam:  "test_counter",
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName:       "counter restarts from zero",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           testutils.TCreateCounterDeltaJSON(t, "test_counter", 2),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "get reset counter",
+			handlerSetup:   getMetricHandlerSetup,
+			body:           `{"id":"test_counter","type":"counter"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"test_counter","type":"counter","delta":2}`,
+		},
+		{
+			testName:       "set labelled gauge",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"cpu_usage","type":"gauge","value":1,"labels":{"host":"a"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "set gauge",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           testutils.TCreateGaugeDiffJSON(t, "cpu_idle", 2),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "set another gauge",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           testutils.TCreateGaugeDiffJSON(t, "mem_free", 3),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:     "delete labelled series",
+			handlerSetup: deleteMetricHandlerSetup,
+			pathParams: map[string]string{
+				protocol.TypeParam: protocol.Gauge,
+				protocol.KeyParam:  "cpu_usage",
+			},
+			query:          "host=b",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName:       "bulk delete without filter",
+			handlerSetup:   deleteMetricsHandlerSetup,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "bulk delete with invalid regex",
+			handlerSetup:   deleteMetricsHandlerSetup,
+			query:          "regex=(",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "bulk delete by prefix",
+			handlerSetup:   deleteMetricsHandlerSetup,
+			query:          "prefix=cpu_",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"deleted":["cpu_idle","cpu_usage{host=\"a\"}"]}`,
+		},
+		{
+			testName:       "bulk delete by regex of other type",
+			handlerSetup:   deleteMetricsHandlerSetup,
+			query:          "regex=^mem&type=counter",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"deleted":[]}`,
+		},
+		{
+			testName:       "bulk delete by regex",
+			handlerSetup:   deleteMetricsHandlerSetup,
+			query:          "regex=^mem&type=gauge",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"deleted":["mem_free"]}`,
+		},
+	}
+
+	performHTTPHandlerTests(t, tests)
+}
+
+func TestWithRequester(t *testing.T) {
+	r := httptest.NewRequest(http.MethodDelete, protocol.DeleteMetricsURL, nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+	r.Header.Set(protocol.RealIPHeader, "192.168.0.1")
+
+	ctx := withRequester(r)
+	assert.Equal(t, "10.0.0.1", audit.RequesterIPFromContext(ctx))
+	assert.Equal(t, "192.168.0.1", audit.ReportedIPFromContext(ctx))
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/logic"
+	"net/http"
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+// DeleteMetricsHandler removes every series whose metric ID matches
+// `prefix` and/or `regex` query params, optionally restricted by `type`.
+type DeleteMetricsHandler struct {
+	deleter MetricDeleter
+	logger  *zap.Logger
+}
+
+func NewDeleteMetrics(deleter MetricDeleter, logger *zap.Logger) *DeleteMetricsHandler {
+	return &DeleteMetricsHandler{
+		deleter: deleter,
+		logger:  logger,
+	}
+}
+
+func (h *DeleteMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestLogger := NewRequestLogger(h.logger, r)
+	defer closeBody(r.Body, requestLogger)
+
+	filter, err := parseDeleteFilter(r)
+	if err != nil {
+		requestLogger.Debug("failed to parse request", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	const errDelete = "delete failed"
+	ctx := withRequester(r)
+	deleted, err := h.deleter.DeleteMatching(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, logic.ErrEmptyFilter):
+			requestLogger.Debug(errDelete, zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		default:
+			requestLogger.Error(errDelete, zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	encoded, err := json.Marshal(protocol.DeleteResult{Deleted: deleted})
+	if err != nil {
+		requestLogger.Error("failed to marshal json", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(encoded)
+	if err != nil {
+		requestLogger.Error("failed to write response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func parseDeleteFilter(r *http.Request) (*logic.DeleteFilter, error) {
+	query := r.URL.Query()
+	filter := &logic.DeleteFilter{
+		Type:   query.Get(protocol.TypeParam),
+		Prefix: query.Get(protocol.PrefixParam),
+	}
+	switch filter.Type {
+	case "", protocol.Gauge, protocol.Counter, protocol.Histogram:
+	default:
+		return nil, ErrNonExistentType
+	}
+	if raw := query.Get(protocol.RegexParam); raw != "" {
+		pattern, err := regexp.Compile(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s' param: %w", ErrParsing, protocol.RegexParam, err)
+		}
+		filter.Pattern = pattern
+	}
+	if filter.Prefix == "" && filter.Pattern == nil {
+		return nil, fmt.Errorf("%w: prefix or regex required", ErrParsing)
+	}
+	return filter, nil
+}
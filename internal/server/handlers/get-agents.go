@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type GetAgentsHandler struct {
+	provider AgentsProvider
+	logger   *zap.Logger
+}
+
+func NewGetAgents(provider AgentsProvider, logger *zap.Logger) *GetAgentsHandler {
+	return &GetAgentsHandler{
+		provider: provider,
+		logger:   logger,
+	}
+}
+
+func (h *GetAgentsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestLogger := NewRequestLogger(h.logger, r)
+	defer closeBody(r.Body, requestLogger)
+	encoded, err := json.Marshal(h.provider.Agents())
+	if err != nil {
+		requestLogger.Error("failed to marshal json", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(encoded)
+	if err != nil {
+		requestLogger.Error("failed to write response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type GetAlertsHandler struct {
+	provider AlertsProvider
+	logger   *zap.Logger
+}
+
+func NewGetAlerts(provider AlertsProvider, logger *zap.Logger) *GetAlertsHandler {
+	return &GetAlertsHandler{
+		provider: provider,
+		logger:   logger,
+	}
+}
+
+func (h *GetAlertsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestLogger := NewRequestLogger(h.logger, r)
+	defer closeBody(r.Body, requestLogger)
+	encoded, err := json.Marshal(h.provider.FiringAlerts())
+	if err != nil {
+		requestLogger.Error("failed to marshal json", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(encoded)
+	if err != nil {
+		requestLogger.Error("failed to write response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"fmt"
+	"go-metrics-service/internal/common/protocol"
 	"html/template"
 	"net/http"
 
@@ -11,12 +12,18 @@ import (
 
 type GetAllMetricsHandler struct {
 	repository AllMetricsRepository
+	staleness  StalenessChecker
 	logger     *zap.Logger
 }
 
-func NewGetAllMetrics(repository AllMetricsRepository, logger *zap.Logger) *GetAllMetricsHandler {
+func NewGetAllMetrics(
+	repository AllMetricsRepository,
+	staleness StalenessChecker,
+	logger *zap.Logger,
+) *GetAllMetricsHandler {
 	return &GetAllMetricsHandler{
 		repository: repository,
+		staleness:  staleness,
 		logger:     logger,
 	}
 }
@@ -30,8 +37,21 @@ func (h *GetAllMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	staleKeys, err := h.staleness.StaleKeys(r.Context())
+	if err != nil {
+		requestLogger.Error("Failed to get stale metrics", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	var buffer bytes.Buffer
 	for k, v := range data {
+		if histogram, ok := v.(protocol.HistogramValue); ok {
+			v = formatHistogramSummary(&histogram)
+		}
+		if _, stale := staleKeys[k]; stale {
+			buffer.WriteString(fmt.Sprintf("%v: %v (stale)\n", k, v))
+			continue
+		}
 		buffer.WriteString(fmt.Sprintf("%v: %v\n", k, v))
 	}
 	tmpl, err := template.New("data").Parse(`{{ .}}`)
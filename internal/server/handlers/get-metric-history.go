@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/internal/server/logic"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+const defaultHistoryWindow = time.Hour
+
+type GetMetricHistoryHandler struct {
+	gaugeRepository   GaugeRepository
+	counterRepository CounterRepository
+	historyRepository HistoryRepository
+	logger            *zap.Logger
+}
+
+type historyRequest struct {
+	labels      map[string]string
+	from        time.Time
+	to          time.Time
+	metricType  string
+	key         string
+	aggregation string
+	step        time.Duration
+}
+
+func NewGetMetricHistory(
+	gaugeRepository GaugeRepository,
+	counterRepository CounterRepository,
+	historyRepository HistoryRepository,
+	logger *zap.Logger,
+) *GetMetricHistoryHandler {
+	return &GetMetricHistoryHandler{
+		gaugeRepository:   gaugeRepository,
+		counterRepository: counterRepository,
+		historyRepository: historyRepository,
+		logger:            logger,
+	}
+}
+
+func (h *GetMetricHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestLogger := NewRequestLogger(h.logger, r)
+	defer closeBody(r.Body, requestLogger)
+
+	request, err := parseHistoryRequest(r, time.Now())
+	if err != nil {
+		requestLogger.Debug("failed to parse request", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	const errHistory = "failed to get history"
+	result, err := h.getHistory(r.Context(), &request)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotFound):
+			requestLogger.Debug(errHistory, zap.Error(err))
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case errors.Is(err, data.ErrWrongType),
+			errors.Is(err, ErrNonExistentType),
+			errors.Is(err, logic.ErrWrongAggregation):
+			requestLogger.Debug(errHistory, zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		default:
+			requestLogger.Error(errHistory, zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		requestLogger.Error("failed to marshal json", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(encoded)
+	if err != nil {
+		requestLogger.Error("failed to write response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *GetMetricHistoryHandler) getHistory(
+	ctx context.Context,
+	request *historyRequest,
+) ([]protocol.MetricsSample, error) {
+	seriesKey := protocol.SeriesKey(request.key, request.labels)
+	switch request.metricType {
+	case protocol.Gauge:
+		if _, err := h.gaugeRepository.GetGauge(ctx, seriesKey); err != nil {
+			return nil, fmt.Errorf("get gauge: %w", err)
+		}
+	case protocol.Counter:
+		if _, err := h.counterRepository.GetCounter(ctx, seriesKey); err != nil {
+			return nil, fmt.Errorf("get counter: %w", err)
+		}
+	default:
+		return nil, ErrNonExistentType
+	}
+	from := request.from
+	if request.step > 0 && request.metricType == protocol.Counter {
+		// Counter increase of the first bucket is counted from the last sample before it.
+		from = from.Add(-request.step)
+	}
+	samples, err := h.historyRepository.GetHistory(ctx, seriesKey, from, request.to)
+	if err != nil {
+		return nil, fmt.Errorf("get history: %w", err)
+	}
+	if request.step > 0 {
+		aggregation := request.aggregation
+		if aggregation == "" {
+			aggregation = logic.DefaultAggregation(request.metricType)
+		}
+		samples, err = logic.Downsample(samples, request.metricType, request.from, request.step, aggregation)
+		if err != nil {
+			return nil, fmt.Errorf("downsample: %w", err)
+		}
+	}
+	res := make([]protocol.MetricsSample, 0, len(samples))
+	for _, sample := range samples {
+		m := protocol.MetricsSample{
+			Metrics: protocol.Metrics{
+				ID:     request.key,
+				MType:  request.metricType,
+				Labels: request.labels,
+			},
+			Timestamp: sample.Timestamp,
+		}
+		switch value := sample.Value.(type) {
+		case int64:
+			m.Delta = &value
+		case float64:
+			m.Value = &value
+		default:
+			return nil, data.ErrWrongType
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+func parseHistoryRequest(r *http.Request, now time.Time) (historyRequest, error) {
+	query := r.URL.Query()
+	request := historyRequest{
+		metricType:  chi.URLParam(r, protocol.TypeParam),
+		key:         chi.URLParam(r, protocol.KeyParam),
+		aggregation: query.Get(protocol.AggregationParam),
+		to:          now,
+	}
+	if request.key == "" {
+		return historyRequest{}, fmt.Errorf("%w: empty key", ErrParsing)
+	}
+	var err error
+	request.labels, err = labelsFromQuery(
+		r,
+		protocol.FromParam,
+		protocol.ToParam,
+		protocol.StepParam,
+		protocol.AggregationParam,
+	)
+	if err != nil {
+		return historyRequest{}, err
+	}
+	if query.Has(protocol.ToParam) {
+		request.to, err = parseTimeParam(query, protocol.ToParam)
+		if err != nil {
+			return historyRequest{}, err
+		}
+	}
+	request.from = request.to.Add(-defaultHistoryWindow)
+	if query.Has(protocol.FromParam) {
+		request.from, err = parseTimeParam(query, protocol.FromParam)
+		if err != nil {
+			return historyRequest{}, err
+		}
+	}
+	if request.from.After(request.to) {
+		return historyRequest{}, ErrWrongInterval
+	}
+	if query.Has(protocol.StepParam) {
+		request.step, err = parseDurationParam(query, protocol.StepParam)
+		if err != nil {
+			return historyRequest{}, err
+		}
+		if request.step <= 0 {
+			return historyRequest{}, ErrWrongInterval
+		}
+	}
+	return request, nil
+}
+
+// parseTimeParam accepts either RFC 3339 timestamp or unix seconds.
+func parseTimeParam(query url.Values, name string) (time.Time, error) {
+	raw := query.Get(name)
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	res, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: '%s' param: %w", ErrParsing, name, err)
+	}
+	return res, nil
+}
+
+// parseDurationParam accepts either Go duration string or seconds.
+func parseDurationParam(query url.Values, name string) (time.Duration, error) {
+	raw := query.Get(name)
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	res, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%w: '%s' param: %w", ErrParsing, name, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/testutils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMetricHistory(t *testing.T) {
+	serverContext := testutils.NewServerContext()
+	updateMetricHandlerSetup := handlerSetup{
+		handler: NewUpdateMetric(serverContext.Controller, serverContext.Logger),
+		method:  http.MethodPost,
+		url:     protocol.UpdateMetricURL,
+	}
+	historyHandler := NewGetMetricHistory(
+		serverContext.Repository,
+		serverContext.Repository,
+		serverContext.Repository,
+		serverContext.Logger,
+	)
+
+	for _, delta := range []int64{1, 2, 3} {
+		w, r := createResponseAndRequest(&handlerTestData{
+			handlerSetup: updateMetricHandlerSetup,
+			body:         testutils.TCreateCounterDeltaJSON(t, "test_counter", delta),
+		})
+		updateMetricHandlerSetup.handler.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	historySetup := func(url string) handlerSetup {
+		return handlerSetup{
+			handler: historyHandler,
+			method:  http.MethodGet,
+			url:     url,
+		}
+	}
+	counterParams := map[string]string{
+		protocol.TypeParam: protocol.Counter,
+		protocol.KeyParam:  "test_counter",
+	}
+
+	t.Run("raw samples", func(t *testing.T) {
+		w, r := createResponseAndRequest(&handlerTestData{
+			handlerSetup: historySetup("/history/counter/test_counter"),
+			pathParams:   counterParams,
+		})
+		historyHandler.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		var samples []protocol.MetricsSample
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &samples))
+		require.Len(t, samples, 3)
+		for i, expected := range []int64{1, 3, 6} {
+			require.NotNil(t, samples[i].Delta)
+			assert.Equal(t, expected, *samples[i].Delta)
+			assert.Equal(t, "test_counter", samples[i].ID)
+		}
+	})
+
+	performHTTPHandlerTests(t, []handlerTestData{
+		{
+			testName:       "non-existent metric",
+			handlerSetup:   historySetup("/history/counter/non_existent"),
+			pathParams:     map[string]string{protocol.TypeParam: protocol.Counter, protocol.KeyParam: "non_existent"},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName:       "wrong type",
+			handlerSetup:   historySetup("/history/gauge/test_counter"),
+			pathParams:     map[string]string{protocol.TypeParam: protocol.Gauge, protocol.KeyParam: "test_counter"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "wrong aggregation",
+			handlerSetup:   historySetup("/history/counter/test_counter?step=1m&agg=avg"),
+			pathParams:     counterParams,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "wrong interval",
+			handlerSetup:   historySetup("/history/counter/test_counter?from=200&to=100"),
+			pathParams:     counterParams,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "invalid step",
+			handlerSetup:   historySetup("/history/counter/test_counter?step=abc"),
+			pathParams:     counterParams,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "empty interval",
+			handlerSetup:   historySetup("/history/counter/test_counter?from=100&to=200"),
+			pathParams:     counterParams,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[]",
+		},
+	})
+}
@@ -13,20 +13,26 @@ import (
 )
 
 type GetMetricValueHandler struct {
-	gaugeRepository   GaugeRepository
-	counterRepository CounterRepository
-	logger            *zap.Logger
+	gaugeRepository     GaugeRepository
+	counterRepository   CounterRepository
+	histogramRepository HistogramRepository
+	staleness           StalenessChecker
+	logger              *zap.Logger
 }
 
 func NewGetMetricValue(
 	gaugeRepository GaugeRepository,
 	counterRepository CounterRepository,
+	histogramRepository HistogramRepository,
+	staleness StalenessChecker,
 	logger *zap.Logger,
 ) *GetMetricValueHandler {
 	return &GetMetricValueHandler{
-		gaugeRepository:   gaugeRepository,
-		counterRepository: counterRepository,
-		logger:            logger,
+		gaugeRepository:     gaugeRepository,
+		counterRepository:   counterRepository,
+		histogramRepository: histogramRepository,
+		staleness:           staleness,
+		logger:              logger,
 	}
 }
 
@@ -58,6 +64,10 @@ func (h *GetMetricValueHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 			requestLogger.Debug(errFill, zap.Error(err))
 			w.WriteHeader(http.StatusBadRequest)
 			return
+		case errors.Is(err, protocol.ErrInvalidLabel):
+			requestLogger.Debug(errFill, zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
 		default:
 			requestLogger.Error(errFill, zap.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -81,21 +91,37 @@ func (h *GetMetricValueHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 }
 
 func (h *GetMetricValueHandler) fill(ctx context.Context, requestData *protocol.Metrics) error {
+	if err := protocol.ValidateLabels(requestData.Labels); err != nil {
+		return err //nolint:wrapcheck // unnecessary
+	}
+	key := requestData.SeriesKey()
 	switch requestData.MType {
 	case protocol.Gauge:
-		value, err := h.gaugeRepository.GetGauge(ctx, requestData.ID)
+		value, err := h.gaugeRepository.GetGauge(ctx, key)
 		if err != nil {
 			return fmt.Errorf("get gauge: %w", err)
 		}
 		requestData.Value = &value
 	case protocol.Counter:
-		value, err := h.counterRepository.GetCounter(ctx, requestData.ID)
+		value, err := h.counterRepository.GetCounter(ctx, key)
 		if err != nil {
 			return fmt.Errorf("get counter: %w", err)
 		}
 		requestData.Delta = &value
+	case protocol.Histogram:
+		value, err := h.histogramRepository.GetHistogram(ctx, key)
+		if err != nil {
+			return fmt.Errorf("get histogram: %w", err)
+		}
+		requestData.Histogram = &value
+		requestData.Quantiles = value.Quantiles()
 	default:
 		return ErrNonExistentType
 	}
+	stale, err := h.staleness.IsStale(ctx, key)
+	if err != nil {
+		return fmt.Errorf("check staleness: %w", err)
+	}
+	requestData.Stale = stale
 	return nil
 }
@@ -15,9 +15,15 @@ func TestGetMetric(t *testing.T) {
 		url:     protocol.UpdateMetricURL,
 	}
 	getMetricHandlerSetup := handlerSetup{
-		handler: NewGetMetricValue(serverContext.Repository, serverContext.Repository, serverContext.Logger),
-		method:  http.MethodPost,
-		url:     protocol.GetMetricURL,
+		handler: NewGetMetricValue(
+			serverContext.Repository,
+			serverContext.Repository,
+			serverContext.Repository,
+			serverContext.Expiry,
+			serverContext.Logger,
+		),
+		method: http.MethodPost,
+		url:    protocol.GetMetricURL,
 	}
 
 	tests := []handlerTestData{
@@ -53,6 +59,59 @@ func TestGetMetric(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"id":"test_gauge","type":"gauge","value":1.3}`,
 		},
+		{
+			testName:       "set labelled gauge",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"test_gauge","type":"gauge","value":7.5,"labels":{"host":"a"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "get labelled gauge value",
+			handlerSetup:   getMetricHandlerSetup,
+			body:           `{"id":"test_gauge","type":"gauge","labels":{"host":"a"}}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"test_gauge","type":"gauge","value":7.5,"labels":{"host":"a"}}`,
+		},
+		{
+			testName:       "unlabelled gauge is distinct series",
+			handlerSetup:   getMetricHandlerSetup,
+			body:           `{"id":"test_gauge","type":"gauge"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"test_gauge","type":"gauge","value":1.3}`,
+		},
+		{
+			testName:       "get non-existent label set",
+			handlerSetup:   getMetricHandlerSetup,
+			body:           `{"id":"test_gauge","type":"gauge","labels":{"host":"b"}}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName:       "invalid label name",
+			handlerSetup:   getMetricHandlerSetup,
+			body:           `{"id":"test_gauge","type":"gauge","labels":{"1host":"a"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "observe histogram",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"latency","type":"histogram","histogram":{"bounds":[1,2],"counts":[2,2,0],"sum":5,"count":4}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "merge histogram",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"latency","type":"histogram","histogram":{"bounds":[1,2],"counts":[2,2,0],"sum":5,"count":4}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "get histogram with quantiles",
+			handlerSetup:   getMetricHandlerSetup,
+			body:           `{"id":"latency","type":"histogram"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"id":"latency","type":"histogram",` +
+				`"histogram":{"bounds":[1,2],"counts":[4,4,0],"sum":10,"count":8},` +
+				`"quantiles":{"p50":1,"p90":1.8,"p99":1.98}}`,
+		},
 	}
 
 	performHTTPHandlerTests(t, tests)
@@ -66,9 +125,15 @@ func BenchmarkGetMetric(b *testing.B) {
 		url:     protocol.UpdateMetricURL,
 	}
 	getMetricHandlerSetup := handlerSetup{
-		handler: NewGetMetricValue(serverContext.Repository, serverContext.Repository, serverContext.Logger),
-		method:  http.MethodPost,
-		url:     protocol.GetMetricURL,
+		handler: NewGetMetricValue(
+			serverContext.Repository,
+			serverContext.Repository,
+			serverContext.Repository,
+			serverContext.Expiry,
+			serverContext.Logger,
+		),
+		method: http.MethodPost,
+		url:    protocol.GetMetricURL,
 	}
 
 	updateMetricTestData := &handlerTestData{
@@ -8,26 +8,33 @@ import (
 	"go-metrics-service/internal/server/data"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
 type GetMetricValuePathParamsHandler struct {
-	gaugeRepository   GaugeRepository
-	counterRepository CounterRepository
-	logger            *zap.Logger
+	gaugeRepository     GaugeRepository
+	counterRepository   CounterRepository
+	histogramRepository HistogramRepository
+	staleness           StalenessChecker
+	logger              *zap.Logger
 }
 
 func NewGetMetricValuePathParams(
 	gaugeRepository GaugeRepository,
 	counterRepository CounterRepository,
+	histogramRepository HistogramRepository,
+	staleness StalenessChecker,
 	logger *zap.Logger,
 ) *GetMetricValuePathParamsHandler {
 	return &GetMetricValuePathParamsHandler{
-		gaugeRepository:   gaugeRepository,
-		counterRepository: counterRepository,
-		logger:            logger,
+		gaugeRepository:     gaugeRepository,
+		counterRepository:   counterRepository,
+		histogramRepository: histogramRepository,
+		staleness:           staleness,
+		logger:              logger,
 	}
 }
 
@@ -41,7 +48,14 @@ func (h *GetMetricValuePathParamsHandler) ServeHTTP(w http.ResponseWriter, r *ht
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	result, err := h.getValue(r.Context(), metricType, key)
+	labels, err := labelsFromQuery(r)
+	if err != nil {
+		requestLogger.Debug("invalid labels", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	seriesKey := protocol.SeriesKey(key, labels)
+	result, err := h.getValue(r.Context(), metricType, seriesKey)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrNotFound):
@@ -58,6 +72,15 @@ func (h *GetMetricValuePathParamsHandler) ServeHTTP(w http.ResponseWriter, r *ht
 			return
 		}
 	}
+	stale, err := h.staleness.IsStale(r.Context(), seriesKey)
+	if err != nil {
+		requestLogger.Error("failed to check staleness", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if stale {
+		w.Header().Set(protocol.StaleHeader, "true")
+	}
 	w.Header().Set("Content-Type", "text/plain")
 	_, err = w.Write([]byte(result))
 	if err != nil {
@@ -82,7 +105,29 @@ func (h *GetMetricValuePathParamsHandler) getValue(ctx context.Context, metricTy
 			return "", fmt.Errorf("get counter: %w", err)
 		}
 		return strconv.FormatInt(value, 10), nil
+	case protocol.Histogram:
+		value, err := h.histogramRepository.GetHistogram(ctx, key)
+		if err != nil {
+			return "", fmt.Errorf("get histogram: %w", err)
+		}
+		return formatHistogramSummary(&value), nil
 	default:
 		return "", ErrNonExistentType
 	}
 }
+
+// formatHistogramSummary renders histogram as "count=N sum=S p50=.. p90=.. p99=..".
+func formatHistogramSummary(value *protocol.HistogramValue) string {
+	parts := []string{
+		"count=" + strconv.FormatUint(value.Count, 10),
+		"sum=" + strconv.FormatFloat(value.Sum, 'f', -1, 64),
+	}
+	quantiles := value.Quantiles()
+	for _, q := range protocol.DefaultQuantiles {
+		name := protocol.QuantileName(q)
+		if v, ok := quantiles[name]; ok {
+			parts = append(parts, name+"="+strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type GetPrometheusMetricsHandler struct {
+	repository AllMetricsRepository
+	staleness  StalenessChecker
+	logger     *zap.Logger
+}
+
+func NewGetPrometheusMetrics(
+	repository AllMetricsRepository,
+	staleness StalenessChecker,
+	logger *zap.Logger,
+) *GetPrometheusMetricsHandler {
+	return &GetPrometheusMetricsHandler{
+		repository: repository,
+		staleness:  staleness,
+		logger:     logger,
+	}
+}
+
+func (h *GetPrometheusMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestLogger := NewRequestLogger(h.logger, r)
+	defer closeBody(r.Body, requestLogger)
+	values, err := h.repository.GetAll(r.Context())
+	if err != nil {
+		requestLogger.Error("Failed to get metrics", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	staleKeys, err := h.staleness.StaleKeys(r.Context())
+	if err != nil {
+		requestLogger.Error("Failed to get stale metrics", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Stale series are not exposed so that scrapers see them disappear.
+	for key := range staleKeys {
+		delete(values, key)
+	}
+	w.Header().Set("Content-Type", prometheusContentType)
+	_, err = w.Write(formatPrometheus(values, requestLogger))
+	if err != nil {
+		requestLogger.Error("failed to write response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+var prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type prometheusSeries struct {
+	labels map[string]string
+	key    string
+	id     string
+	name   string
+}
+
+// formatPrometheus renders values in Prometheus text exposition format sorted by metric name and labels.
+func formatPrometheus(values map[string]any, logger *zap.Logger) []byte {
+	series := make([]prometheusSeries, 0, len(values))
+	for key := range values {
+		id, labels, err := protocol.ParseSeriesKey(key)
+		if err != nil {
+			logger.Error("unparsable series key", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		series = append(series, prometheusSeries{
+			key:    key,
+			id:     id,
+			name:   SanitizePrometheusName(id),
+			labels: labels,
+		})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].name != series[j].name {
+			return series[i].name < series[j].name
+		}
+		return series[i].key < series[j].key
+	})
+	var buffer bytes.Buffer
+	emittedTypes := make(map[string]string, len(series))
+	// Different IDs may sanitize to the same name, series of the first ID in sort order own it.
+	owners := make(map[string]string, len(series))
+	for _, s := range series {
+		var metricType, value string
+		var histogram *protocol.HistogramValue
+		switch v := values[s.key].(type) {
+		case int64:
+			metricType = "counter"
+			value = strconv.FormatInt(v, 10)
+		case float64:
+			metricType = "gauge"
+			value = strconv.FormatFloat(v, 'g', -1, 64)
+		case protocol.HistogramValue:
+			metricType = "histogram"
+			histogram = &v
+		default:
+			logger.Error("unsupported metric value type", zap.String("key", s.key))
+			continue
+		}
+		if owner, ok := owners[s.name]; ok && owner != s.id {
+			logger.Warn(
+				"prometheus metric name collision",
+				zap.String("key", s.key),
+				zap.String("name", s.name),
+				zap.String("owner", owner),
+			)
+			continue
+		}
+		owners[s.name] = s.id
+		if emittedType, ok := emittedTypes[s.name]; ok {
+			if emittedType != metricType {
+				logger.Warn("prometheus metric name collision", zap.String("key", s.key), zap.String("name", s.name))
+				continue
+			}
+		} else {
+			emittedTypes[s.name] = metricType
+			buffer.WriteString(fmt.Sprintf("# TYPE %s %s\n", s.name, metricType))
+		}
+		if histogram != nil {
+			writePrometheusHistogram(&buffer, s, histogram)
+			continue
+		}
+		buffer.WriteString(fmt.Sprintf("%s%s %s\n", s.name, formatPrometheusLabels(s.labels), value))
+	}
+	return buffer.Bytes()
+}
+
+// writePrometheusHistogram renders cumulative buckets along with sum and count series.
+func writePrometheusHistogram(buffer *bytes.Buffer, s prometheusSeries, histogram *protocol.HistogramValue) {
+	bucketLabels := make(map[string]string, len(s.labels)+1)
+	for name, value := range s.labels {
+		bucketLabels[name] = value
+	}
+	cumulative := uint64(0)
+	for i, count := range histogram.Counts {
+		cumulative += count
+		bucketLabels["le"] = "+Inf"
+		if i < len(histogram.Bounds) {
+			bucketLabels["le"] = strconv.FormatFloat(histogram.Bounds[i], 'g', -1, 64)
+		}
+		buffer.WriteString(fmt.Sprintf("%s_bucket%s %d\n", s.name, formatPrometheusLabels(bucketLabels), cumulative))
+	}
+	labels := formatPrometheusLabels(s.labels)
+	buffer.WriteString(fmt.Sprintf("%s_sum%s %s\n", s.name, labels, strconv.FormatFloat(histogram.Sum, 'g', -1, 64)))
+	buffer.WriteString(fmt.Sprintf("%s_count%s %d\n", s.name, labels, histogram.Count))
+}
+
+func formatPrometheusLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, prometheusLabelValueEscaper.Replace(labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// SanitizePrometheusName replaces characters not allowed in Prometheus metric names with underscores.
+func SanitizePrometheusName(key string) string {
+	var builder strings.Builder
+	for i, c := range key {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == ':'
+		isDigit := c >= '0' && c <= '9'
+		switch {
+		case isLetter:
+			builder.WriteRune(c)
+		case isDigit:
+			if i == 0 {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(c)
+		default:
+			builder.WriteByte('_')
+		}
+	}
+	if builder.Len() == 0 {
+		return "_"
+	}
+	return builder.String()
+}
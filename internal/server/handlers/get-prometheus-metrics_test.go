@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/testutils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPrometheusMetrics(t *testing.T) {
+	serverContext := testutils.NewServerContext()
+	updateMetricHandlerSetup := handlerSetup{
+		handler: NewUpdateMetric(serverContext.Controller, serverContext.Logger),
+		method:  http.MethodPost,
+		url:     protocol.UpdateMetricURL,
+	}
+	prometheusHandlerSetup := handlerSetup{
+		handler: NewGetPrometheusMetrics(serverContext.Repository, serverContext.Expiry, serverContext.Logger),
+		method:  http.MethodGet,
+		url:     protocol.PrometheusMetricsURL,
+	}
+
+	tests := []handlerTestData{
+		{
+			testName:       "empty",
+			handlerSetup:   prometheusHandlerSetup,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "add counter",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           testutils.TCreateCounterDeltaJSON(t, "PollCount", 5),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "add gauge",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           testutils.TCreateGaugeDiffJSON(t, "Heap.Alloc-1", 1.5e9),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "add labelled gauges",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"Heap.Alloc-1","type":"gauge","value":2,"labels":{"host":"b\"1"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "add gauge colliding after sanitizing",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           testutils.TCreateGaugeDiffJSON(t, "Heap_Alloc-1", 3),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "add histogram",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"GCPause","type":"histogram","histogram":{"bounds":[0.1,1],"counts":[1,2,0],"sum":1.5,"count":3}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "exposition",
+			handlerSetup:   prometheusHandlerSetup,
+			expectedStatus: http.StatusOK,
+			expectedBody: "# TYPE GCPause histogram\n" +
+				"GCPause_bucket{le=\"0.1\"} 1\n" +
+				"GCPause_bucket{le=\"1\"} 3\n" +
+				"GCPause_bucket{le=\"+Inf\"} 3\n" +
+				"GCPause_sum 1.5\n" +
+				"GCPause_count 3\n" +
+				"# TYPE Heap_Alloc_1 gauge\n" +
+				"Heap_Alloc_1 1.5e+09\n" +
+				"Heap_Alloc_1{host=\"b\\\"1\"} 2\n" +
+				"# TYPE PollCount counter\n" +
+				"PollCount 5\n",
+		},
+	}
+
+	performHTTPHandlerTests(t, tests)
+}
+
+func TestSanitizePrometheusName(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{key: "HeapAlloc", expected: "HeapAlloc"},
+		{key: "CpuUtilization1", expected: "CpuUtilization1"},
+		{key: "1st-metric", expected: "_1st_metric"},
+		{key: "ns:metric.name", expected: "ns:metric_name"},
+		{key: "", expected: "_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SanitizePrometheusName(tt.key))
+		})
+	}
+}
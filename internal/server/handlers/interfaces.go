@@ -4,6 +4,11 @@ import (
 	"context"
 	"errors"
 	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/alerting"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/internal/server/logic"
+	"time"
 )
 
 // Errors.
@@ -12,6 +17,7 @@ var (
 	ErrNonExistentType = errors.New("non-existent type")
 	ErrWrongValueType  = errors.New("wrong value type")
 	ErrParsing         = errors.New("parsing error")
+	ErrWrongInterval   = errors.New("wrong interval")
 )
 
 // Data.
@@ -24,13 +30,39 @@ type CounterRepository interface {
 	GetCounter(ctx context.Context, key string) (int64, error)
 }
 
+type HistogramRepository interface {
+	GetHistogram(ctx context.Context, key string) (protocol.HistogramValue, error)
+}
+
 type AllMetricsRepository interface {
 	GetAll(ctx context.Context) (map[string]any, error)
 }
 
+type StalenessChecker interface {
+	IsStale(ctx context.Context, key string) (bool, error)
+	StaleKeys(ctx context.Context) (map[string]struct{}, error)
+}
+
+type HistoryRepository interface {
+	GetHistory(ctx context.Context, key string, from, to time.Time) ([]data.Sample, error)
+}
+
 // Logic.
 
 type MetricController interface {
 	Update(ctx context.Context, metric protocol.Metrics) error
 	UpdateMany(ctx context.Context, metrics []protocol.Metrics) error
 }
+
+type MetricDeleter interface {
+	Delete(ctx context.Context, metric protocol.Metrics) error
+	DeleteMatching(ctx context.Context, filter *logic.DeleteFilter) ([]string, error)
+}
+
+type AlertsProvider interface {
+	FiringAlerts() []alerting.Alert
+}
+
+type AgentsProvider interface {
+	Agents() []agents.Agent
+}
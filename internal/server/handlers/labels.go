@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"go-metrics-service/internal/common/protocol"
+	"net/http"
+	"slices"
+)
+
+// labelsFromQuery treats every query parameter except reserved ones as label selector.
+func labelsFromQuery(r *http.Request, reserved ...string) (map[string]string, error) {
+	query := r.URL.Query()
+	labels := make(map[string]string)
+	for name, values := range query {
+		if slices.Contains(reserved, name) || len(values) == 0 {
+			continue
+		}
+		labels[name] = values[0]
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	if err := protocol.ValidateLabels(labels); err != nil {
+		return nil, err //nolint:wrapcheck // unnecessary
+	}
+	return labels, nil
+}
@@ -54,6 +54,12 @@ func (h *UpdateMetricValueHandler) ServeHTTP(w http.ResponseWriter, r *http.Requ
 			requestLogger.Debug(errUpdate, zap.Error(err))
 			w.WriteHeader(http.StatusBadRequest)
 			return
+		case errors.Is(err, protocol.ErrInvalidLabel),
+			errors.Is(err, protocol.ErrInvalidID),
+			errors.Is(err, protocol.ErrInvalidHistogram):
+			requestLogger.Debug(errUpdate, zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
 		default:
 			requestLogger.Error(errUpdate, zap.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -78,6 +84,13 @@ func (h *UpdateMetricValueHandler) update(ctx context.Context, requestData *prot
 		if err := h.metricController.Update(ctx, *requestData); err != nil {
 			return fmt.Errorf("change counter: %w", err)
 		}
+	case protocol.Histogram:
+		if requestData.Histogram == nil {
+			return ErrWrongValueType
+		}
+		if err := h.metricController.Update(ctx, *requestData); err != nil {
+			return fmt.Errorf("merge histogram: %w", err)
+		}
 	default:
 		return ErrNonExistentType
 	}
@@ -112,6 +112,30 @@ func TestUpdateMetric(t *testing.T) {
 			body:           `{"id":"test_gauge","type":"gauge","value":"hello, world!"}`,
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			testName:       "histogram",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"test_histogram","type":"histogram","histogram":{"bounds":[1],"counts":[1,0],"sum":0.5,"count":1}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "histogram bounds mismatch",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"test_histogram","type":"histogram","histogram":{"bounds":[2],"counts":[1,0],"sum":0.5,"count":1}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "histogram counts mismatch",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"test_histogram","type":"histogram","histogram":{"bounds":[1],"counts":[1],"sum":0.5,"count":1}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "no value specified histogram",
+			handlerSetup:   updateMetricHandlerSetup,
+			body:           `{"id":"test_histogram","type":"histogram"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	performHTTPHandlerTests(t, tests)
@@ -39,10 +39,16 @@ func (h *UpdateMetricPathParamsHandler) ServeHTTP(w http.ResponseWriter, r *http
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	err := h.updateValue(r.Context(), metricType, key, valueStr, requestLogger)
+	labels, err := labelsFromQuery(r)
+	if err != nil {
+		requestLogger.Debug("invalid labels", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = h.updateValue(r.Context(), metricType, key, valueStr, labels, requestLogger)
 	if err != nil {
 		switch {
-		case errors.Is(err, ErrParsing):
+		case errors.Is(err, ErrParsing), errors.Is(err, protocol.ErrInvalidLabel), errors.Is(err, protocol.ErrInvalidID):
 			requestLogger.Debug("parsing failed", zap.Error(err))
 			w.WriteHeader(http.StatusBadRequest)
 			return
@@ -65,6 +71,7 @@ func (h *UpdateMetricPathParamsHandler) ServeHTTP(w http.ResponseWriter, r *http
 func (h *UpdateMetricPathParamsHandler) updateValue(
 	ctx context.Context,
 	metricType, key, valueStr string,
+	labels map[string]string,
 	requestLogger *zap.Logger,
 ) error {
 	requestLogger.Debug("updating value",
@@ -81,10 +88,11 @@ func (h *UpdateMetricPathParamsHandler) updateValue(
 		if err := h.metricController.Update(
 			ctx,
 			protocol.Metrics{
-				ID:    key,
-				MType: protocol.Gauge,
-				Delta: nil,
-				Value: &value,
+				ID:     key,
+				MType:  protocol.Gauge,
+				Delta:  nil,
+				Value:  &value,
+				Labels: labels,
 			},
 		); err != nil {
 			return fmt.Errorf("failed to set: %w", err)
@@ -98,10 +106,11 @@ func (h *UpdateMetricPathParamsHandler) updateValue(
 		if err := h.metricController.Update(
 			ctx,
 			protocol.Metrics{
-				ID:    key,
-				MType: protocol.Counter,
-				Delta: &delta,
-				Value: nil,
+				ID:     key,
+				MType:  protocol.Counter,
+				Delta:  &delta,
+				Value:  nil,
+				Labels: labels,
 			},
 		); err != nil {
 			return fmt.Errorf("failed to set: %w", err)
@@ -146,6 +146,28 @@ func TestUpdateMetricPathParams(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			testName:     "labelled series",
+			handlerSetup: updateMetricHandlerSetup,
+			pathParams: map[string]string{
+				protocol.TypeParam:  protocol.Gauge,
+				protocol.KeyParam:   "test_counter",
+				protocol.ValueParam: "1.5",
+			},
+			query:          "host=a",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:     "invalid label name",
+			handlerSetup: updateMetricHandlerSetup,
+			pathParams: map[string]string{
+				protocol.TypeParam:  protocol.Gauge,
+				protocol.KeyParam:   "test_gauge",
+				protocol.ValueParam: "1.5",
+			},
+			query:          "host-name=a",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	performHTTPHandlerTests(t, tests)
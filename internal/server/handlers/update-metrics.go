@@ -37,9 +37,13 @@ func (h *UpdateMetricsValueHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err := h.metricController.UpdateMany(r.Context(), requestData); err != nil {
+	ctx := data.WithBatchID(r.Context(), r.Header.Get(protocol.IdempotencyKeyHeader))
+	if err := h.metricController.UpdateMany(ctx, requestData); err != nil {
 		switch {
-		case errors.Is(err, ErrParsing):
+		case errors.Is(err, ErrParsing),
+			errors.Is(err, protocol.ErrInvalidLabel),
+			errors.Is(err, protocol.ErrInvalidID),
+			errors.Is(err, protocol.ErrInvalidHistogram):
 			requestLogger.Debug("parsing failed", zap.Error(err))
 			w.WriteHeader(http.StatusBadRequest)
 			return
@@ -18,12 +18,16 @@ import (
 type Repository interface {
 	handlers.GaugeRepository
 	handlers.CounterRepository
+	handlers.HistogramRepository
 	handlers.AllMetricsRepository
+	handlers.HistoryRepository
 	logic.Repository
+	GRPCRepository
 }
 
 type Controller interface {
 	handlers.MetricController
+	handlers.MetricDeleter
 }
 
 type HTTPServer struct {
@@ -40,20 +44,26 @@ type middlewareFactory interface {
 func NewHTTP(
 	cfg Config,
 	repository Repository,
-	hashFactory middleware.HashFactory,
+	hashKeys middleware.HashKeys,
 	pingables []handlers.Pingable,
 	logger *zap.Logger,
 	decoder middleware.Decoder,
 	controller Controller,
+	alerts handlers.AlertsProvider,
+	agents handlers.AgentsProvider,
+	staleness handlers.StalenessChecker,
 ) (*HTTPServer, error) {
 	mux, err := createMux(
-		hashFactory,
+		hashKeys,
 		repository,
 		controller,
+		alerts,
+		agents,
+		staleness,
 		pingables,
 		logger,
 		decoder,
-		cfg.TrustedSubnet,
+		cfg,
 	)
 
 	if err != nil {
@@ -92,20 +102,24 @@ func (s *HTTPServer) Shutdown() error {
 }
 
 func createMux(
-	hashFactory middleware.HashFactory,
+	hashKeys middleware.HashKeys,
 	repository Repository,
 	controller Controller,
+	alerts handlers.AlertsProvider,
+	agents handlers.AgentsProvider,
+	staleness handlers.StalenessChecker,
 	pingables []handlers.Pingable,
 	logger *zap.Logger,
 	decoder middleware.Decoder,
-	trustedSubnet string,
+	cfg Config,
 ) (*chi.Mux, error) {
 	loggerMiddleware := middleware.NewLogger(logger)
+	agentIdentityMiddleware := middleware.NewAgentIdentity()
 
 	var subnetFilterMiddleware middlewareFactory
 
-	if trustedSubnet != "" {
-		mw, err := middleware.NewSubnetFilter(logger, trustedSubnet)
+	if cfg.TrustedSubnet != "" {
+		mw, err := middleware.NewSubnetFilter(logger, cfg.TrustedSubnet)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create subnet filter: %w", err)
 		}
@@ -124,13 +138,37 @@ func createMux(
 
 	var responseHashMiddleware middlewareFactory
 	var requestHashMiddleware middlewareFactory
-
-	if hashFactory != nil {
-		responseHashMiddleware = middleware.NewResponseHash(logger, hashFactory)
-		requestHashMiddleware = middleware.NewRequestHash(logger, hashFactory)
+	var signedRequestHashMiddleware middlewareFactory
+
+	if hashKeys != nil {
+		nonces := middleware.NewNonceCache(cfg.NonceCacheSize)
+		responseHashMiddleware = middleware.NewResponseHash(logger, hashKeys)
+		requestHashMiddleware = middleware.NewRequestHash(
+			logger,
+			hashKeys,
+			cfg.HashReplayWindow,
+			nonces,
+			middleware.SignatureOptional,
+		)
+		signedRequestHashMiddleware = middleware.NewRequestHash(
+			logger,
+			hashKeys,
+			cfg.HashReplayWindow,
+			nonces,
+			middleware.SignatureRequired,
+		)
 	} else {
 		responseHashMiddleware = middleware.NewNop()
 		requestHashMiddleware = middleware.NewNop()
+		signedRequestHashMiddleware = middleware.NewNop()
+	}
+
+	var idempotencyMiddleware middlewareFactory
+
+	if cfg.IdempotencyTTL > 0 {
+		idempotencyMiddleware = middleware.NewIdempotency(logger, cfg.IdempotencyTTL, cfg.IdempotencyCacheSize)
+	} else {
+		idempotencyMiddleware = middleware.NewNop()
 	}
 
 	requestDecompressMiddleware := middleware.NewRequestDecompressor(logger)
@@ -139,30 +177,69 @@ func createMux(
 	updateMetricPathParamsHandler := handlers.NewUpdateMetricPathParams(controller, logger)
 	updateMetricHandler := handlers.NewUpdateMetric(controller, logger)
 	updateMetricsHandler := handlers.NewUpdateMetrics(controller, logger)
-	getMetricValuePathParamsHandler := handlers.NewGetMetricValuePathParams(repository, repository, logger)
-	getMetricValueHandler := handlers.NewGetMetricValue(repository, repository, logger)
-	getAllMetricsHandler := handlers.NewGetAllMetrics(repository, logger)
+	deleteMetricHandler := handlers.NewDeleteMetric(controller, logger)
+	deleteMetricsHandler := handlers.NewDeleteMetrics(controller, logger)
+	getMetricValuePathParamsHandler := handlers.NewGetMetricValuePathParams(
+		repository,
+		repository,
+		repository,
+		staleness,
+		logger,
+	)
+	getMetricValueHandler := handlers.NewGetMetricValue(repository, repository, repository, staleness, logger)
+	getMetricHistoryHandler := handlers.NewGetMetricHistory(repository, repository, repository, logger)
+	getAllMetricsHandler := handlers.NewGetAllMetrics(repository, staleness, logger)
+	getPrometheusMetricsHandler := handlers.NewGetPrometheusMetrics(repository, staleness, logger)
+	getAlertsHandler := handlers.NewGetAlerts(alerts, logger)
+	getAgentsHandler := handlers.NewGetAgents(agents, logger)
 	pingHandler := handlers.NewPing(pingables, logger)
 
 	router := chi.NewRouter()
 
+	// Routes changing metrics accept only signed requests when hash keys are configured.
+	router.With(
+		loggerMiddleware.CreateHandler,
+		subnetFilterMiddleware.CreateHandler,
+		decryptMiddleware.CreateHandler,
+		signedRequestHashMiddleware.CreateHandler,
+		responseHashMiddleware.CreateHandler,
+	).Group(func(router chi.Router) {
+		// Idempotency-Key of batch update is its batch ID, controller applies batch once by it.
+		router.With(
+			requestDecompressMiddleware.CreateHandler,
+			agentIdentityMiddleware.CreateHandler,
+		).Post(protocol.UpdateMetricsURL, updateMetricsHandler.ServeHTTP)
+		router.With(
+			idempotencyMiddleware.CreateHandler,
+			requestDecompressMiddleware.CreateHandler,
+			agentIdentityMiddleware.CreateHandler,
+		).Group(func(router chi.Router) {
+			router.Post(protocol.UpdateMetricURL, updateMetricHandler.ServeHTTP)
+			router.Post(protocol.UpdateMetricPathParamsURL, updateMetricPathParamsHandler.ServeHTTP)
+			router.Delete(protocol.DeleteMetricPathParamsURL, deleteMetricHandler.ServeHTTP)
+			router.Delete(protocol.DeleteMetricsURL, deleteMetricsHandler.ServeHTTP)
+		})
+	})
 	router.With(
 		loggerMiddleware.CreateHandler,
 		subnetFilterMiddleware.CreateHandler,
 		decryptMiddleware.CreateHandler,
 		requestHashMiddleware.CreateHandler,
 		responseHashMiddleware.CreateHandler,
+		idempotencyMiddleware.CreateHandler,
 		requestDecompressMiddleware.CreateHandler,
-	).Route("/", func(router chi.Router) {
-		router.Post(protocol.UpdateMetricURL, updateMetricHandler.ServeHTTP)
-		router.Post(protocol.UpdateMetricsURL, updateMetricsHandler.ServeHTTP)
-		router.Post(protocol.UpdateMetricPathParamsURL, updateMetricPathParamsHandler.ServeHTTP)
+		agentIdentityMiddleware.CreateHandler,
+	).Group(func(router chi.Router) {
 		router.Get(protocol.PingURL, pingHandler.ServeHTTP)
 		router.With(responseCompressMiddleware.CreateHandler).
-			Route("/", func(router chi.Router) {
+			Group(func(router chi.Router) {
 				router.Post(protocol.GetMetricURL, getMetricValueHandler.ServeHTTP)
 				router.Get(protocol.GetMetricPathParamsURL, getMetricValuePathParamsHandler.ServeHTTP)
+				router.Get(protocol.GetMetricHistoryURL, getMetricHistoryHandler.ServeHTTP)
 				router.Get(protocol.GetAllMetricsURL, getAllMetricsHandler.ServeHTTP)
+				router.Get(protocol.PrometheusMetricsURL, getPrometheusMetricsHandler.ServeHTTP)
+				router.Get(protocol.AlertsURL, getAlertsHandler.ServeHTTP)
+				router.Get(protocol.AgentsURL, getAgentsHandler.ServeHTTP)
 			})
 	})
 
@@ -3,15 +3,21 @@ package server
 import (
 	"go-metrics-service/internal/common/logging"
 	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/alerting"
+	"go-metrics-service/internal/server/audit"
 	"go-metrics-service/internal/server/controllers"
+	"go-metrics-service/internal/server/data"
 	"go-metrics-service/internal/server/data/repositories/memrepository"
 	"go-metrics-service/internal/server/data/storages"
 	"go-metrics-service/internal/server/data/storages/memstorage"
 	"go-metrics-service/internal/server/handlers"
 	"go-metrics-service/internal/server/logic"
+	"go-metrics-service/internal/server/watch"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,20 +26,36 @@ import (
 )
 
 func setupServer() (*httptest.Server, error) {
+	return setupServerWith(controllers.Config{}, Config{})
+}
+
+func setupServerWith(cfg controllers.Config, serverCfg Config) (*httptest.Server, error) {
 	logger := logging.CreateZapLogger(true)
 	memStorage := memstorage.New(logger)
-	memRepository := memrepository.New(memStorage, logger)
+	memRepository := memrepository.New(memStorage, data.HistoryConfig{}, logger)
 	transactionManager := storages.NewDummyTransactionsManager()
 	service := logic.NewService(memRepository, logger)
-	controller := controllers.NewController(transactionManager, service, logger)
+	agentsRegistry := agents.New(0, 0)
+	controller := controllers.NewController(
+		cfg,
+		transactionManager,
+		service,
+		agentsRegistry,
+		audit.New(nil, logger),
+		watch.New(0),
+		logger,
+	)
 	mux, err := createMux(
 		nil,
 		memRepository,
 		controller,
+		alerting.NewEngine(alerting.Config{}, memRepository, nil, logger),
+		agentsRegistry,
+		logic.NewExpiry(data.ExpiryConfig{}, memRepository, logger),
 		make([]handlers.Pingable, 0),
 		logger,
 		nil,
-		"",
+		serverCfg,
 	)
 	if err != nil {
 		return nil, err
@@ -283,3 +305,83 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+// postFromAgents reports counter delta from every agent.
+func postFromAgents(t *testing.T, serverURL string, deltas map[string]string) {
+	t.Helper()
+	for agentID, delta := range deltas {
+		resp, err := resty.New().R().
+			SetHeader(protocol.AgentIDHeader, agentID).
+			SetHeader(protocol.RealIPHeader, "10.0.0.1").
+			SetHeader("Content-Type", "application/json").
+			SetBody(`{"id":"PollCount","type":"counter","delta":` + delta + `}`).
+			Post(serverURL + protocol.UpdateMetricURL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+	}
+}
+
+func TestAgents(t *testing.T) {
+	server, err := setupServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	postFromAgents(t, server.URL, map[string]string{"a": "3", "b": "5"})
+
+	resp, err := resty.New().R().Get(server.URL + "/value/counter/PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "8", string(resp.Body()))
+
+	var agentsList []agents.Agent
+	resp, err = resty.New().R().SetResult(&agentsList).Get(server.URL + protocol.AgentsURL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+	require.Len(t, agentsList, 2)
+	assert.Equal(t, "a", agentsList[0].ID)
+	assert.Equal(t, "10.0.0.1", agentsList[0].IP)
+	assert.Equal(t, 1, agentsList[0].MetricsCount)
+	assert.Equal(t, "b", agentsList[1].ID)
+}
+
+func TestAgentsInstanceLabel(t *testing.T) {
+	server, err := setupServerWith(controllers.Config{InstanceLabel: true}, Config{})
+	require.NoError(t, err)
+	defer server.Close()
+
+	postFromAgents(t, server.URL, map[string]string{"a": "3", "b": "5"})
+
+	resp, err := resty.New().R().
+		SetQueryParam(protocol.InstanceLabel, "b").
+		Get(server.URL + "/value/counter/PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "5", string(resp.Body()))
+
+	// Every agent has its own series, unlabelled series was never reported.
+	resp, err = resty.New().R().Get(server.URL + "/value/counter/PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+}
+
+func TestRetriedBatchAppliedOnce(t *testing.T) {
+	server, err := setupServerWith(controllers.Config{}, Config{IdempotencyTTL: time.Minute, IdempotencyCacheSize: 10})
+	require.NoError(t, err)
+	defer server.Close()
+
+	for range 2 {
+		resp, err := resty.New().R().
+			SetHeader(protocol.AgentIDHeader, "a").
+			SetHeader(protocol.IdempotencyKeyHeader, "batch").
+			SetHeader("Content-Type", "application/json").
+			SetBody(`[{"id":"PollCount","type":"counter","delta":3}]`).
+			Post(server.URL + protocol.UpdateMetricsURL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+	}
+
+	resp, err := resty.New().R().Get(server.URL + "/value/counter/PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "3", string(resp.Body()))
+}
@@ -0,0 +1,2 @@
+// Package interceptors contains gRPC server interceptors matching HTTP middleware
+package interceptors
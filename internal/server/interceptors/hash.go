@@ -0,0 +1,239 @@
+package interceptors
+
+import (
+	"context"
+	"go-metrics-service/internal/common/hashing"
+	"go-metrics-service/internal/common/protocol"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	errHashMismatch = status.Error(codes.Unauthenticated, "hash mismatch")
+	errUnsigned     = status.Error(codes.Unauthenticated, "call is not signed")
+	errKeyIdentity  = status.Error(codes.Unauthenticated, "hash key ID does not match agent ID")
+	errReplayed     = status.Error(codes.Unauthenticated, "replayed or expired call")
+	errHashing      = status.Error(codes.Internal, "failed to calculate hash")
+)
+
+// Hash verifies HMAC of requests and signs responses. Unary calls carry hash, timestamp
+// and nonce in metadata, streams carry them in metadata of the opening call over the method name,
+// and stream messages implementing hashing.SignedMessage carry their own.
+// Signature policy is the same as of HTTP routes: calls of signed methods, changing metrics,
+// are rejected unsigned, other calls are served unsigned but verified when signed.
+// Calls signed too long ago or carrying seen nonce are rejected as replayed.
+// Keys are selected by x-hash-key-id metadata, agent key is accepted only from agent with the same ID.
+type Hash struct {
+	keys          HashKeys
+	nonces        NonceStore
+	logger        *zap.Logger
+	now           func() time.Time
+	signedMethods map[string]struct{}
+	replayWindow  time.Duration
+}
+
+// HashKeys selects HMAC keys by key ID.
+type HashKeys interface {
+	// Verifiers returns keys accepted for key ID.
+	Verifiers(keyID string) []hashing.Factory
+	// Signer returns key to sign response with.
+	Signer(keyID string) (hashing.Factory, bool)
+}
+
+// NonceStore remembers nonces of accepted calls.
+type NonceStore interface {
+	// Add records nonce valid until expiresAt, false is returned for nonce which must be rejected.
+	Add(nonce string, expiresAt, now time.Time) bool
+}
+
+// NewHash creates interceptors rejecting unsigned calls of signedMethods, full method names are expected.
+func NewHash(
+	logger *zap.Logger,
+	keys HashKeys,
+	replayWindow time.Duration,
+	nonces NonceStore,
+	signedMethods []string,
+) *Hash {
+	signed := make(map[string]struct{}, len(signedMethods))
+	for _, method := range signedMethods {
+		signed[method] = struct{}{}
+	}
+	return &Hash{
+		keys:          keys,
+		nonces:        nonces,
+		logger:        logger,
+		now:           time.Now,
+		signedMethods: signed,
+		replayWindow:  replayWindow,
+	}
+}
+
+func (h *Hash) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		keyID, err := h.keyID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		message, ok := req.(proto.Message)
+		if !ok {
+			return nil, errHashing
+		}
+		err = h.verifyCall(ctx, keyID, info.FullMethod, func(factory hashing.Factory, timestamp, nonce string) (string, error) {
+			return hashing.MessageRequestSum(factory, timestamp, nonce, message)
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		signer, ok := h.keys.Signer(keyID)
+		if !ok {
+			return resp, nil
+		}
+		if message, ok := resp.(proto.Message); ok {
+			sum, err := hashing.MessageHash(signer, message)
+			if err != nil {
+				h.logger.Error("failed to calculate response hash", zap.Error(err))
+				return nil, errHashing
+			}
+			if err := grpc.SetHeader(ctx, metadata.Pairs(protocol.HashMetadata, sum)); err != nil {
+				h.logger.Error("failed to set response hash", zap.Error(err))
+			}
+		}
+		return resp, nil
+	}
+}
+
+func (h *Hash) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		keyID, err := h.keyID(ss.Context())
+		if err != nil {
+			return err
+		}
+		err = h.verifyCall(ss.Context(), keyID, info.FullMethod, func(factory hashing.Factory, timestamp, nonce string) (string, error) {
+			return hashing.StreamSum(factory, timestamp, nonce, info.FullMethod)
+		})
+		if err != nil {
+			return err
+		}
+		signer, _ := h.keys.Signer(keyID)
+		return handler(srv, &hashedServerStream{
+			ServerStream: ss,
+			hash:         h,
+			verifiers:    h.keys.Verifiers(keyID),
+			signer:       signer,
+		})
+	}
+}
+
+// keyID returns key ID of the call, agent key is tied to agent identity.
+func (h *Hash) keyID(ctx context.Context) (string, error) {
+	keyID := metadataValue(ctx, protocol.HashKeyIDMetadata)
+	if keyID != "" && keyID != metadataValue(ctx, protocol.AgentIDMetadata) {
+		h.logger.Warn("hash key ID does not match agent ID", zap.String("key_id", keyID))
+		return "", errKeyIdentity
+	}
+	return keyID, nil
+}
+
+// verifyCall checks hash of call metadata calculated by sum. Unsigned call is rejected only for signed methods.
+func (h *Hash) verifyCall(
+	ctx context.Context,
+	keyID, method string,
+	sum func(factory hashing.Factory, timestamp, nonce string) (string, error),
+) error {
+	received := metadataValue(ctx, protocol.HashMetadata)
+	if received == "" {
+		if _, ok := h.signedMethods[method]; ok {
+			return errUnsigned
+		}
+		return nil
+	}
+	timestamp := metadataValue(ctx, protocol.TimestampMetadata)
+	nonce := metadataValue(ctx, protocol.NonceMetadata)
+	valid, err := hashing.MatchAny(h.keys.Verifiers(keyID), received, func(factory hashing.Factory) (string, error) {
+		return sum(factory, timestamp, nonce)
+	})
+	if err != nil {
+		h.logger.Error("failed to calculate request hash", zap.Error(err))
+		return errHashing
+	}
+	if !valid {
+		return errHashMismatch
+	}
+	return h.checkReplay(timestamp, nonce)
+}
+
+// checkReplay rejects calls signed out of replay window or carrying seen nonce. It is called
+// only after hash check, so forged calls can not fill the nonce store.
+func (h *Hash) checkReplay(timestamp, nonce string) error {
+	if timestamp == "" || nonce == "" {
+		h.logger.Debug("signed call without timestamp or nonce")
+		return errReplayed
+	}
+	signedAt, err := hashing.ParseTimestamp(timestamp)
+	if err != nil {
+		h.logger.Debug("invalid call timestamp", zap.Error(err))
+		return errReplayed
+	}
+	now := h.now()
+	if age := now.Sub(signedAt); age > h.replayWindow || age < -h.replayWindow {
+		h.logger.Debug("call timestamp is out of replay window", zap.Time("signed_at", signedAt))
+		return errReplayed
+	}
+	if !h.nonces.Add(nonce, signedAt.Add(h.replayWindow), now) {
+		h.logger.Warn("replayed call rejected", zap.String("nonce", nonce))
+		return errReplayed
+	}
+	return nil
+}
+
+type hashedServerStream struct {
+	grpc.ServerStream
+	hash      *Hash
+	signer    hashing.Factory
+	verifiers []hashing.Factory
+}
+
+func (s *hashedServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err //nolint:wrapcheck // stream error is returned as is
+	}
+	signed, ok := m.(hashing.SignedMessage)
+	if !ok {
+		return nil
+	}
+	if signed.GetHash() == "" {
+		return errUnsigned
+	}
+	valid, err := hashing.VerifyAny(s.verifiers, signed)
+	if err != nil {
+		s.hash.logger.Error("failed to calculate message hash", zap.Error(err))
+		return errHashing
+	}
+	if !valid {
+		return errHashMismatch
+	}
+	if stamped, ok := m.(hashing.StampedMessage); ok {
+		return s.hash.checkReplay(stamped.GetTimestamp(), stamped.GetNonce())
+	}
+	return nil
+}
+
+func (s *hashedServerStream) SendMsg(m any) error {
+	if signed, ok := m.(hashing.SignedMessage); ok && s.signer != nil {
+		if err := hashing.Sign(s.signer, signed); err != nil {
+			s.hash.logger.Error("failed to sign message", zap.Error(err))
+			return errHashing
+		}
+	}
+	return s.ServerStream.SendMsg(m) //nolint:wrapcheck // stream error is returned as is
+}
@@ -0,0 +1,333 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"go-metrics-service/internal/common/hashing"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/middleware"
+	pb "go-metrics-service/proto"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type echoUpdatesServer struct {
+	pb.UnimplementedUpdateMetricsServer
+}
+
+func (echoUpdatesServer) UpdateMetrics(context.Context, *pb.UpdateMetricsRequest) (*pb.UpdateMetricsResponse, error) {
+	return &pb.UpdateMetricsResponse{}, nil
+}
+
+func (echoUpdatesServer) StreamUpdates(stream grpc.BidiStreamingServer[pb.MetricsBatch, pb.MetricsBatchAck]) error {
+	for {
+		batch, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		sequence := batch.GetSequence()
+		if err := stream.Send(pb.MetricsBatchAck_builder{Sequence: &sequence}.Build()); err != nil {
+			return err
+		}
+	}
+}
+
+type emptyMetricsServer struct {
+	pb.UnimplementedMetricsServer
+}
+
+func (emptyMetricsServer) WatchMetrics(*pb.WatchMetricsRequest, grpc.ServerStreamingServer[pb.Metric]) error {
+	return nil
+}
+
+// setupClient serves on loopback, so subnet filter sees peer address 127.0.0.1.
+func setupClient(t *testing.T, keys []hashing.Key, trustedSubnet string) (pb.UpdateMetricsClient, pb.MetricsClient) {
+	t.Helper()
+	logger := zap.NewNop()
+	subnetFilter, err := NewSubnetFilter(logger, trustedSubnet)
+	require.NoError(t, err)
+	keyring, err := hashing.NewKeyring(keys)
+	require.NoError(t, err)
+	hash := NewHash(
+		logger,
+		keyring,
+		time.Minute,
+		middleware.NewNonceCache(100),
+		[]string{pb.UpdateMetrics_UpdateMetrics_FullMethodName, pb.UpdateMetrics_StreamUpdates_FullMethodName},
+	)
+	loggerInterceptor := NewLogger(logger)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(loggerInterceptor.Unary(), subnetFilter.Unary(), hash.Unary()),
+		grpc.ChainStreamInterceptor(loggerInterceptor.Stream(), subnetFilter.Stream(), hash.Stream()),
+	)
+	pb.RegisterUpdateMetricsServer(server, echoUpdatesServer{})
+	pb.RegisterMetricsServer(server, emptyMetricsServer{})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return pb.NewUpdateMetricsClient(conn), pb.NewMetricsClient(conn)
+}
+
+// signedMetadata returns metadata of unary call signed with secret at signedAt.
+func signedMetadata(t *testing.T, secret, nonce string, signedAt time.Time, message proto.Message) []string {
+	t.Helper()
+	timestamp := hashing.FormatTimestamp(signedAt)
+	sum, err := hashing.MessageRequestSum(hashing.NewHMAC(secret), timestamp, nonce, message)
+	require.NoError(t, err)
+	return []string{protocol.HashMetadata, sum, protocol.TimestampMetadata, timestamp, protocol.NonceMetadata, nonce}
+}
+
+// signedStream returns context of stream opening signed with secret.
+func signedStream(t *testing.T, secret, nonce, method string) context.Context {
+	t.Helper()
+	timestamp := hashing.FormatTimestamp(time.Now())
+	sum, err := hashing.StreamSum(hashing.NewHMAC(secret), timestamp, nonce, method)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(
+		context.Background(),
+		protocol.HashMetadata, sum,
+		protocol.TimestampMetadata, timestamp,
+		protocol.NonceMetadata, nonce,
+	)
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	hashFactory := hashing.NewHMAC("secret")
+	agentFactory := hashing.NewHMAC("agent secret")
+	client, _ := setupClient(t, []hashing.Key{
+		{Secret: "secret"},
+		{ID: "agent", Secret: "agent secret"},
+		{ID: "agent", Secret: "old agent secret", ExpiresAt: time.Now().Add(time.Hour)},
+	}, "127.0.0.0/8")
+	request := &pb.UpdateMetricsRequest{}
+	now := time.Now()
+	agentKey := []string{protocol.HashKeyIDMetadata, "agent", protocol.AgentIDMetadata, "agent"}
+
+	tests := []struct {
+		name   string
+		md     []string
+		signer hashing.Factory
+		code   codes.Code
+	}{
+		{name: "signed", md: signedMetadata(t, "secret", "n1", now, request), code: codes.OK},
+		{name: "unsigned", md: []string{}, code: codes.Unauthenticated},
+		{name: "wrong hash", md: []string{protocol.HashMetadata, "00"}, code: codes.Unauthenticated},
+		{name: "old timestamp", md: signedMetadata(t, "secret", "n2", now.Add(-2*time.Minute), request), code: codes.Unauthenticated},
+		{name: "no nonce", md: signedMetadata(t, "secret", "", now, request), code: codes.Unauthenticated},
+		{
+			name:   "agent key",
+			md:     append(signedMetadata(t, "agent secret", "n3", now, request), agentKey...),
+			signer: agentFactory,
+			code:   codes.OK,
+		},
+		{
+			name:   "retired agent key in grace period",
+			md:     append(signedMetadata(t, "old agent secret", "n4", now, request), agentKey...),
+			signer: agentFactory,
+			code:   codes.OK,
+		},
+		{
+			name: "agent key of other agent",
+			md: append(
+				signedMetadata(t, "agent secret", "n5", now, request),
+				protocol.HashKeyIDMetadata, "agent", protocol.AgentIDMetadata, "other",
+			),
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "shared key under agent key ID",
+			md:   append(signedMetadata(t, "secret", "n6", now, request), agentKey...),
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "unknown key ID",
+			md: append(
+				signedMetadata(t, "secret", "n7", now, request),
+				protocol.HashKeyIDMetadata, "other", protocol.AgentIDMetadata, "other",
+			),
+			code: codes.Unauthenticated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), tt.md...)
+			var header metadata.MD
+			response, err := client.UpdateMetrics(ctx, request, grpc.Header(&header))
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.code != codes.OK {
+				return
+			}
+			signer := tt.signer
+			if signer == nil {
+				signer = hashFactory
+			}
+			expected, err := hashing.MessageHash(signer, response)
+			require.NoError(t, err)
+			assert.Equal(t, []string{expected}, header.Get(protocol.HashMetadata))
+
+			_, err = client.UpdateMetrics(ctx, request)
+			assert.Equal(t, codes.Unauthenticated, status.Code(err), "replayed call accepted")
+		})
+	}
+}
+
+func TestSubnetFilter(t *testing.T) {
+	client, _ := setupClient(t, []hashing.Key{{Secret: "secret"}}, "10.0.0.0/8")
+	request := &pb.UpdateMetricsRequest{}
+	// Address claimed in metadata is ignored, loopback peer is outside trusted subnet.
+	md := append(signedMetadata(t, "secret", "n1", time.Now(), request), protocol.RealIPMetadata, "10.1.2.3")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), md...)
+	_, err := client.UpdateMetrics(ctx, request)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	hashFactory := hashing.NewHMAC("secret")
+	client, _ := setupClient(t, []hashing.Key{{Secret: "secret"}}, "127.0.0.0/8")
+
+	send := func(t *testing.T, stream grpc.BidiStreamingClient[pb.MetricsBatch, pb.MetricsBatchAck], batch *pb.MetricsBatch) error {
+		t.Helper()
+		require.NoError(t, stream.Send(batch))
+		ack, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, batch.GetSequence(), ack.GetSequence())
+		valid, err := hashing.Verify(hashFactory, ack)
+		require.NoError(t, err)
+		assert.True(t, valid)
+		return nil
+	}
+
+	stream, err := client.StreamUpdates(signedStream(t, "secret", "open1", pb.UpdateMetrics_StreamUpdates_FullMethodName))
+	require.NoError(t, err)
+	batch := pb.MetricsBatch_builder{Sequence: ptr(uint64(1))}.Build()
+	require.NoError(t, hashing.Stamp(batch, time.Now()))
+	require.NoError(t, hashing.Sign(hashFactory, batch))
+	require.NoError(t, send(t, stream, batch))
+	replayed := proto.Clone(batch).(*pb.MetricsBatch)
+	assert.Equal(t, codes.Unauthenticated, status.Code(send(t, stream, replayed)))
+
+	tests := []struct {
+		name  string
+		batch func() *pb.MetricsBatch
+	}{
+		{
+			name: "opened unsigned",
+			batch: func() *pb.MetricsBatch {
+				batch := pb.MetricsBatch_builder{Sequence: ptr(uint64(1))}.Build()
+				require.NoError(t, hashing.Stamp(batch, time.Now()))
+				require.NoError(t, hashing.Sign(hashFactory, batch))
+				return batch
+			},
+		},
+		{
+			name: "forged",
+			batch: func() *pb.MetricsBatch {
+				return pb.MetricsBatch_builder{Sequence: ptr(uint64(1)), Hash: ptr("00")}.Build()
+			},
+		},
+		{
+			name: "unsigned",
+			batch: func() *pb.MetricsBatch {
+				batch := pb.MetricsBatch_builder{Sequence: ptr(uint64(1))}.Build()
+				require.NoError(t, hashing.Stamp(batch, time.Now()))
+				return batch
+			},
+		},
+		{
+			name: "not stamped",
+			batch: func() *pb.MetricsBatch {
+				batch := pb.MetricsBatch_builder{Sequence: ptr(uint64(1))}.Build()
+				require.NoError(t, hashing.Sign(hashFactory, batch))
+				return batch
+			},
+		},
+		{
+			name: "old timestamp",
+			batch: func() *pb.MetricsBatch {
+				batch := pb.MetricsBatch_builder{Sequence: ptr(uint64(1))}.Build()
+				require.NoError(t, hashing.Stamp(batch, time.Now().Add(-2*time.Minute)))
+				require.NoError(t, hashing.Sign(hashFactory, batch))
+				return batch
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if i > 0 {
+				ctx = signedStream(t, "secret", fmt.Sprintf("open%d", i+2), pb.UpdateMetrics_StreamUpdates_FullMethodName)
+			}
+			stream, err := client.StreamUpdates(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, codes.Unauthenticated, status.Code(send(t, stream, tt.batch())))
+		})
+	}
+}
+
+func TestReadStreamSignature(t *testing.T) {
+	_, client := setupClient(t, []hashing.Key{{Secret: "secret"}, {ID: "agent", Secret: "agent secret"}}, "127.0.0.0/8")
+	method := pb.Metrics_WatchMetrics_FullMethodName
+
+	tests := []struct {
+		ctx  context.Context
+		name string
+		code codes.Code
+	}{
+		{name: "unsigned", ctx: context.Background(), code: codes.OK},
+		{name: "signed", ctx: signedStream(t, "secret", "n1", method), code: codes.OK},
+		{name: "forged", ctx: signedStream(t, "other secret", "n2", method), code: codes.Unauthenticated},
+		{
+			name: "signed for other method",
+			ctx:  signedStream(t, "secret", "n3", pb.UpdateMetrics_StreamUpdates_FullMethodName),
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "agent key of other agent",
+			ctx: metadata.AppendToOutgoingContext(
+				signedStream(t, "agent secret", "n4", method),
+				protocol.HashKeyIDMetadata, "agent", protocol.AgentIDMetadata, "other",
+			),
+			code: codes.Unauthenticated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.WatchMetrics(tt.ctx, &pb.WatchMetricsRequest{})
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			if tt.code == codes.OK {
+				assert.ErrorIs(t, err, io.EOF)
+				return
+			}
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
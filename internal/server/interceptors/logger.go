@@ -0,0 +1,48 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+type Logger struct {
+	logger *zap.Logger
+}
+
+func NewLogger(logger *zap.Logger) *Logger {
+	return &Logger{
+		logger: logger,
+	}
+}
+
+func (l *Logger) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		l.log(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func (l *Logger) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		l.log(ss.Context(), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func (l *Logger) log(ctx context.Context, method string, start time.Time, err error) {
+	l.logger.Info(
+		"Call handled",
+		zap.String("method", method),
+		zap.String("peer", PeerIP(ctx)),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("code", status.Code(err).String()),
+	)
+}
@@ -0,0 +1,58 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SubnetFilter rejects calls from clients outside trusted subnet. Client address is taken
+// from connection, x-real-ip metadata is set by client and can not be trusted.
+type SubnetFilter struct {
+	trustedSubnet *net.IPNet
+	logger        *zap.Logger
+}
+
+func NewSubnetFilter(logger *zap.Logger, trustedSubnet string) (*SubnetFilter, error) {
+	_, ipNet, err := net.ParseCIDR(trustedSubnet)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing trusted subnet: %w", err)
+	}
+	return &SubnetFilter{
+		trustedSubnet: ipNet,
+		logger:        logger,
+	}, nil
+}
+
+func (sf *SubnetFilter) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := sf.check(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (sf *SubnetFilter) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := sf.check(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (sf *SubnetFilter) check(ctx context.Context) error {
+	ipStr := PeerIP(ctx)
+	ip := net.ParseIP(ipStr)
+	if ip == nil || !sf.trustedSubnet.Contains(ip) {
+		sf.logger.Debug("call from untrusted address", zap.String("ip", ipStr))
+		return status.Error(codes.PermissionDenied, "address is not trusted") //nolint:wrapcheck // status error
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/data"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var ErrEmptyFilter = errors.New("empty delete filter")
+
+type DeletionRepository interface {
+	// GetType returns protocol type of series stored under key.
+	GetType(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, keys []string) ([]string, error)
+}
+
+// DeleteFilter selects series by metric ID. Series with any labels match when ID matches.
+// Empty Type matches every metric type.
+type DeleteFilter struct {
+	Pattern *regexp.Regexp
+	Type    string
+	Prefix  string
+}
+
+func (f *DeleteFilter) String() string {
+	parts := make([]string, 0)
+	if f.Type != "" {
+		parts = append(parts, "type="+f.Type)
+	}
+	if f.Prefix != "" {
+		parts = append(parts, "prefix="+f.Prefix)
+	}
+	if f.Pattern != nil {
+		parts = append(parts, "regex="+f.Pattern.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f *DeleteFilter) matches(id, metricType string) bool {
+	if f.Type != "" && f.Type != metricType {
+		return false
+	}
+	if f.Prefix != "" && !strings.HasPrefix(id, f.Prefix) {
+		return false
+	}
+	return f.Pattern == nil || f.Pattern.MatchString(id)
+}
+
+// DeleteMetric removes series of metricType stored under key.
+func (s *Service) DeleteMetric(ctx context.Context, metricType, key string) error {
+	storedType, err := s.r.GetType(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to get metric type: %w", err)
+	}
+	if storedType != metricType {
+		return data.ErrWrongType
+	}
+	deleted, err := s.r.Delete(ctx, []string{key})
+	if err != nil {
+		return fmt.Errorf("failed to delete metric: %w", err)
+	}
+	if len(deleted) == 0 {
+		return data.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteMetrics removes every series matching filter and returns sorted removed keys.
+func (s *Service) DeleteMetrics(ctx context.Context, filter *DeleteFilter) ([]string, error) {
+	if filter.Prefix == "" && filter.Pattern == nil {
+		return nil, ErrEmptyFilter
+	}
+	values, err := s.r.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
+	keys := make([]string, 0)
+	for key, value := range values {
+		id, _, err := protocol.ParseSeriesKey(key)
+		if err != nil {
+			s.l.Debug("skipping unparsable series key", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		if filter.matches(id, MetricType(value)) {
+			keys = append(keys, key)
+		}
+	}
+	deleted, err := s.r.Delete(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete metrics: %w", err)
+	}
+	sort.Strings(deleted)
+	return deleted, nil
+}
+
+// MetricType returns protocol type of stored value.
+func MetricType(value any) string {
+	switch value.(type) {
+	case int64:
+		return protocol.Counter
+	case float64:
+		return protocol.Gauge
+	case protocol.HistogramValue:
+		return protocol.Histogram
+	default:
+		return ""
+	}
+}
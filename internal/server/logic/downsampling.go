@@ -0,0 +1,142 @@
+package logic
+
+import (
+	"errors"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/data"
+	"slices"
+	"time"
+)
+
+var ErrWrongAggregation = errors.New("wrong aggregation")
+
+// DefaultAggregation returns aggregation used for metric type when none requested.
+func DefaultAggregation(metricType string) string {
+	if metricType == protocol.Counter {
+		return protocol.AggregationIncrease
+	}
+	return protocol.AggregationAvg
+}
+
+// Downsample groups samples ordered by timestamp into step-long buckets starting at from
+// and reduces every non-empty bucket to one sample stamped with bucket start.
+// Gauges support avg/min/max/last, counters support rate/increase.
+// Samples before from are not reported, the last of them is the base of counter increase in the first bucket.
+func Downsample(
+	samples []data.Sample,
+	metricType string,
+	from time.Time,
+	step time.Duration,
+	aggregation string,
+) ([]data.Sample, error) {
+	reduce, err := reducerFor(metricType, aggregation, step)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]data.Sample, 0)
+	var prev *data.Sample
+	start := 0
+	for start < len(samples) && samples[start].Timestamp.Before(from) {
+		prev = &samples[start]
+		start++
+	}
+	for start < len(samples) {
+		bucket := samples[start].Timestamp.Sub(from) / step
+		end := start + 1
+		for end < len(samples) && samples[end].Timestamp.Sub(from)/step == bucket {
+			end++
+		}
+		value, err := reduce(prev, samples[start:end])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, data.Sample{
+			Timestamp: from.Add(bucket * step),
+			Value:     value,
+		})
+		prev = &samples[end-1]
+		start = end
+	}
+	return res, nil
+}
+
+type reducer func(prev *data.Sample, bucket []data.Sample) (any, error)
+
+func reducerFor(metricType, aggregation string, step time.Duration) (reducer, error) {
+	switch metricType {
+	case protocol.Gauge:
+		switch aggregation {
+		case protocol.AggregationAvg:
+			return reduceGauges(func(values []float64) float64 {
+				sum := 0.0
+				for _, v := range values {
+					sum += v
+				}
+				return sum / float64(len(values))
+			}), nil
+		case protocol.AggregationMin:
+			return reduceGauges(func(values []float64) float64 {
+				return slices.Min(values)
+			}), nil
+		case protocol.AggregationMax:
+			return reduceGauges(func(values []float64) float64 {
+				return slices.Max(values)
+			}), nil
+		case protocol.AggregationLast:
+			return reduceGauges(func(values []float64) float64 {
+				return values[len(values)-1]
+			}), nil
+		default:
+			return nil, ErrWrongAggregation
+		}
+	case protocol.Counter:
+		switch aggregation {
+		case protocol.AggregationIncrease:
+			return func(prev *data.Sample, bucket []data.Sample) (any, error) {
+				return counterIncrease(prev, bucket)
+			}, nil
+		case protocol.AggregationRate:
+			return func(prev *data.Sample, bucket []data.Sample) (any, error) {
+				increase, err := counterIncrease(prev, bucket)
+				if err != nil {
+					return nil, err
+				}
+				return float64(increase) / step.Seconds(), nil
+			}, nil
+		default:
+			return nil, ErrWrongAggregation
+		}
+	default:
+		return nil, ErrWrongAggregation
+	}
+}
+
+func reduceGauges(f func(values []float64) float64) reducer {
+	return func(_ *data.Sample, bucket []data.Sample) (any, error) {
+		values := make([]float64, len(bucket))
+		for i, sample := range bucket {
+			value, ok := sample.Value.(float64)
+			if !ok {
+				return nil, data.ErrWrongType
+			}
+			values[i] = value
+		}
+		return f(values), nil
+	}
+}
+
+func counterIncrease(prev *data.Sample, bucket []data.Sample) (int64, error) {
+	base := bucket[0]
+	if prev != nil {
+		base = *prev
+	}
+	first, ok := base.Value.(int64)
+	if !ok {
+		return 0, data.ErrWrongType
+	}
+	last, ok := bucket[len(bucket)-1].Value.(int64)
+	if !ok {
+		return 0, data.ErrWrongType
+	}
+	return last - first, nil
+}
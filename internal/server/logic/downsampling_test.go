@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/data"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsample(t *testing.T) {
+	from := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return from.Add(time.Duration(seconds) * time.Second)
+	}
+	gauges := []data.Sample{
+		{Timestamp: at(0), Value: 1.0},
+		{Timestamp: at(10), Value: 3.0},
+		{Timestamp: at(70), Value: 5.0},
+	}
+	counters := []data.Sample{
+		{Timestamp: at(0), Value: int64(10)},
+		{Timestamp: at(30), Value: int64(16)},
+		{Timestamp: at(90), Value: int64(46)},
+	}
+	tests := []struct {
+		name        string
+		samples     []data.Sample
+		metricType  string
+		aggregation string
+		expected    []any
+	}{
+		{
+			name:        "gauge avg",
+			samples:     gauges,
+			metricType:  protocol.Gauge,
+			aggregation: protocol.AggregationAvg,
+			expected:    []any{2.0, 5.0},
+		},
+		{
+			name:        "gauge min",
+			samples:     gauges,
+			metricType:  protocol.Gauge,
+			aggregation: protocol.AggregationMin,
+			expected:    []any{1.0, 5.0},
+		},
+		{
+			name:        "gauge max",
+			samples:     gauges,
+			metricType:  protocol.Gauge,
+			aggregation: protocol.AggregationMax,
+			expected:    []any{3.0, 5.0},
+		},
+		{
+			name:        "gauge last",
+			samples:     gauges,
+			metricType:  protocol.Gauge,
+			aggregation: protocol.AggregationLast,
+			expected:    []any{3.0, 5.0},
+		},
+		{
+			name:        "counter increase",
+			samples:     counters,
+			metricType:  protocol.Counter,
+			aggregation: protocol.AggregationIncrease,
+			expected:    []any{int64(6), int64(30)},
+		},
+		{
+			name:        "counter rate",
+			samples:     counters,
+			metricType:  protocol.Counter,
+			aggregation: protocol.AggregationRate,
+			expected:    []any{0.1, 0.5},
+		},
+		{
+			name:        "counter increase from sample before range",
+			samples:     append([]data.Sample{{Timestamp: at(-30), Value: int64(4)}}, counters...),
+			metricType:  protocol.Counter,
+			aggregation: protocol.AggregationIncrease,
+			expected:    []any{int64(12), int64(30)},
+		},
+		{
+			name:        "gauge avg ignores sample before range",
+			samples:     append([]data.Sample{{Timestamp: at(-30), Value: 100.0}}, gauges...),
+			metricType:  protocol.Gauge,
+			aggregation: protocol.AggregationAvg,
+			expected:    []any{2.0, 5.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Downsample(tt.samples, tt.metricType, from, time.Minute, tt.aggregation)
+			require.NoError(t, err)
+			require.Len(t, res, len(tt.expected))
+			for i, sample := range res {
+				assert.Equal(t, from.Add(time.Duration(i)*time.Minute), sample.Timestamp)
+				assert.Equal(t, tt.expected[i], sample.Value)
+			}
+		})
+	}
+}
+
+func TestDownsampleWrongAggregation(t *testing.T) {
+	_, err := Downsample(nil, protocol.Gauge, time.Now(), time.Minute, protocol.AggregationRate)
+	assert.ErrorIs(t, err, ErrWrongAggregation)
+	_, err = Downsample(nil, protocol.Counter, time.Now(), time.Minute, protocol.AggregationAvg)
+	assert.ErrorIs(t, err, ErrWrongAggregation)
+}
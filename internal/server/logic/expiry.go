@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/pkg/gohelpers"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ExpiryRepository interface {
+	GetUpdatedAt(ctx context.Context, key string) (time.Time, error)
+	GetAllUpdatedAt(ctx context.Context) (map[string]time.Time, error)
+	DeleteUpdatedBefore(ctx context.Context, border time.Time) (int, error)
+	DeleteBatchesBefore(ctx context.Context, border time.Time) (int, error)
+	DeleteHistoryBefore(ctx context.Context, border time.Time) (int, error)
+}
+
+// Expiry reports stale series and periodically purges series not updated for too long.
+type Expiry struct {
+	repository ExpiryRepository
+	logger     *zap.Logger
+	doneCh     chan struct{}
+	now        func() time.Time
+	cfg        data.ExpiryConfig
+}
+
+func NewExpiry(cfg data.ExpiryConfig, repository ExpiryRepository, logger *zap.Logger) *Expiry {
+	return &Expiry{
+		cfg:        cfg,
+		repository: repository,
+		logger:     logger,
+		doneCh:     make(chan struct{}),
+		now:        time.Now,
+	}
+}
+
+func (e *Expiry) Start() chan error {
+	return gohelpers.StartTickerProcess(e.doneCh, e.Sweep, e.cfg.SweepInterval)
+}
+
+func (e *Expiry) Stop() {
+	close(e.doneCh)
+}
+
+// Sweep deletes series not updated since purge border and forgets batch IDs and history samples past retention.
+func (e *Expiry) Sweep(ctx context.Context) error {
+	now := e.now()
+	if border := e.cfg.PurgeBorder(now); !border.IsZero() {
+		deleted, err := e.repository.DeleteUpdatedBefore(ctx, border)
+		if err != nil {
+			return fmt.Errorf("failed to purge stale series: %w", err)
+		}
+		if deleted > 0 {
+			e.logger.Info("stale series purged", zap.Int("count", deleted), zap.Time("border", border))
+		}
+	}
+	if border := e.cfg.BatchBorder(now); !border.IsZero() {
+		deleted, err := e.repository.DeleteBatchesBefore(ctx, border)
+		if err != nil {
+			return fmt.Errorf("failed to purge applied batches: %w", err)
+		}
+		if deleted > 0 {
+			e.logger.Debug("applied batches purged", zap.Int("count", deleted), zap.Time("border", border))
+		}
+	}
+	if border := e.cfg.HistoryBorder(now); !border.IsZero() {
+		deleted, err := e.repository.DeleteHistoryBefore(ctx, border)
+		if err != nil {
+			return fmt.Errorf("failed to purge history: %w", err)
+		}
+		if deleted > 0 {
+			e.logger.Debug("history samples purged", zap.Int("count", deleted), zap.Time("border", border))
+		}
+	}
+	return nil
+}
+
+// IsStale reports whether series was not updated for staleness TTL.
+func (e *Expiry) IsStale(ctx context.Context, key string) (bool, error) {
+	if e.cfg.StaleAfter <= 0 {
+		return false, nil
+	}
+	updated, err := e.repository.GetUpdatedAt(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to get update time: %w", err)
+	}
+	return e.cfg.IsStale(updated, e.now()), nil
+}
+
+// StaleKeys returns set of stale series keys.
+func (e *Expiry) StaleKeys(ctx context.Context) (map[string]struct{}, error) {
+	res := make(map[string]struct{})
+	if e.cfg.StaleAfter <= 0 {
+		return res, nil
+	}
+	updated, err := e.repository.GetAllUpdatedAt(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get update times: %w", err)
+	}
+	now := e.now()
+	for key, updatedAt := range updated {
+		if e.cfg.IsStale(updatedAt, now) {
+			res[key] = struct{}{}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"context"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/internal/server/data/repositories/memrepository"
+	"go-metrics-service/internal/server/data/storages/memstorage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExpiry(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	repository := memrepository.New(memstorage.New(logger), data.HistoryConfig{}, logger)
+	require.NoError(t, repository.SetGauge(ctx, "Alloc", 1))
+	expiry := NewExpiry(
+		data.ExpiryConfig{
+			StaleAfter: time.Minute,
+			PurgeAfter: time.Hour,
+		},
+		repository,
+		logger,
+	)
+
+	stale, err := expiry.IsStale(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.False(t, stale)
+
+	_, err = expiry.IsStale(ctx, "Missing")
+	assert.ErrorIs(t, err, data.ErrNotFound)
+
+	expiry.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	stale, err = expiry.IsStale(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.True(t, stale)
+	staleKeys, err := expiry.StaleKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"Alloc": {}}, staleKeys)
+
+	require.NoError(t, expiry.Sweep(ctx))
+	_, err = repository.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+
+	expiry.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.NoError(t, expiry.Sweep(ctx))
+	_, err = repository.GetGauge(ctx, "Alloc")
+	assert.ErrorIs(t, err, data.ErrNotFound)
+}
+
+func TestExpirySweepHistory(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	repository := memrepository.New(memstorage.New(logger), data.HistoryConfig{Enabled: true}, logger)
+	require.NoError(t, repository.SetGauge(ctx, "Alloc", 1))
+	expiry := NewExpiry(data.ExpiryConfig{HistoryRetention: time.Hour}, repository, logger)
+	from, to := time.Now().Add(-time.Minute), time.Now().Add(time.Minute)
+
+	require.NoError(t, expiry.Sweep(ctx))
+	samples, err := repository.GetHistory(ctx, "Alloc", from, to)
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+
+	// Series not written anymore loses its history while its value is kept.
+	expiry.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.NoError(t, expiry.Sweep(ctx))
+	samples, err = repository.GetHistory(ctx, "Alloc", from, to)
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+	_, err = repository.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+}
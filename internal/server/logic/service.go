@@ -4,6 +4,9 @@ package logic
 import (
 	"context"
 	"fmt"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/data"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -16,7 +19,20 @@ type Repository interface {
 	SetCounters(ctx context.Context, values map[string]int64) error
 	SetGauge(ctx context.Context, key string, value float64) error
 	SetGauges(ctx context.Context, values map[string]float64) error
+	GetHistogram(ctx context.Context, key string) (protocol.HistogramValue, error)
+	SetHistogram(ctx context.Context, key string, value protocol.HistogramValue) error
+	SetHistograms(ctx context.Context, values map[string]protocol.HistogramValue) error
 	GetAll(ctx context.Context) (map[string]any, error)
+	GetHistory(ctx context.Context, key string, from, to time.Time) ([]data.Sample, error)
+	ExpiryRepository
+	DeletionRepository
+	BatchRepository
+}
+
+// BatchRepository records applied batches so retried batches are not applied twice.
+type BatchRepository interface {
+	HasBatch(ctx context.Context, key string) (bool, error)
+	MarkBatch(ctx context.Context, key string, at time.Time) (bool, error)
 }
 
 type Service struct {
@@ -29,6 +45,12 @@ type CounterDiff struct {
 	Delta int64
 }
 
+// HistogramDiff holds observations to merge into histogram.
+type HistogramDiff struct {
+	Key   string
+	Delta protocol.HistogramValue
+}
+
 type GaugeDiff struct {
 	Key      string
 	NewValue float64
@@ -41,6 +63,56 @@ func NewService(r Repository, l *zap.Logger) *Service {
 	}
 }
 
+// BatchApplied reports whether batch was marked as applied.
+func (s *Service) BatchApplied(ctx context.Context, key string) (bool, error) {
+	applied, err := s.r.HasBatch(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to check batch: %w", err)
+	}
+	return applied, nil
+}
+
+// MarkBatch records fully applied batch and returns false if it was applied before.
+func (s *Service) MarkBatch(ctx context.Context, key string) (bool, error) {
+	fresh, err := s.r.MarkBatch(ctx, key, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to mark batch: %w", err)
+	}
+	return fresh, nil
+}
+
+// UpdateBatch resolves new values of all series before storing any of them,
+// so batch rejected because of a single series does not leave others updated.
+func (s *Service) UpdateBatch(
+	ctx context.Context,
+	counters []CounterDiff,
+	gauges []GaugeDiff,
+	histograms []HistogramDiff,
+) error {
+	counterValues, err := s.counterValues(ctx, counters)
+	if err != nil {
+		return err
+	}
+	gaugeValues, err := s.gaugeValues(ctx, gauges)
+	if err != nil {
+		return err
+	}
+	histogramValues, err := s.histogramValues(ctx, histograms)
+	if err != nil {
+		return err
+	}
+	if err := s.r.SetCounters(ctx, counterValues); err != nil {
+		return fmt.Errorf("failed to set counters: %w", err)
+	}
+	if err := s.r.SetGauges(ctx, gaugeValues); err != nil {
+		return fmt.Errorf("failed to set gauges: %w", err)
+	}
+	if err := s.r.SetHistograms(ctx, histogramValues); err != nil {
+		return fmt.Errorf("failed to set histograms: %w", err)
+	}
+	return nil
+}
+
 func (s *Service) UpdateGauge(ctx context.Context, diff GaugeDiff) error {
 	err := s.r.SetGauge(ctx, diff.Key, diff.NewValue)
 	if err != nil {
@@ -50,11 +122,11 @@ func (s *Service) UpdateGauge(ctx context.Context, diff GaugeDiff) error {
 }
 
 func (s *Service) UpdateGauges(ctx context.Context, diffs []GaugeDiff) error {
-	values := make(map[string]float64)
-	for _, diff := range diffs {
-		values[diff.Key] = diff.NewValue
+	values, err := s.gaugeValues(ctx, diffs)
+	if err != nil {
+		return err
 	}
-	err := s.r.SetGauges(ctx, values)
+	err = s.r.SetGauges(ctx, values)
 	if err != nil {
 		return fmt.Errorf("failed to set gauges: %w", err)
 	}
@@ -74,6 +146,18 @@ func (s *Service) UpdateCounter(ctx context.Context, diff CounterDiff) error {
 }
 
 func (s *Service) UpdateCounters(ctx context.Context, diffs []CounterDiff) error {
+	values, err := s.counterValues(ctx, diffs)
+	if err != nil {
+		return err
+	}
+	err = s.r.SetCounters(ctx, values)
+	if err != nil {
+		return fmt.Errorf("failed to set counters: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) counterValues(ctx context.Context, diffs []CounterDiff) (map[string]int64, error) {
 	values := make(map[string]int64)
 	for _, diff := range diffs {
 		if _, ok := values[diff.Key]; ok {
@@ -81,16 +165,32 @@ func (s *Service) UpdateCounters(ctx context.Context, diffs []CounterDiff) error
 		} else {
 			newValue, err := s.getChangedCounter(ctx, diff.Key, diff.Delta)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			values[diff.Key] = newValue
 		}
 	}
-	err := s.r.SetCounters(ctx, values)
-	if err != nil {
-		return fmt.Errorf("failed to set counters: %w", err)
+	return values, nil
+}
+
+// gaugeValues checks that none of gauges is stored under other type.
+func (s *Service) gaugeValues(ctx context.Context, diffs []GaugeDiff) (map[string]float64, error) {
+	values := make(map[string]float64)
+	for _, diff := range diffs {
+		if _, ok := values[diff.Key]; !ok {
+			hasValue, err := s.r.Has(ctx, diff.Key)
+			if err != nil {
+				return nil, fmt.Errorf("hasValue: %w", err)
+			}
+			if hasValue {
+				if _, err := s.r.GetGauge(ctx, diff.Key); err != nil {
+					return nil, fmt.Errorf("%w: getting gauge '%s' failed", err, diff.Key)
+				}
+			}
+		}
+		values[diff.Key] = diff.NewValue
 	}
-	return nil
+	return values, nil
 }
 
 func (s *Service) getChangedCounter(ctx context.Context, key string, delta int64) (int64, error) {
@@ -118,3 +218,83 @@ func (s *Service) getChangedCounter(ctx context.Context, key string, delta int64
 	)
 	return newValue, nil
 }
+
+func (s *Service) UpdateHistogram(ctx context.Context, diff HistogramDiff) error {
+	newValue, err := s.getMergedHistogram(ctx, diff.Key, diff.Delta)
+	if err != nil {
+		return err
+	}
+	err = s.r.SetHistogram(ctx, diff.Key, newValue)
+	if err != nil {
+		return fmt.Errorf("failed to set histogram: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) UpdateHistograms(ctx context.Context, diffs []HistogramDiff) error {
+	values, err := s.histogramValues(ctx, diffs)
+	if err != nil {
+		return err
+	}
+	err = s.r.SetHistograms(ctx, values)
+	if err != nil {
+		return fmt.Errorf("failed to set histograms: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) histogramValues(
+	ctx context.Context,
+	diffs []HistogramDiff,
+) (map[string]protocol.HistogramValue, error) {
+	values := make(map[string]protocol.HistogramValue)
+	for _, diff := range diffs {
+		if err := diff.Delta.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: histogram '%s'", err, diff.Key)
+		}
+		if value, ok := values[diff.Key]; ok {
+			if err := value.Merge(diff.Delta); err != nil {
+				return nil, fmt.Errorf("%w: merging histogram '%s' failed", err, diff.Key)
+			}
+			values[diff.Key] = value
+			continue
+		}
+		newValue, err := s.getMergedHistogram(ctx, diff.Key, diff.Delta)
+		if err != nil {
+			return nil, err
+		}
+		values[diff.Key] = newValue
+	}
+	return values, nil
+}
+
+func (s *Service) getMergedHistogram(
+	ctx context.Context,
+	key string,
+	delta protocol.HistogramValue,
+) (protocol.HistogramValue, error) {
+	if err := delta.Validate(); err != nil {
+		return protocol.HistogramValue{}, fmt.Errorf("%w: histogram '%s'", err, key)
+	}
+	hasValue, err := s.r.Has(ctx, key)
+	if err != nil {
+		return protocol.HistogramValue{}, fmt.Errorf("hasValue: %w", err)
+	}
+	if !hasValue {
+		return delta.Clone(), nil
+	}
+	value, err := s.r.GetHistogram(ctx, key)
+	if err != nil {
+		return protocol.HistogramValue{}, fmt.Errorf("%w: getting histogram '%s' failed", err, key)
+	}
+	if err := value.Merge(delta); err != nil {
+		return protocol.HistogramValue{}, fmt.Errorf("%w: merging histogram '%s' failed", err, key)
+	}
+	s.l.Debug(
+		"merge histogram",
+		zap.String("key", key),
+		zap.Uint64("count", value.Count),
+		zap.Uint64("delta", delta.Count),
+	)
+	return value, nil
+}
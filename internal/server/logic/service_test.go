@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"context"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/data"
+	"go-metrics-service/internal/server/data/repositories/memrepository"
+	"go-metrics-service/internal/server/data/storages/memstorage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUpdateHistogramsDuplicateKey(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	repository := memrepository.New(memstorage.New(logger), data.HistoryConfig{}, logger)
+	service := NewService(repository, logger)
+
+	valid := protocol.NewHistogramValue([]float64{1, 2})
+	valid.Observe(1.5)
+	malformed := protocol.HistogramValue{Bounds: []float64{1, 2}, Counts: []uint64{1}, Count: 1}
+
+	err := service.UpdateHistograms(ctx, []HistogramDiff{
+		{Key: "Latency", Delta: valid},
+		{Key: "Latency", Delta: malformed},
+	})
+	require.ErrorIs(t, err, protocol.ErrInvalidHistogram)
+	_, err = repository.GetHistogram(ctx, "Latency")
+	assert.ErrorIs(t, err, data.ErrNotFound)
+
+	require.NoError(t, service.UpdateHistograms(ctx, []HistogramDiff{
+		{Key: "Latency", Delta: valid},
+		{Key: "Latency", Delta: valid},
+	}))
+	stored, err := repository.GetHistogram(ctx, "Latency")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), stored.Count)
+}
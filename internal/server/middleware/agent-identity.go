@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/agents"
+	"net/http"
+)
+
+// AgentIdentity puts identity of the agent sending request into request context.
+type AgentIdentity struct{}
+
+func NewAgentIdentity() *AgentIdentity {
+	return &AgentIdentity{}
+}
+
+func (i *AgentIdentity) CreateHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(protocol.AgentIDHeader)
+		if id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := agents.WithIdentity(r.Context(), agents.Identity{
+			ID: id,
+			IP: r.Header.Get(protocol.RealIPHeader),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
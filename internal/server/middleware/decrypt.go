@@ -2,15 +2,20 @@ package middleware
 
 import (
 	"bytes"
+	"errors"
+	"go-metrics-service/internal/common/protocol"
 	"go-metrics-service/internal/server/handlers"
+	"go-metrics-service/pkg/rsahelpers"
 	"io"
 	"net/http"
 
 	"go.uber.org/zap"
 )
 
+// Decoder decrypts body of format named by Content-Encryption header
+// with key named by X-Key-ID header.
 type Decoder interface {
-	Decode([]byte) ([]byte, error)
+	Decode(keyID, encryption string, chiper []byte) ([]byte, error)
 }
 
 type RequestDecoder struct {
@@ -36,7 +41,20 @@ func (rd *RequestDecoder) CreateHandler(next http.Handler) http.Handler {
 			return
 		}
 
-		decoded, err := rd.decoder.Decode(body)
+		decoded, err := rd.decoder.Decode(
+			r.Header.Get(protocol.KeyIDHeader),
+			r.Header.Get(protocol.ContentEncryptionHeader),
+			body,
+		)
+		// Body which can not be decrypted is not decrypted on retry either.
+		if errors.Is(err, rsahelpers.ErrUnknownEncryption) ||
+			errors.Is(err, rsahelpers.ErrUnknownKey) ||
+			errors.Is(err, rsahelpers.ErrInvalidEnvelope) ||
+			errors.Is(err, rsahelpers.ErrDecryption) {
+			requestLogger.Debug("failed to decode body", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			requestLogger.Error("failed to decode body", zap.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
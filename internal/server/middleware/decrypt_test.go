@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"go-metrics-service/pkg/rsahelpers"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type decoderStub struct {
+	err error
+}
+
+func (d decoderStub) Decode(_, _ string, chiper []byte) ([]byte, error) {
+	return chiper, d.err
+}
+
+func TestRequestDecoderStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		name string
+		code int
+	}{
+		{name: "decrypted", code: http.StatusOK},
+		{name: "unknown key", err: rsahelpers.ErrUnknownKey, code: http.StatusBadRequest},
+		{name: "invalid envelope", err: rsahelpers.ErrInvalidEnvelope, code: http.StatusBadRequest},
+		{name: "authentication failed", err: fmt.Errorf("%w: block", rsahelpers.ErrDecryption), code: http.StatusBadRequest},
+		{
+			name: "no key fits",
+			err:  errors.Join(rsahelpers.ErrDecryption, fmt.Errorf("%w: tag", rsahelpers.ErrInvalidEnvelope)),
+			code: http.StatusBadRequest,
+		},
+		{name: "internal", err: errors.New("failed to create cipher"), code: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewRequestDecoder(decoderStub{err: tt.err}, zap.NewNop()).
+				CreateHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/updates/", strings.NewReader("chiper")))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
@@ -3,23 +3,30 @@ package middleware
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"go-metrics-service/internal/common/hashing"
 	"go-metrics-service/internal/common/protocol"
 	"go-metrics-service/internal/server/handlers"
 	"hash"
 	"io"
 	"net/http"
+	"time"
 
 	"go.uber.org/zap"
 )
 
-type HashFactory interface {
-	Create() hash.Hash
+// HashKeys selects HMAC keys by key ID of X-Hash-Key-ID header.
+type HashKeys interface {
+	// Verifiers returns keys accepted for key ID.
+	Verifiers(keyID string) []hashing.Factory
+	// Signer returns key to sign response with.
+	Signer(keyID string) (hashing.Factory, bool)
 }
 
 type ResponseHash struct {
-	hashFactory HashFactory
-	logger      *zap.Logger
+	keys   HashKeys
+	logger *zap.Logger
 }
 
 type HashWriter struct {
@@ -34,18 +41,24 @@ func (w *HashWriter) Write(b []byte) (int, error) {
 	return w.b.Write(b)
 }
 
-func NewResponseHash(logger *zap.Logger, hashFactory HashFactory) *ResponseHash {
+func NewResponseHash(logger *zap.Logger, keys HashKeys) *ResponseHash {
 	return &ResponseHash{
-		hashFactory: hashFactory,
-		logger:      logger,
+		keys:   keys,
+		logger: logger,
 	}
 }
 
+// CreateHandler signs response with key of request key ID, response to unknown key ID is not signed.
 func (rh *ResponseHash) CreateHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signer, ok := rh.keys.Signer(r.Header.Get(protocol.HashKeyIDHeader))
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
 		hw := &HashWriter{
 			ResponseWriter: w,
-			h:              rh.hashFactory.Create(),
+			h:              signer.Create(),
 		}
 		h.ServeHTTP(hw, r)
 		w.Header().Set(protocol.HashHeader, hex.EncodeToString(hw.h.Sum(nil)))
@@ -57,52 +70,120 @@ func (rh *ResponseHash) CreateHandler(h http.Handler) http.Handler {
 	})
 }
 
+// SignaturePolicy tells whether unsigned requests are served.
+type SignaturePolicy int
+
+const (
+	// SignatureOptional serves unsigned requests, signed ones are verified anyway.
+	SignatureOptional SignaturePolicy = iota
+	// SignatureRequired rejects unsigned requests, it protects routes changing metrics.
+	SignatureRequired
+)
+
 type RequestHash struct {
-	hashFactory HashFactory
-	logger      *zap.Logger
+	keys         HashKeys
+	nonces       *NonceCache
+	logger       *zap.Logger
+	now          func() time.Time
+	replayWindow time.Duration
+	policy       SignaturePolicy
 }
 
-func NewRequestHash(logger *zap.Logger, hashFactory HashFactory) *RequestHash {
+// NewRequestHash verifies requests signed within replayWindow from server time,
+// nonces of accepted requests are remembered so each signed request is accepted once.
+// Middlewares of one server must share nonces, otherwise request is accepted once by each of them.
+func NewRequestHash(
+	logger *zap.Logger,
+	keys HashKeys,
+	replayWindow time.Duration,
+	nonces *NonceCache,
+	policy SignaturePolicy,
+) *RequestHash {
 	return &RequestHash{
-		hashFactory: hashFactory,
-		logger:      logger,
+		keys:         keys,
+		nonces:       nonces,
+		logger:       logger,
+		now:          time.Now,
+		replayWindow: replayWindow,
+		policy:       policy,
 	}
 }
 
 func (rh *RequestHash) CreateHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestLogger := handlers.NewRequestLogger(rh.logger, r)
 		receivedHashVal := r.Header.Get(protocol.HashHeader)
 		if receivedHashVal == "" {
+			if rh.policy == SignatureRequired {
+				// Stripping the signature must not let request skip replay window and nonce checks.
+				requestLogger.Debug("unsigned request rejected")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
 			next.ServeHTTP(w, r)
 			return
 		}
-		requestLogger := handlers.NewRequestLogger(rh.logger, r)
+		keyID := r.Header.Get(protocol.HashKeyIDHeader)
+		// Agent key is tied to agent identity, so one agent can not write metrics of another.
+		if keyID != "" && keyID != r.Header.Get(protocol.AgentIDHeader) {
+			requestLogger.Warn("hash key ID does not match agent ID", zap.String("key_id", keyID))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		timestamp := r.Header.Get(protocol.TimestampHeader)
+		nonce := r.Header.Get(protocol.NonceHeader)
+		now := rh.now()
+		signedAt, err := rh.checkWindow(timestamp, nonce, now)
+		if err != nil {
+			requestLogger.Debug("request rejected", zap.Error(err))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
 		bodyBytes, err := readBodyAndRewind(&r.Body)
 		if err != nil {
 			requestLogger.Error("failed to read request body", zap.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		calculatedHashVal, err := calculateHash(bodyBytes, rh.hashFactory)
+		valid, err := hashing.MatchAny(
+			rh.keys.Verifiers(keyID),
+			receivedHashVal,
+			func(factory hashing.Factory) (string, error) {
+				return hashing.RequestSum(factory, timestamp, nonce, bodyBytes)
+			},
+		)
 		if err != nil {
 			requestLogger.Error("failed to calculate hash", zap.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
-		if calculatedHashVal != receivedHashVal {
-			w.WriteHeader(http.StatusBadRequest)
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Nonce is recorded only after hash check, so forged requests can not fill the cache.
+		if !rh.nonces.Add(nonce, signedAt.Add(rh.replayWindow), now) {
+			requestLogger.Warn("replayed request rejected", zap.String("nonce", nonce))
+			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-func calculateHash(bodyBytes []byte, hashFactory HashFactory) (string, error) {
-	h := hashFactory.Create()
-	_, err := h.Write(bodyBytes)
+// checkWindow rejects requests signed too long ago, captured request can be replayed only within the window.
+func (rh *RequestHash) checkWindow(timestamp, nonce string, now time.Time) (time.Time, error) {
+	if timestamp == "" || nonce == "" {
+		return time.Time{}, errors.New("signed request without timestamp or nonce")
+	}
+	signedAt, err := hashing.ParseTimestamp(timestamp)
 	if err != nil {
-		return "", fmt.Errorf("failed to write body: %w", err)
+		return time.Time{}, err //nolint:wrapcheck // error is only logged
+	}
+	if age := now.Sub(signedAt); age > rh.replayWindow || age < -rh.replayWindow {
+		return time.Time{}, fmt.Errorf("request timestamp %s is out of replay window", signedAt.Format(time.RFC3339))
 	}
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return signedAt, nil
 }
 
 func readBodyAndRewind(readCloser *io.ReadCloser) ([]byte, error) {
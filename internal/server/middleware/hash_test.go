@@ -3,11 +3,130 @@ package middleware
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/hex"
 	"go-metrics-service/internal/common/hashing"
+	"go-metrics-service/internal/common/protocol"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
+func TestRequestHash(t *testing.T) {
+	now := time.Now()
+	keyring, err := hashing.NewKeyring([]hashing.Key{
+		{Secret: "shared"},
+		{ID: "agent", Secret: "new"},
+		{ID: "agent", Secret: "old", ExpiresAt: now.Add(time.Hour)},
+		{ID: "agent", Secret: "expired", ExpiresAt: now.Add(-time.Hour)},
+	})
+	require.NoError(t, err)
+	requestHash := NewRequestHash(zap.NewNop(), keyring, time.Minute, NewNonceCache(100), SignatureOptional)
+	requestHash.now = func() time.Time { return now }
+	handler := NewResponseHash(zap.NewNop(), keyring).CreateHandler(
+		requestHash.CreateHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		})),
+	)
+
+	body := []byte(`[{"id":"PollCount","type":"counter","delta":1}]`)
+	type request struct {
+		secret    string
+		keyID     string
+		agentID   string
+		timestamp time.Time
+		nonce     string
+		unsigned  bool
+	}
+	tests := []struct {
+		name     string
+		request  request
+		code     int
+		response string
+	}{
+		{name: "shared key", request: request{secret: "shared", timestamp: now, nonce: "n1"}, code: http.StatusOK, response: "shared"},
+		{name: "agent key", request: request{secret: "new", keyID: "agent", agentID: "agent", timestamp: now, nonce: "n2"}, code: http.StatusOK, response: "new"},
+		{name: "retired key in grace period", request: request{secret: "old", keyID: "agent", agentID: "agent", timestamp: now, nonce: "n3"}, code: http.StatusOK, response: "new"},
+		{name: "expired key", request: request{secret: "expired", keyID: "agent", agentID: "agent", timestamp: now, nonce: "n4"}, code: http.StatusUnauthorized},
+		{name: "key of other ID", request: request{secret: "shared", keyID: "agent", agentID: "agent", timestamp: now, nonce: "n5"}, code: http.StatusUnauthorized},
+		{name: "unknown key ID", request: request{secret: "new", keyID: "other", agentID: "other", timestamp: now, nonce: "n6"}, code: http.StatusUnauthorized},
+		{name: "key of other agent", request: request{secret: "new", keyID: "agent", agentID: "other", timestamp: now, nonce: "n9"}, code: http.StatusUnauthorized},
+		{name: "old timestamp", request: request{secret: "shared", timestamp: now.Add(-2 * time.Minute), nonce: "n7"}, code: http.StatusUnauthorized},
+		{name: "future timestamp", request: request{secret: "shared", timestamp: now.Add(2 * time.Minute), nonce: "n8"}, code: http.StatusUnauthorized},
+		{name: "no nonce", request: request{secret: "shared", timestamp: now}, code: http.StatusUnauthorized},
+		{name: "unsigned", request: request{unsigned: true}, code: http.StatusOK, response: "shared"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, protocol.UpdateMetricsURL, bytes.NewReader(body))
+			if !tt.request.unsigned {
+				timestamp := hashing.FormatTimestamp(tt.request.timestamp)
+				sum, err := hashing.RequestSum(hashing.NewHMAC(tt.request.secret), timestamp, tt.request.nonce, body)
+				require.NoError(t, err)
+				r.Header.Set(protocol.HashHeader, sum)
+				r.Header.Set(protocol.HashKeyIDHeader, tt.request.keyID)
+				r.Header.Set(protocol.TimestampHeader, timestamp)
+				r.Header.Set(protocol.NonceHeader, tt.request.nonce)
+				r.Header.Set(protocol.AgentIDHeader, tt.request.agentID)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.code, w.Code)
+			if tt.response == "" {
+				return
+			}
+			h := hashing.NewHMAC(tt.response).Create()
+			h.Write([]byte("ok"))
+			sum := h.Sum(nil)
+			assert.Equal(t, hex.EncodeToString(sum), w.Header().Get(protocol.HashHeader))
+
+			if !tt.request.unsigned {
+				replay := httptest.NewRequest(http.MethodPost, protocol.UpdateMetricsURL, bytes.NewReader(body))
+				replay.Header = r.Header.Clone()
+				w = httptest.NewRecorder()
+				handler.ServeHTTP(w, replay)
+				assert.Equal(t, http.StatusUnauthorized, w.Code, "replayed request accepted")
+			}
+		})
+	}
+}
+
+func TestRequestHashRequired(t *testing.T) {
+	now := time.Now()
+	keyring, err := hashing.NewKeyring([]hashing.Key{{Secret: "shared"}})
+	require.NoError(t, err)
+	requestHash := NewRequestHash(zap.NewNop(), keyring, time.Minute, NewNonceCache(100), SignatureRequired)
+	requestHash.now = func() time.Time { return now }
+	served := 0
+	handler := requestHash.CreateHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		served++
+	}))
+
+	body := []byte(`[{"id":"PollCount","type":"counter","delta":1}]`)
+	timestamp := hashing.FormatTimestamp(now)
+	sum, err := hashing.RequestSum(hashing.NewHMAC("shared"), timestamp, "n1", body)
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodPost, protocol.UpdateMetricsURL, bytes.NewReader(body))
+	r.Header.Set(protocol.HashHeader, sum)
+	r.Header.Set(protocol.TimestampHeader, timestamp)
+	r.Header.Set(protocol.NonceHeader, "n1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Captured request replayed without signature headers must not bypass nonce check.
+	replay := httptest.NewRequest(http.MethodPost, protocol.UpdateMetricsURL, bytes.NewReader(body))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, replay)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 1, served)
+}
+
 func BenchmarkCalculateHash(b *testing.B) {
 	hashFactory := hashing.NewHMAC("private key")
 	body := make([]byte, 1_024_000)
@@ -15,9 +134,10 @@ func BenchmarkCalculateHash(b *testing.B) {
 	if err != nil {
 		b.Fatal(err)
 	}
+	timestamp := hashing.FormatTimestamp(time.Now())
 	b.ResetTimer()
 	for range b.N {
-		_, err := calculateHash(body, hashFactory)
+		_, err := hashing.RequestSum(hashFactory, timestamp, "nonce", body)
 		if err != nil {
 			b.Fatal(err)
 		}
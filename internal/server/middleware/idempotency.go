@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"go-metrics-service/internal/common/protocol"
+	"go-metrics-service/internal/server/handlers"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Idempotency applies request carrying Idempotency-Key header once: request retried with the
+// same key by the same agent gets response of the first one. Request arriving while the first
+// one is processed waits for it. Server error responses are not stored, so retry is applied again.
+type Idempotency struct {
+	mux      *sync.Mutex
+	results  map[string]*idempotentResult
+	logger   *zap.Logger
+	now      func() time.Time
+	order    []orderedResult
+	ttl      time.Duration
+	capacity int
+}
+
+type idempotentResult struct {
+	expiresAt time.Time
+	done      chan struct{}
+	header    http.Header
+	body      []byte
+	code      int
+}
+
+type orderedResult struct {
+	result *idempotentResult
+	key    string
+}
+
+// NewIdempotency stores up to capacity responses for ttl.
+func NewIdempotency(logger *zap.Logger, ttl time.Duration, capacity int) *Idempotency {
+	return &Idempotency{
+		mux:      &sync.Mutex{},
+		results:  make(map[string]*idempotentResult),
+		logger:   logger,
+		now:      time.Now,
+		order:    make([]orderedResult, 0),
+		ttl:      ttl,
+		capacity: capacity,
+	}
+}
+
+func (i *Idempotency) CreateHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(protocol.IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Keys are generated by agents, so they are scoped by agent.
+		key = r.Header.Get(protocol.AgentIDHeader) + "/" + key
+		for {
+			result, first := i.acquire(key)
+			if first {
+				i.serve(next, w, r, key, result)
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			case <-result.done:
+			}
+			if result.code == 0 {
+				// First request failed and was forgotten, this one is applied instead.
+				continue
+			}
+			handlers.NewRequestLogger(i.logger, r).Debug("idempotent request replayed", zap.String("key", key))
+			writeResult(w, result)
+			return
+		}
+	})
+}
+
+// acquire returns result stored for key, or registers new one to be filled by the caller.
+func (i *Idempotency) acquire(key string) (*idempotentResult, bool) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	if result, ok := i.results[key]; ok && (result.expiresAt.IsZero() || result.expiresAt.After(i.now())) {
+		return result, false
+	}
+	delete(i.results, key)
+	i.evict()
+	result := &idempotentResult{done: make(chan struct{})}
+	i.results[key] = result
+	i.order = append(i.order, orderedResult{key: key, result: result})
+	return result, true
+}
+
+func (i *Idempotency) serve(next http.Handler, w http.ResponseWriter, r *http.Request, key string, result *idempotentResult) {
+	recorder := &responseRecorder{header: make(http.Header), code: http.StatusOK}
+	defer func() {
+		i.mux.Lock()
+		if recorder.code < http.StatusInternalServerError {
+			result.header = recorder.header
+			result.body = recorder.body.Bytes()
+			result.code = recorder.code
+			result.expiresAt = i.now().Add(i.ttl)
+		} else if i.results[key] == result {
+			delete(i.results, key)
+		}
+		i.mux.Unlock()
+		close(result.done)
+	}()
+	next.ServeHTTP(recorder, r)
+	writeResult(w, &idempotentResult{header: recorder.header, body: recorder.body.Bytes(), code: recorder.code})
+}
+
+// evict drops expired and forgotten results, then the oldest completed ones to make room for new one.
+func (i *Idempotency) evict() {
+	now := i.now()
+	for len(i.order) > 0 {
+		oldest := i.order[0]
+		stored, ok := i.results[oldest.key]
+		if ok && stored == oldest.result {
+			completed := !oldest.result.expiresAt.IsZero()
+			expired := completed && !oldest.result.expiresAt.After(now)
+			if !expired && (!completed || len(i.results) < i.capacity) {
+				return
+			}
+			delete(i.results, oldest.key)
+		}
+		i.order = i.order[1:]
+	}
+}
+
+func writeResult(w http.ResponseWriter, result *idempotentResult) {
+	for name, values := range result.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(result.code)
+	_, _ = w.Write(result.body)
+}
+
+// responseRecorder keeps response to be stored and written later.
+type responseRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	code   int
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+//nolint:wrapcheck // wrapping unnecessary
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.code = code
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"go-metrics-service/internal/common/protocol"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestIdempotency(t *testing.T) {
+	var applied atomic.Int32
+	var failing atomic.Bool
+	release := make(chan struct{})
+	close(release)
+	handler := NewIdempotency(zap.NewNop(), time.Minute, 10).CreateHandler(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			<-release
+			if failing.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			applied.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"applied":true}`))
+		}),
+	)
+	send := func(agentID, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, protocol.UpdateMetricsURL, nil)
+		r.Header.Set(protocol.AgentIDHeader, agentID)
+		r.Header.Set(protocol.IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := send("agent", "batch-1")
+	retry := send("agent", "batch-1")
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.Equal(t, int32(1), applied.Load())
+
+	// Keys are scoped by agent.
+	send("other", "batch-1")
+	assert.Equal(t, int32(2), applied.Load())
+
+	// Failed request is applied again on retry.
+	failing.Store(true)
+	assert.Equal(t, http.StatusInternalServerError, send("agent", "batch-2").Code)
+	failing.Store(false)
+	assert.Equal(t, http.StatusOK, send("agent", "batch-2").Code)
+	assert.Equal(t, int32(3), applied.Load())
+
+	// Concurrent retries wait for the first one.
+	release = make(chan struct{})
+	wg := sync.WaitGroup{}
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, send("agent", "batch-3").Code)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(4), applied.Load())
+}
+
+func TestIdempotencyExpiration(t *testing.T) {
+	var applied atomic.Int32
+	idempotency := NewIdempotency(zap.NewNop(), time.Minute, 2)
+	now := time.Now()
+	idempotency.now = func() time.Time { return now }
+	handler := idempotency.CreateHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		applied.Add(1)
+	}))
+	send := func(key string) {
+		r := httptest.NewRequest(http.MethodPost, protocol.UpdateMetricsURL, nil)
+		r.Header.Set(protocol.IdempotencyKeyHeader, key)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	send("a")
+	send("b")
+	send("a")
+	assert.Equal(t, int32(2), applied.Load())
+
+	// Capacity evicts the oldest response.
+	send("c")
+	send("a")
+	assert.Equal(t, int32(4), applied.Load())
+
+	now = now.Add(2 * time.Minute)
+	send("c")
+	assert.Equal(t, int32(5), applied.Load())
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// NonceCache remembers nonces of accepted signed requests until their timestamp leaves
+// replay window. Cache is bounded: when it is full the soonest expiring nonce is evicted
+// and requests expiring not later than it are rejected, as their nonces can not be checked.
+type NonceCache struct {
+	mux       *sync.Mutex
+	nonces    map[string]struct{}
+	expiries  nonceHeap
+	watermark time.Time
+	capacity  int
+}
+
+func NewNonceCache(capacity int) *NonceCache {
+	return &NonceCache{
+		mux:      &sync.Mutex{},
+		nonces:   make(map[string]struct{}),
+		expiries: make(nonceHeap, 0),
+		capacity: capacity,
+	}
+}
+
+// Add records nonce valid until expiresAt. It returns false when request must be rejected:
+// nonce was seen before or can not be checked anymore.
+func (c *NonceCache) Add(nonce string, expiresAt, now time.Time) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for len(c.expiries) > 0 && !c.expiries[0].expiresAt.After(now) {
+		delete(c.nonces, c.expiries[0].nonce)
+		heap.Pop(&c.expiries)
+	}
+	if _, ok := c.nonces[nonce]; ok {
+		return false
+	}
+	if !expiresAt.After(c.watermark) {
+		return false
+	}
+	if len(c.expiries) >= c.capacity {
+		evicted := c.expiries[0]
+		heap.Pop(&c.expiries)
+		delete(c.nonces, evicted.nonce)
+		c.watermark = evicted.expiresAt
+		if !expiresAt.After(c.watermark) {
+			return false
+		}
+	}
+	c.nonces[nonce] = struct{}{}
+	heap.Push(&c.expiries, nonceEntry{nonce: nonce, expiresAt: expiresAt})
+	return true
+}
+
+type nonceEntry struct {
+	expiresAt time.Time
+	nonce     string
+}
+
+// nonceHeap orders nonces by expiration, soonest first.
+type nonceHeap []nonceEntry
+
+func (h nonceHeap) Len() int           { return len(h) }
+func (h nonceHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h nonceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *nonceHeap) Push(x any) {
+	*h = append(*h, x.(nonceEntry)) //nolint:forcetypeassert // heap contains only entries
+}
+
+func (h *nonceHeap) Pop() any {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	*h = old[:n-1]
+	return entry
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceCache(t *testing.T) {
+	now := time.Now()
+	cache := NewNonceCache(2)
+
+	assert.True(t, cache.Add("a", now.Add(time.Minute), now))
+	assert.False(t, cache.Add("a", now.Add(time.Minute), now), "nonce accepted twice")
+	assert.True(t, cache.Add("b", now.Add(2*time.Minute), now))
+
+	// Full cache evicts the soonest expiring nonce and rejects requests expiring before it.
+	assert.True(t, cache.Add("c", now.Add(3*time.Minute), now))
+	assert.False(t, cache.Add("a", now.Add(time.Minute), now), "evicted nonce accepted again")
+	assert.False(t, cache.Add("d", now.Add(30*time.Second), now))
+	assert.False(t, cache.Add("b", now.Add(2*time.Minute), now))
+
+	// Expired nonces are dropped without raising watermark.
+	later := now.Add(150 * time.Second)
+	assert.True(t, cache.Add("e", later.Add(time.Minute), later))
+	assert.True(t, cache.Add("f", later.Add(time.Minute), later))
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"go-metrics-service/internal/common/protocol"
 	"net"
 	"net/http"
 
@@ -26,7 +27,7 @@ func NewSubnetFilter(logger *zap.Logger, trustedSubnet string) (*SubnetFilter, e
 
 func (sf *SubnetFilter) CreateHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var ipStr = r.Header.Get("X-Real-IP")
+		var ipStr = r.Header.Get(protocol.RealIPHeader)
 		var ip = net.ParseIP(ipStr)
 		if ip == nil {
 			w.WriteHeader(http.StatusBadRequest)
@@ -0,0 +1,108 @@
+// Package watch fans out applied metric updates to subscribers
+package watch
+
+import (
+	"errors"
+	"go-metrics-service/internal/common/protocol"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrSlowSubscriber is reported to subscriber dropped for not keeping up with updates.
+	ErrSlowSubscriber = errors.New("subscriber is too slow")
+	// ErrClosed is reported to subscribers when hub is closed.
+	ErrClosed = errors.New("watch hub closed")
+)
+
+// Subscription receives updates of metrics whose ID starts with prefix.
+// Updates channel is closed when subscription is cancelled or dropped.
+type Subscription struct {
+	updates chan protocol.Metrics
+	err     error
+	prefix  string
+}
+
+func (s *Subscription) Updates() <-chan protocol.Metrics {
+	return s.updates
+}
+
+// Err returns reason of subscription drop after Updates channel is closed.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+type Hub struct {
+	mux         *sync.Mutex
+	subscribers map[*Subscription]struct{}
+	bufferSize  int
+	closed      bool
+}
+
+func New(bufferSize int) *Hub {
+	return &Hub{
+		mux:         &sync.Mutex{},
+		subscribers: make(map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe registers new subscriber. Cancel must be called when subscriber stops reading.
+func (h *Hub) Subscribe(prefix string) (subscription *Subscription, cancel func()) {
+	subscription = &Subscription{
+		updates: make(chan protocol.Metrics, h.bufferSize),
+		prefix:  prefix,
+	}
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.subscribers[subscription] = struct{}{}
+	if h.closed {
+		h.remove(subscription, ErrClosed)
+	}
+	return subscription, func() {
+		h.mux.Lock()
+		defer h.mux.Unlock()
+		h.remove(subscription, nil)
+	}
+}
+
+// Publish passes metrics to matching subscribers without blocking.
+// Subscribers with full buffer are dropped.
+func (h *Hub) Publish(metrics []protocol.Metrics) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	for subscription := range h.subscribers {
+		for _, metric := range metrics {
+			if !strings.HasPrefix(metric.ID, subscription.prefix) {
+				continue
+			}
+			select {
+			case subscription.updates <- metric:
+			default:
+				h.remove(subscription, ErrSlowSubscriber)
+			}
+			if _, ok := h.subscribers[subscription]; !ok {
+				break
+			}
+		}
+	}
+}
+
+// Close drops every subscriber and rejects new ones.
+func (h *Hub) Close() {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.closed = true
+	for subscription := range h.subscribers {
+		h.remove(subscription, ErrClosed)
+	}
+}
+
+func (h *Hub) remove(subscription *Subscription, err error) {
+	if _, ok := h.subscribers[subscription]; !ok {
+		return
+	}
+	delete(h.subscribers, subscription)
+	subscription.err = err
+	close(subscription.updates)
+}
@@ -0,0 +1,72 @@
+package watch
+
+import (
+	"go-metrics-service/internal/common/protocol"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := New(2)
+	all, cancelAll := hub.Subscribe("")
+	defer cancelAll()
+	cpu, cancelCPU := hub.Subscribe("cpu")
+	defer cancelCPU()
+
+	value := 1.0
+	hub.Publish([]protocol.Metrics{
+		{ID: "cpu_idle", MType: protocol.Gauge, Value: &value},
+		{ID: "mem_free", MType: protocol.Gauge, Value: &value},
+	})
+
+	assert.Equal(t, "cpu_idle", (<-all.Updates()).ID)
+	assert.Equal(t, "mem_free", (<-all.Updates()).ID)
+	assert.Equal(t, "cpu_idle", (<-cpu.Updates()).ID)
+	assert.Empty(t, cpu.Updates())
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := New(1)
+	slow, cancel := hub.Subscribe("")
+	defer cancel()
+
+	hub.Publish([]protocol.Metrics{{ID: "a"}, {ID: "b"}, {ID: "c"}})
+
+	received := make([]string, 0)
+	for metric := range slow.Updates() {
+		received = append(received, metric.ID)
+	}
+	assert.Equal(t, []string{"a"}, received)
+	require.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+}
+
+func TestHubCancel(t *testing.T) {
+	hub := New(1)
+	subscription, cancel := hub.Subscribe("")
+	cancel()
+	cancel()
+
+	hub.Publish([]protocol.Metrics{{ID: "a"}})
+
+	_, ok := <-subscription.Updates()
+	assert.False(t, ok)
+	assert.NoError(t, subscription.Err())
+}
+
+func TestHubClose(t *testing.T) {
+	hub := New(1)
+	before, cancel := hub.Subscribe("")
+	defer cancel()
+
+	hub.Close()
+	after, cancelAfter := hub.Subscribe("")
+	defer cancelAfter()
+
+	for _, subscription := range []*Subscription{before, after} {
+		_, ok := <-subscription.Updates()
+		assert.False(t, ok)
+		assert.ErrorIs(t, subscription.Err(), ErrClosed)
+	}
+}
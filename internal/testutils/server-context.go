@@ -1,11 +1,15 @@
 package testutils
 
 import (
+	"go-metrics-service/internal/server/agents"
+	"go-metrics-service/internal/server/audit"
 	"go-metrics-service/internal/server/controllers"
+	"go-metrics-service/internal/server/data"
 	"go-metrics-service/internal/server/data/repositories/memrepository"
 	"go-metrics-service/internal/server/data/storages"
 	"go-metrics-service/internal/server/data/storages/memstorage"
 	"go-metrics-service/internal/server/logic"
+	"go-metrics-service/internal/server/watch"
 
 	"go.uber.org/zap"
 )
@@ -13,19 +17,29 @@ import (
 type ServerContext struct {
 	Repository logic.Repository
 	Controller *controllers.Controller
+	Expiry     *logic.Expiry
 	Logger     *zap.Logger
 }
 
 func NewServerContext() *ServerContext {
 	logger := zap.NewNop()
 	memStorage := memstorage.New(logger)
-	memRepository := memrepository.New(memStorage, logger)
+	memRepository := memrepository.New(memStorage, data.HistoryConfig{Enabled: true}, logger)
 	transactionManager := storages.NewDummyTransactionsManager()
 	service := logic.NewService(memRepository, logger)
-	controller := controllers.NewController(transactionManager, service, logger)
+	controller := controllers.NewController(
+		controllers.Config{},
+		transactionManager,
+		service,
+		agents.New(0, 0),
+		audit.New(nil, logger),
+		watch.New(0),
+		logger,
+	)
 	return &ServerContext{
 		Repository: memRepository,
 		Controller: controller,
+		Expiry:     logic.NewExpiry(data.ExpiryConfig{}, memRepository, logger),
 		Logger:     logger,
 	}
 }
@@ -0,0 +1,137 @@
+// Package circuitbreaker contains circuit breaker stopping calls to failing remote side
+package circuitbreaker
+
+import (
+	"errors"
+	"go-metrics-service/pkg/timeutils"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned while breaker does not let calls through.
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	// Closed lets every call through and counts consecutive failures.
+	Closed State = iota
+	// Open rejects calls until open timeout passes.
+	Open
+	// HalfOpen lets one probe call through, its result closes or reopens breaker.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Config controls breaker. Breaker opens after FailureThreshold consecutive failures
+// and stays open for OpenBackoff delay, growing with every failed probe.
+type Config struct {
+	OpenBackoff      timeutils.Backoff
+	FailureThreshold int
+}
+
+// Breaker is safe for concurrent use, every allowed call must be reported with Success or Failure.
+type Breaker struct {
+	openedUntil time.Time
+	mux         *sync.Mutex
+	now         func() time.Time
+	onChange    func(from, to State)
+	cfg         Config
+	state       State
+	failures    int
+	trips       int
+	probing     bool
+}
+
+// New creates closed breaker. onChange is called under breaker lock on every state change, it may be nil.
+func New(cfg Config, onChange func(from, to State)) *Breaker {
+	return &Breaker{
+		mux:      &sync.Mutex{},
+		now:      time.Now,
+		onChange: onChange,
+		cfg:      cfg,
+		state:    Closed,
+	}
+}
+
+// Allow returns ErrOpen if call must not be made now.
+func (b *Breaker) Allow() error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	switch b.state {
+	case Open:
+		if b.now().Before(b.openedUntil) {
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+		b.probing = true
+		return nil
+	case HalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success reports successful call and closes breaker.
+func (b *Breaker) Success() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.failures = 0
+	b.trips = 0
+	b.probing = false
+	b.setState(Closed)
+}
+
+// Failure reports failed call, breaker opens when threshold is reached or probe fails.
+func (b *Breaker) Failure() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.failures++
+	b.probing = false
+	if b.state == Closed && b.failures < b.cfg.FailureThreshold {
+		return
+	}
+	if b.state == Open {
+		// Call allowed before breaker opened has failed too.
+		return
+	}
+	b.openedUntil = b.now().Add(b.cfg.OpenBackoff.Delay(b.trips))
+	b.trips++
+	b.setState(Open)
+}
+
+// State returns current breaker state.
+func (b *Breaker) State() State {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.state
+}
+
+// setState changes state and notifies listener. Requires mux.
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
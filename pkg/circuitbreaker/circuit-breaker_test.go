@@ -0,0 +1,60 @@
+package circuitbreaker
+
+import (
+	"go-metrics-service/pkg/timeutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	transitions := make([]string, 0)
+	b := New(
+		Config{
+			OpenBackoff:      timeutils.Backoff{Initial: time.Second, Max: 3 * time.Second, Multiplier: 2},
+			FailureThreshold: 2,
+		},
+		func(from, to State) { transitions = append(transitions, from.String()+"->"+to.String()) },
+	)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	require.NoError(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, Closed, b.State())
+	b.Failure()
+	assert.Equal(t, Open, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+	assert.Equal(t, HalfOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrOpen, "only one probe is let through")
+	b.Failure()
+	assert.Equal(t, Open, b.State())
+
+	now = now.Add(time.Second)
+	assert.ErrorIs(t, b.Allow(), ErrOpen, "open timeout grows after failed probe")
+	now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+	b.Success()
+	assert.Equal(t, Closed, b.State())
+	require.NoError(t, b.Allow())
+
+	b.Failure()
+	b.Failure()
+	now = now.Add(time.Second)
+	require.NoError(t, b.Allow(), "open timeout is reset after recovery")
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+		"closed->open",
+		"open->half-open",
+	}, transitions)
+}
@@ -0,0 +1,124 @@
+package rsahelpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encryption formats advertised in Content-Encryption header.
+const (
+	// EncryptionOAEP is a body split into RSA-OAEP encrypted blocks.
+	EncryptionOAEP = "rsa-oaep"
+	// EncryptionHybrid is an envelope of OAEP encrypted AES-256 key,
+	// GCM nonce and GCM sealed body.
+	EncryptionHybrid = "rsa-oaep+aes-256-gcm"
+)
+
+const sessionKeySize = 32
+
+var (
+	ErrUnknownEncryption = errors.New("unknown encryption")
+	ErrInvalidEnvelope   = errors.New("invalid envelope")
+	// ErrDecryption means chiper is not encrypted with the key or is corrupted.
+	ErrDecryption = errors.New("decryption failed")
+)
+
+var hybridLabel = []byte("envelope key")
+
+// HybridEncoder encrypts with public key known to server under keyID.
+type HybridEncoder struct {
+	publicKey *rsa.PublicKey
+	keyID     string
+}
+
+func NewHybridEncoder(publicPem []byte, keyID string) (*HybridEncoder, error) {
+	pub, err := parsePublicKey(publicPem)
+	if err != nil {
+		return nil, err
+	}
+	return &HybridEncoder{
+		publicKey: pub,
+		keyID:     keyID,
+	}, nil
+}
+
+func (e *HybridEncoder) Encode(plain []byte) ([]byte, error) {
+	return EncryptHybrid(rand.Reader, e.publicKey, plain)
+}
+
+func (e *HybridEncoder) Encryption() string {
+	return EncryptionHybrid
+}
+
+func (e *HybridEncoder) KeyID() string {
+	return e.keyID
+}
+
+// EncryptHybrid seals msg with random AES-256-GCM key and puts the key wrapped with OAEP before it.
+func EncryptHybrid(random io.Reader, public *rsa.PublicKey, msg []byte) ([]byte, error) {
+	key := make([]byte, sessionKeySize)
+	if _, err := io.ReadFull(random, key); err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), random, public, key, hybridLabel)
+	if err != nil {
+		return nil, fmt.Errorf("session key encryption failed: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	envelope := make([]byte, 0, len(wrappedKey)+len(nonce)+len(msg)+gcm.Overhead())
+	envelope = append(envelope, wrappedKey...)
+	envelope = append(envelope, nonce...)
+	return gcm.Seal(envelope, nonce, msg, wrappedKey), nil
+}
+
+// DecryptHybrid opens envelope created by EncryptHybrid.
+func DecryptHybrid(private *rsa.PrivateKey, envelope []byte) ([]byte, error) {
+	keySize := private.PublicKey.Size()
+	if len(envelope) < keySize {
+		return nil, ErrInvalidEnvelope
+	}
+	wrappedKey := envelope[:keySize]
+	key, err := rsa.DecryptOAEP(sha256.New(), nil, private, wrappedKey, hybridLabel)
+	if err != nil {
+		return nil, fmt.Errorf("%w: session key: %w", ErrDecryption, err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	rest := envelope[keySize:]
+	if len(rest) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, sealed := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+	return gcm, nil
+}
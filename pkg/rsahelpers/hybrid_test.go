@@ -0,0 +1,134 @@
+package rsahelpers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generatePems(t testing.TB) (privatePem, publicPem []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	prv, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: prv}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+}
+
+// writeKeyring puts private keys to files and loads keyring from them.
+func writeKeyring(t *testing.T, privatePems map[string][]byte) (*Keyring, map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	files := make(map[string]string, len(privatePems))
+	for id, privatePem := range privatePems {
+		path := filepath.Join(dir, id+".pem")
+		require.NoError(t, os.WriteFile(path, privatePem, 0o600))
+		files[id] = path
+	}
+	keyring, err := NewKeyring(files, "")
+	require.NoError(t, err)
+	return keyring, files
+}
+
+func TestDecoderFormats(t *testing.T) {
+	privatePem, publicPem := generatePems(t)
+	decoder, _ := writeKeyring(t, map[string][]byte{"k1": privatePem})
+	hybrid, err := NewHybridEncoder(publicPem, "k1")
+	require.NoError(t, err)
+	oaep, err := NewOAEPEncoder(publicPem)
+	require.NoError(t, err)
+
+	plain := bytes.Repeat([]byte("metrics batch "), 1000)
+	for _, encoder := range []interface {
+		Encode([]byte) ([]byte, error)
+		Encryption() string
+	}{hybrid, oaep} {
+		t.Run(encoder.Encryption(), func(t *testing.T) {
+			encoded, err := encoder.Encode(plain)
+			require.NoError(t, err)
+			decoded, err := decoder.Decode("k1", encoder.Encryption(), encoded)
+			require.NoError(t, err)
+			assert.Equal(t, plain, decoded)
+		})
+	}
+
+	legacy, err := oaep.Encode(plain)
+	require.NoError(t, err)
+	decoded, err := decoder.Decode("", "", legacy)
+	require.NoError(t, err)
+	assert.Equal(t, plain, decoded)
+
+	envelope, err := hybrid.Encode(plain)
+	require.NoError(t, err)
+	assert.Less(t, len(envelope), len(legacy))
+
+	_, err = decoder.Decode("k1", "rot13", envelope)
+	require.ErrorIs(t, err, ErrUnknownEncryption)
+}
+
+func TestDecryptHybridDetectsTampering(t *testing.T) {
+	privatePem, publicPem := generatePems(t)
+	decoder, _ := writeKeyring(t, map[string][]byte{"k1": privatePem})
+	encoder, err := NewHybridEncoder(publicPem, "k1")
+	require.NoError(t, err)
+
+	envelope, err := encoder.Encode([]byte("counter delta"))
+	require.NoError(t, err)
+
+	tampered := bytes.Clone(envelope)
+	tampered[len(tampered)-1] ^= 1
+	_, err = decoder.Decode("k1", EncryptionHybrid, tampered)
+	require.ErrorIs(t, err, ErrInvalidEnvelope)
+
+	_, err = decoder.Decode("k1", EncryptionHybrid, envelope[:100])
+	require.ErrorIs(t, err, ErrInvalidEnvelope)
+
+	_, err = decoder.Decode("k1", EncryptionHybrid, envelope[:len(envelope)-20])
+	require.ErrorIs(t, err, ErrInvalidEnvelope)
+
+	wrappedKey := bytes.Clone(envelope)
+	wrappedKey[0] ^= 1
+	_, err = decoder.Decode("k1", EncryptionHybrid, wrappedKey)
+	require.ErrorIs(t, err, ErrDecryption)
+
+	// Body without key ID fits no key, failures of every key are joined.
+	otherPem, _ := generatePems(t)
+	keyring, _ := writeKeyring(t, map[string][]byte{"k1": privatePem, "k2": otherPem})
+	_, err = keyring.Decode("", EncryptionOAEP, wrappedKey)
+	require.ErrorIs(t, err, ErrDecryption)
+}
+
+func BenchmarkEncode(b *testing.B) {
+	_, publicPem := generatePems(b)
+	hybrid, err := NewHybridEncoder(publicPem, "")
+	require.NoError(b, err)
+	oaep, err := NewOAEPEncoder(publicPem)
+	require.NoError(b, err)
+	plain := make([]byte, 256*1024)
+	_, err = rand.Read(plain)
+	require.NoError(b, err)
+
+	b.Run(EncryptionHybrid, func(b *testing.B) {
+		for range b.N {
+			_, err := hybrid.Encode(plain)
+			require.NoError(b, err)
+		}
+	})
+	b.Run(EncryptionOAEP, func(b *testing.B) {
+		for range b.N {
+			_, err := oaep.Encode(plain)
+			require.NoError(b, err)
+		}
+	})
+}
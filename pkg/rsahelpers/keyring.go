@@ -0,0 +1,149 @@
+package rsahelpers
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const keyFileExt = ".pem"
+
+var (
+	ErrUnknownKey   = errors.New("unknown key")
+	ErrDuplicateKey = errors.New("duplicate key id")
+)
+
+// Keyring holds private keys identified by key ID and loaded from files,
+// files are read again on Reload so keys can be rotated without restart.
+type Keyring struct {
+	mux   *sync.RWMutex
+	files map[string]string
+	dir   string
+	keys  map[string]*rsa.PrivateKey
+	ids   []string
+}
+
+// NewKeyring loads keys from files, a map of key ID to private key PEM file path,
+// and from every *.pem file of dir named by its key ID. Empty dir is not read.
+func NewKeyring(files map[string]string, dir string) (*Keyring, error) {
+	k := &Keyring{
+		mux:   &sync.RWMutex{},
+		files: files,
+		dir:   dir,
+	}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload reads every key file again, listing dir so keys can be added and removed.
+// Keys in use are kept when any file fails to load.
+func (k *Keyring) Reload() error {
+	files, err := k.keyFiles()
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]*rsa.PrivateKey, len(files))
+	for id, path := range files {
+		privatePem, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read key '%s' file '%s': %w", id, path, err)
+		}
+		prv, err := parsePrivateKey(privatePem)
+		if err != nil {
+			return fmt.Errorf("failed to parse key '%s': %w", id, err)
+		}
+		keys[id] = prv
+	}
+	ids := make([]string, 0, len(keys))
+	for id := range keys {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	k.keys = keys
+	k.ids = ids
+	return nil
+}
+
+// keyFiles returns configured key files along with ones currently found in dir.
+func (k *Keyring) keyFiles() (map[string]string, error) {
+	files := make(map[string]string, len(k.files))
+	for id, path := range k.files {
+		files[id] = path
+	}
+	if k.dir == "" {
+		return files, nil
+	}
+	entries, err := os.ReadDir(k.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys dir '%s': %w", k.dir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), keyFileExt)
+		if _, ok := files[id]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateKey, id)
+		}
+		files[id] = filepath.Join(k.dir, entry.Name())
+	}
+	return files, nil
+}
+
+// IDs returns IDs of loaded keys in sorted order.
+func (k *Keyring) IDs() []string {
+	k.mux.RLock()
+	defer k.mux.RUnlock()
+	return slices.Clone(k.ids)
+}
+
+// Decode decrypts chiper with key identified by keyID. Chiper without key ID,
+// sent by clients not aware of keyring, is decrypted with any key that fits.
+func (k *Keyring) Decode(keyID, encryption string, chiper []byte) ([]byte, error) {
+	k.mux.RLock()
+	defer k.mux.RUnlock()
+	if keyID != "" {
+		prv, ok := k.keys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+		}
+		return decode(prv, encryption, chiper)
+	}
+	errs := make([]error, 0, len(k.ids))
+	for _, id := range k.ids {
+		plain, err := decode(k.keys[id], encryption, chiper)
+		if err == nil {
+			return plain, nil
+		}
+		if errors.Is(err, ErrUnknownEncryption) {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, ErrUnknownKey
+	}
+	return nil, errors.Join(errs...)
+}
+
+func decode(prv *rsa.PrivateKey, encryption string, chiper []byte) ([]byte, error) {
+	switch encryption {
+	case "", EncryptionOAEP:
+		return DecryptOAEP(sha256.New(), prv, chiper, nil)
+	case EncryptionHybrid:
+		return DecryptHybrid(prv, chiper)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEncryption, encryption)
+	}
+}
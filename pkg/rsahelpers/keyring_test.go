@@ -0,0 +1,102 @@
+package rsahelpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	oldPrivate, oldPublic := generatePems(t)
+	newPrivate, newPublic := generatePems(t)
+	keyring, _ := writeKeyring(t, map[string][]byte{"old": oldPrivate, "new": newPrivate})
+	assert.Equal(t, []string{"new", "old"}, keyring.IDs())
+
+	plain := []byte("gauge value")
+	for _, tt := range []struct {
+		publicPem []byte
+		keyID     string
+	}{
+		{publicPem: oldPublic, keyID: "old"},
+		{publicPem: newPublic, keyID: "new"},
+	} {
+		encoder, err := NewHybridEncoder(tt.publicPem, tt.keyID)
+		require.NoError(t, err)
+		encoded, err := encoder.Encode(plain)
+		require.NoError(t, err)
+
+		decoded, err := keyring.Decode(tt.keyID, EncryptionHybrid, encoded)
+		require.NoError(t, err)
+		assert.Equal(t, plain, decoded)
+
+		// Agents not sending key ID are served by any matching key.
+		decoded, err = keyring.Decode("", EncryptionHybrid, encoded)
+		require.NoError(t, err)
+		assert.Equal(t, plain, decoded)
+	}
+
+	encoder, err := NewHybridEncoder(oldPublic, "old")
+	require.NoError(t, err)
+	encoded, err := encoder.Encode(plain)
+	require.NoError(t, err)
+	_, err = keyring.Decode("new", EncryptionHybrid, encoded)
+	require.Error(t, err)
+	_, err = keyring.Decode("missing", EncryptionHybrid, encoded)
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestKeyringReload(t *testing.T) {
+	firstPrivate, _ := generatePems(t)
+	secondPrivate, secondPublic := generatePems(t)
+	keyring, files := writeKeyring(t, map[string][]byte{"k": firstPrivate})
+
+	encoder, err := NewHybridEncoder(secondPublic, "k")
+	require.NoError(t, err)
+	encoded, err := encoder.Encode([]byte("counter delta"))
+	require.NoError(t, err)
+	_, err = keyring.Decode("k", EncryptionHybrid, encoded)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(files["k"], secondPrivate, 0o600))
+	require.NoError(t, keyring.Reload())
+	_, err = keyring.Decode("k", EncryptionHybrid, encoded)
+	require.NoError(t, err)
+
+	// Broken file does not drop keys in use.
+	require.NoError(t, os.WriteFile(files["k"], []byte("broken"), 0o600))
+	require.Error(t, keyring.Reload())
+	_, err = keyring.Decode("k", EncryptionHybrid, encoded)
+	require.NoError(t, err)
+}
+
+func TestKeyringReloadDir(t *testing.T) {
+	oldPrivate, _ := generatePems(t)
+	newPrivate, newPublic := generatePems(t)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old.pem"), oldPrivate, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a key"), 0o600))
+	keyring, err := NewKeyring(nil, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old"}, keyring.IDs())
+
+	encoder, err := NewHybridEncoder(newPublic, "new")
+	require.NoError(t, err)
+	encoded, err := encoder.Encode([]byte("gauge value"))
+	require.NoError(t, err)
+	_, err = keyring.Decode("new", EncryptionHybrid, encoded)
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	// Key put into dir is picked up on reload and removed one is dropped.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.pem"), newPrivate, 0o600))
+	require.NoError(t, os.Remove(filepath.Join(dir, "old.pem")))
+	require.NoError(t, keyring.Reload())
+	assert.Equal(t, []string{"new"}, keyring.IDs())
+	_, err = keyring.Decode("new", EncryptionHybrid, encoded)
+	require.NoError(t, err)
+
+	_, err = NewKeyring(map[string]string{"new": filepath.Join(dir, "new.pem")}, dir)
+	require.ErrorIs(t, err, ErrDuplicateKey)
+}
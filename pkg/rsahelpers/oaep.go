@@ -17,6 +17,16 @@ type OAEPDecoder struct {
 }
 
 func NewOAEPDecoder(privatePem []byte) (*OAEPDecoder, error) {
+	prv, err := parsePrivateKey(privatePem)
+	if err != nil {
+		return nil, err
+	}
+	return &OAEPDecoder{
+		privateKey: prv,
+	}, nil
+}
+
+func parsePrivateKey(privatePem []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(privatePem)
 	if block == nil || block.Type != "PRIVATE KEY" {
 		return nil, errors.New("invalid private key")
@@ -25,9 +35,11 @@ func NewOAEPDecoder(privatePem []byte) (*OAEPDecoder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid private key: %w", err)
 	}
-	return &OAEPDecoder{
-		privateKey: prv.(*rsa.PrivateKey),
-	}, nil
+	rsaPrv, ok := prv.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid private key: RSA key expected")
+	}
+	return rsaPrv, nil
 }
 
 func (d *OAEPDecoder) Decode(chiper []byte) ([]byte, error) {
@@ -47,7 +59,7 @@ func DecryptOAEP(hash hash.Hash, private *rsa.PrivateKey, msg []byte, label []by
 
 		decryptedBlockBytes, err := rsa.DecryptOAEP(hash, nil, private, msg[start:finish], label)
 		if err != nil {
-			return nil, fmt.Errorf("block decryption failed: %w", err)
+			return nil, fmt.Errorf("%w: block: %w", ErrDecryption, err)
 		}
 
 		decryptedBytes = append(decryptedBytes, decryptedBlockBytes...)
@@ -61,6 +73,16 @@ type OAEPEncoder struct {
 }
 
 func NewOAEPEncoder(publicPem []byte) (*OAEPEncoder, error) {
+	pub, err := parsePublicKey(publicPem)
+	if err != nil {
+		return nil, err
+	}
+	return &OAEPEncoder{
+		publicKey: pub,
+	}, nil
+}
+
+func parsePublicKey(publicPem []byte) (*rsa.PublicKey, error) {
 	block, _ := pem.Decode(publicPem)
 	if block == nil || block.Type != "PUBLIC KEY" {
 		return nil, errors.New("invalid public key")
@@ -69,9 +91,11 @@ func NewOAEPEncoder(publicPem []byte) (*OAEPEncoder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid public key: %w", err)
 	}
-	return &OAEPEncoder{
-		publicKey: pub.(*rsa.PublicKey),
-	}, nil
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("invalid public key: RSA key expected")
+	}
+	return rsaPub, nil
 }
 
 func (e *OAEPEncoder) Encode(plain []byte) ([]byte, error) {
@@ -79,6 +103,15 @@ func (e *OAEPEncoder) Encode(plain []byte) ([]byte, error) {
 	return EncryptOAEP(sha256.New(), rand.Reader, e.publicKey, plain, nil)
 }
 
+func (e *OAEPEncoder) Encryption() string {
+	return EncryptionOAEP
+}
+
+// KeyID is empty, server tries every key for OAEP encoded bodies.
+func (e *OAEPEncoder) KeyID() string {
+	return ""
+}
+
 func EncryptOAEP(hash hash.Hash, random io.Reader, public *rsa.PublicKey, msg []byte, label []byte) ([]byte, error) {
 	msgLen := len(msg)
 	step := public.Size() - 2*hash.Size() - 2
@@ -0,0 +1,38 @@
+package timeutils
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff produces exponentially growing delays with random jitter.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	// Jitter is the largest fraction of delay randomly cut from it, so many clients do not retry at once.
+	Jitter   float64
+	Attempts int
+}
+
+// Delay returns delay before retry following given number of failed attempts, starting from 0.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d -= d * b.Jitter * rand.Float64() //nolint:gosec // jitter does not need secure random
+	}
+	return time.Duration(d)
+}
+
+// Delays returns freshly jittered delays for every attempt, suitable for Retry.
+func (b Backoff) Delays() []time.Duration {
+	delays := make([]time.Duration, b.Attempts)
+	for i := range delays {
+		delays[i] = b.Delay(i)
+	}
+	return delays
+}
@@ -33,9 +33,9 @@ func Retry(
 		if !onFailed(err) {
 			return err
 		}
-		err = SleepCtx(ctx, delay)
-		if err != nil {
-			return err
+		// Error of the last attempt is returned once attempts are exhausted.
+		if sleepErr := SleepCtx(ctx, delay); sleepErr != nil {
+			return sleepErr
 		}
 	}
 	return err
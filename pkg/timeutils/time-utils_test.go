@@ -47,6 +47,24 @@ func ExampleRetry() {
 	// attempt #3 successed
 }
 
+func ExampleRetry_exhausted() {
+	err := Retry(
+		context.Background(),
+		[]time.Duration{time.Millisecond, time.Millisecond},
+		func(ctx context.Context) error {
+			return errors.New("test error")
+		},
+		func(err error) (needRetry bool) {
+			return true
+		},
+	)
+
+	fmt.Println(err)
+
+	// Output:
+	// test error
+}
+
 func ExampleSleepCtx() {
 	syncCh := make(chan struct{})
 	ctx, cancel := context.WithCancel(context.Background())
@@ -65,3 +83,17 @@ func ExampleSleepCtx() {
 	// Output:
 	// sleep canceled: context canceled
 }
+
+func ExampleBackoff_Delays() {
+	backoff := Backoff{
+		Initial:    time.Second,
+		Max:        5 * time.Second,
+		Multiplier: 2,
+		Attempts:   4,
+	}
+
+	fmt.Println(backoff.Delays())
+
+	// Output:
+	// [1s 2s 4s 5s]
+}
@@ -0,0 +1,66 @@
+// Package tlshelpers builds TLS configs from PEM encoded certificates and keys
+package tlshelpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+var ErrNoCertificates = errors.New("no certificates found")
+
+// NewServerConfig creates server TLS config. When clientCAPem is set
+// clients must present certificate signed by one of its CAs.
+func NewServerConfig(certPem, keyPem, clientCAPem []byte) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAPem != nil {
+		pool, err := newCertPool(clientCAPem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client CA: %w", err)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// NewClientConfig creates client TLS config. Server certificate is verified
+// against caPem or system roots when it is nil. Client certificate is presented
+// when certPem and keyPem are set.
+func NewClientConfig(caPem, certPem, keyPem []byte, serverName string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if caPem != nil {
+		pool, err := newCertPool(caPem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server CA: %w", err)
+		}
+		cfg.RootCAs = pool
+	}
+	if certPem != nil || keyPem != nil {
+		cert, err := tls.X509KeyPair(certPem, keyPem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func newCertPool(pemCerts []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemCerts) {
+		return nil, ErrNoCertificates
+	}
+	return pool, nil
+}
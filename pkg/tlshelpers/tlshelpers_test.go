@@ -0,0 +1,140 @@
+package tlshelpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyPair struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPem []byte
+	keyPem  []byte
+}
+
+// issue creates throwaway certificate signed by parent, self-signed when parent is nil.
+func issue(t *testing.T, parent *keyPair, commonName string, isCA bool) *keyPair {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		DNSNames:              []string{commonName},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return &keyPair{
+		cert:    cert,
+		key:     key,
+		certPem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPem:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+// handshake runs TLS handshake over in-memory connection.
+func handshake(serverCfg, clientCfg *tls.Config) error {
+	serverConn, clientConn := net.Pipe()
+	defer func() {
+		_ = serverConn.Close()
+		_ = clientConn.Close()
+	}()
+	serverErr := make(chan error, 1)
+	go func() {
+		server := tls.Server(serverConn, serverCfg)
+		err := server.Handshake()
+		_ = serverConn.Close()
+		serverErr <- err
+	}()
+	clientErr := tls.Client(clientConn, clientCfg).Handshake()
+	_ = clientConn.Close()
+	if err := <-serverErr; err != nil {
+		return err
+	}
+	return clientErr
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := issue(t, nil, "test-ca", true)
+	serverPair := issue(t, ca, "localhost", false)
+	clientPair := issue(t, ca, "agent", false)
+	foreignCA := issue(t, nil, "foreign-ca", true)
+	foreignClient := issue(t, foreignCA, "agent", false)
+
+	serverCfg, err := NewServerConfig(serverPair.certPem, serverPair.keyPem, ca.certPem)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		caPem      []byte
+		client     *keyPair
+		serverName string
+		wantErr    bool
+	}{
+		{name: "trusted client", caPem: ca.certPem, client: clientPair, serverName: "localhost"},
+		{name: "no client certificate", caPem: ca.certPem, serverName: "localhost", wantErr: true},
+		{name: "foreign client", caPem: ca.certPem, client: foreignClient, serverName: "localhost", wantErr: true},
+		{name: "untrusted server", caPem: foreignCA.certPem, client: clientPair, serverName: "localhost", wantErr: true},
+		{name: "wrong server name", caPem: ca.certPem, client: clientPair, serverName: "example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var certPem, keyPem []byte
+			if tt.client != nil {
+				certPem, keyPem = tt.client.certPem, tt.client.keyPem
+			}
+			clientCfg, err := NewClientConfig(tt.caPem, certPem, keyPem, tt.serverName)
+			require.NoError(t, err)
+			err = handshake(serverCfg, clientCfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestServerTLSWithoutClientVerification(t *testing.T) {
+	ca := issue(t, nil, "test-ca", true)
+	serverPair := issue(t, ca, "localhost", false)
+
+	serverCfg, err := NewServerConfig(serverPair.certPem, serverPair.keyPem, nil)
+	require.NoError(t, err)
+	clientCfg, err := NewClientConfig(ca.certPem, nil, nil, "localhost")
+	require.NoError(t, err)
+	assert.NoError(t, handshake(serverCfg, clientCfg))
+}
+
+func TestInvalidPem(t *testing.T) {
+	_, err := NewServerConfig([]byte("cert"), []byte("key"), nil)
+	require.Error(t, err)
+	_, err = NewClientConfig([]byte("ca"), nil, nil, "localhost")
+	require.ErrorIs(t, err, ErrNoCertificates)
+}
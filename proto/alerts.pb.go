@@ -0,0 +1,410 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: proto/alerts.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Alert struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name        *string                `protobuf:"bytes,1,opt,name=name"`
+	xxx_hidden_Expr        *string                `protobuf:"bytes,2,opt,name=expr"`
+	xxx_hidden_State       *string                `protobuf:"bytes,3,opt,name=state"`
+	xxx_hidden_Value       float64                `protobuf:"fixed64,4,opt,name=value"`
+	xxx_hidden_ActiveAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=active_at,json=activeAt"`
+	xxx_hidden_FiredAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=fired_at,json=firedAt"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Alert) Reset() {
+	*x = Alert{}
+	mi := &file_proto_alerts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alert) ProtoMessage() {}
+
+func (x *Alert) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_alerts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Alert) GetName() string {
+	if x != nil {
+		if x.xxx_hidden_Name != nil {
+			return *x.xxx_hidden_Name
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *Alert) GetExpr() string {
+	if x != nil {
+		if x.xxx_hidden_Expr != nil {
+			return *x.xxx_hidden_Expr
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *Alert) GetState() string {
+	if x != nil {
+		if x.xxx_hidden_State != nil {
+			return *x.xxx_hidden_State
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *Alert) GetValue() float64 {
+	if x != nil {
+		return x.xxx_hidden_Value
+	}
+	return 0
+}
+
+func (x *Alert) GetActiveAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.xxx_hidden_ActiveAt
+	}
+	return nil
+}
+
+func (x *Alert) GetFiredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.xxx_hidden_FiredAt
+	}
+	return nil
+}
+
+func (x *Alert) SetName(v string) {
+	x.xxx_hidden_Name = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 6)
+}
+
+func (x *Alert) SetExpr(v string) {
+	x.xxx_hidden_Expr = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 6)
+}
+
+func (x *Alert) SetState(v string) {
+	x.xxx_hidden_State = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 6)
+}
+
+func (x *Alert) SetValue(v float64) {
+	x.xxx_hidden_Value = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 6)
+}
+
+func (x *Alert) SetActiveAt(v *timestamppb.Timestamp) {
+	x.xxx_hidden_ActiveAt = v
+}
+
+func (x *Alert) SetFiredAt(v *timestamppb.Timestamp) {
+	x.xxx_hidden_FiredAt = v
+}
+
+func (x *Alert) HasName() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *Alert) HasExpr() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *Alert) HasState() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *Alert) HasValue() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *Alert) HasActiveAt() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_ActiveAt != nil
+}
+
+func (x *Alert) HasFiredAt() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_FiredAt != nil
+}
+
+func (x *Alert) ClearName() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Name = nil
+}
+
+func (x *Alert) ClearExpr() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Expr = nil
+}
+
+func (x *Alert) ClearState() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_State = nil
+}
+
+func (x *Alert) ClearValue() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Value = 0
+}
+
+func (x *Alert) ClearActiveAt() {
+	x.xxx_hidden_ActiveAt = nil
+}
+
+func (x *Alert) ClearFiredAt() {
+	x.xxx_hidden_FiredAt = nil
+}
+
+type Alert_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Name     *string
+	Expr     *string
+	State    *string
+	Value    *float64
+	ActiveAt *timestamppb.Timestamp
+	FiredAt  *timestamppb.Timestamp
+}
+
+func (b0 Alert_builder) Build() *Alert {
+	m0 := &Alert{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Name != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 6)
+		x.xxx_hidden_Name = b.Name
+	}
+	if b.Expr != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 6)
+		x.xxx_hidden_Expr = b.Expr
+	}
+	if b.State != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 6)
+		x.xxx_hidden_State = b.State
+	}
+	if b.Value != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 6)
+		x.xxx_hidden_Value = *b.Value
+	}
+	x.xxx_hidden_ActiveAt = b.ActiveAt
+	x.xxx_hidden_FiredAt = b.FiredAt
+	return m0
+}
+
+type ListFiringAlertsRequest struct {
+	state         protoimpl.MessageState `protogen:"opaque.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFiringAlertsRequest) Reset() {
+	*x = ListFiringAlertsRequest{}
+	mi := &file_proto_alerts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFiringAlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFiringAlertsRequest) ProtoMessage() {}
+
+func (x *ListFiringAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_alerts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+type ListFiringAlertsRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+}
+
+func (b0 ListFiringAlertsRequest_builder) Build() *ListFiringAlertsRequest {
+	m0 := &ListFiringAlertsRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	return m0
+}
+
+type ListFiringAlertsResponse struct {
+	state             protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Alerts *[]*Alert              `protobuf:"bytes,1,rep,name=alerts"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ListFiringAlertsResponse) Reset() {
+	*x = ListFiringAlertsResponse{}
+	mi := &file_proto_alerts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFiringAlertsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFiringAlertsResponse) ProtoMessage() {}
+
+func (x *ListFiringAlertsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_alerts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ListFiringAlertsResponse) GetAlerts() []*Alert {
+	if x != nil {
+		if x.xxx_hidden_Alerts != nil {
+			return *x.xxx_hidden_Alerts
+		}
+	}
+	return nil
+}
+
+func (x *ListFiringAlertsResponse) SetAlerts(v []*Alert) {
+	x.xxx_hidden_Alerts = &v
+}
+
+type ListFiringAlertsResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Alerts []*Alert
+}
+
+func (b0 ListFiringAlertsResponse_builder) Build() *ListFiringAlertsResponse {
+	m0 := &ListFiringAlertsResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Alerts = &b.Alerts
+	return m0
+}
+
+var File_proto_alerts_proto protoreflect.FileDescriptor
+
+const file_proto_alerts_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/alerts.proto\x12\bprotocol\x1a\x1fgoogle/protobuf/timestamp.proto\"\xcb\x01\n" +
+	"\x05Alert\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04expr\x18\x02 \x01(\tR\x04expr\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\x01R\x05value\x127\n" +
+	"\tactive_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bactiveAt\x125\n" +
+	"\bfired_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\afiredAt\"\x19\n" +
+	"\x17ListFiringAlertsRequest\"C\n" +
+	"\x18ListFiringAlertsResponse\x12'\n" +
+	"\x06alerts\x18\x01 \x03(\v2\x0f.protocol.AlertR\x06alerts2c\n" +
+	"\x06Alerts\x12Y\n" +
+	"\x10ListFiringAlerts\x12!.protocol.ListFiringAlertsRequest\x1a\".protocol.ListFiringAlertsResponseB Z\x1einternal/common/protocol/protob\beditionsp\xe8\a"
+
+var file_proto_alerts_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_alerts_proto_goTypes = []any{
+	(*Alert)(nil),                    // 0: protocol.Alert
+	(*ListFiringAlertsRequest)(nil),  // 1: protocol.ListFiringAlertsRequest
+	(*ListFiringAlertsResponse)(nil), // 2: protocol.ListFiringAlertsResponse
+	(*timestamppb.Timestamp)(nil),    // 3: google.protobuf.Timestamp
+}
+var file_proto_alerts_proto_depIdxs = []int32{
+	3, // 0: protocol.Alert.active_at:type_name -> google.protobuf.Timestamp
+	3, // 1: protocol.Alert.fired_at:type_name -> google.protobuf.Timestamp
+	0, // 2: protocol.ListFiringAlertsResponse.alerts:type_name -> protocol.Alert
+	1, // 3: protocol.Alerts.ListFiringAlerts:input_type -> protocol.ListFiringAlertsRequest
+	2, // 4: protocol.Alerts.ListFiringAlerts:output_type -> protocol.ListFiringAlertsResponse
+	4, // [4:5] is the sub-list for method output_type
+	3, // [3:4] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_proto_alerts_proto_init() }
+func file_proto_alerts_proto_init() {
+	if File_proto_alerts_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_alerts_proto_rawDesc), len(file_proto_alerts_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_alerts_proto_goTypes,
+		DependencyIndexes: file_proto_alerts_proto_depIdxs,
+		MessageInfos:      file_proto_alerts_proto_msgTypes,
+	}.Build()
+	File_proto_alerts_proto = out.File
+	file_proto_alerts_proto_goTypes = nil
+	file_proto_alerts_proto_depIdxs = nil
+}
@@ -23,8 +23,9 @@ const (
 type Metric_Type int32
 
 const (
-	Metric_COUNTER Metric_Type = 0
-	Metric_GAUGE   Metric_Type = 1
+	Metric_COUNTER   Metric_Type = 0
+	Metric_GAUGE     Metric_Type = 1
+	Metric_HISTOGRAM Metric_Type = 2
 )
 
 // Enum value maps for Metric_Type.
@@ -32,10 +33,12 @@ var (
 	Metric_Type_name = map[int32]string{
 		0: "COUNTER",
 		1: "GAUGE",
+		2: "HISTOGRAM",
 	}
 	Metric_Type_value = map[string]int32{
-		"COUNTER": 0,
-		"GAUGE":   1,
+		"COUNTER":   0,
+		"GAUGE":     1,
+		"HISTOGRAM": 2,
 	}
 )
 
@@ -67,6 +70,8 @@ type Metric struct {
 	xxx_hidden_Type        Metric_Type            `protobuf:"varint,2,opt,name=type,enum=protocol.Metric_Type"`
 	xxx_hidden_Delta       int64                  `protobuf:"varint,3,opt,name=delta"`
 	xxx_hidden_Value       float64                `protobuf:"fixed64,4,opt,name=value"`
+	xxx_hidden_Labels      map[string]string      `protobuf:"bytes,5,rep,name=labels" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_Histogram   *Histogram             `protobuf:"bytes,6,opt,name=histogram"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
@@ -131,24 +136,46 @@ func (x *Metric) GetValue() float64 {
 	return 0
 }
 
+func (x *Metric) GetLabels() map[string]string {
+	if x != nil {
+		return x.xxx_hidden_Labels
+	}
+	return nil
+}
+
+func (x *Metric) GetHistogram() *Histogram {
+	if x != nil {
+		return x.xxx_hidden_Histogram
+	}
+	return nil
+}
+
 func (x *Metric) SetId(v string) {
 	x.xxx_hidden_Id = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 6)
 }
 
 func (x *Metric) SetType(v Metric_Type) {
 	x.xxx_hidden_Type = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 6)
 }
 
 func (x *Metric) SetDelta(v int64) {
 	x.xxx_hidden_Delta = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 6)
 }
 
 func (x *Metric) SetValue(v float64) {
 	x.xxx_hidden_Value = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 6)
+}
+
+func (x *Metric) SetLabels(v map[string]string) {
+	x.xxx_hidden_Labels = v
+}
+
+func (x *Metric) SetHistogram(v *Histogram) {
+	x.xxx_hidden_Histogram = v
 }
 
 func (x *Metric) HasId() bool {
@@ -179,6 +206,13 @@ func (x *Metric) HasValue() bool {
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
 }
 
+func (x *Metric) HasHistogram() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Histogram != nil
+}
+
 func (x *Metric) ClearId() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
 	x.xxx_hidden_Id = nil
@@ -199,13 +233,19 @@ func (x *Metric) ClearValue() {
 	x.xxx_hidden_Value = 0
 }
 
+func (x *Metric) ClearHistogram() {
+	x.xxx_hidden_Histogram = nil
+}
+
 type Metric_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Id    *string
-	Type  *Metric_Type
-	Delta *int64
-	Value *float64
+	Id        *string
+	Type      *Metric_Type
+	Delta     *int64
+	Value     *float64
+	Labels    map[string]string
+	Histogram *Histogram
 }
 
 func (b0 Metric_builder) Build() *Metric {
@@ -213,21 +253,156 @@ func (b0 Metric_builder) Build() *Metric {
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.Id != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 6)
 		x.xxx_hidden_Id = b.Id
 	}
 	if b.Type != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 6)
 		x.xxx_hidden_Type = *b.Type
 	}
 	if b.Delta != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 6)
 		x.xxx_hidden_Delta = *b.Delta
 	}
 	if b.Value != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 6)
 		x.xxx_hidden_Value = *b.Value
 	}
+	x.xxx_hidden_Labels = b.Labels
+	x.xxx_hidden_Histogram = b.Histogram
+	return m0
+}
+
+type Histogram struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Bounds      []float64              `protobuf:"fixed64,1,rep,packed,name=bounds"`
+	xxx_hidden_Counts      []uint64               `protobuf:"varint,2,rep,packed,name=counts"`
+	xxx_hidden_Sum         float64                `protobuf:"fixed64,3,opt,name=sum"`
+	xxx_hidden_Count       uint64                 `protobuf:"varint,4,opt,name=count"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Histogram) Reset() {
+	*x = Histogram{}
+	mi := &file_proto_types_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Histogram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Histogram) ProtoMessage() {}
+
+func (x *Histogram) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_types_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Histogram) GetBounds() []float64 {
+	if x != nil {
+		return x.xxx_hidden_Bounds
+	}
+	return nil
+}
+
+func (x *Histogram) GetCounts() []uint64 {
+	if x != nil {
+		return x.xxx_hidden_Counts
+	}
+	return nil
+}
+
+func (x *Histogram) GetSum() float64 {
+	if x != nil {
+		return x.xxx_hidden_Sum
+	}
+	return 0
+}
+
+func (x *Histogram) GetCount() uint64 {
+	if x != nil {
+		return x.xxx_hidden_Count
+	}
+	return 0
+}
+
+func (x *Histogram) SetBounds(v []float64) {
+	x.xxx_hidden_Bounds = v
+}
+
+func (x *Histogram) SetCounts(v []uint64) {
+	x.xxx_hidden_Counts = v
+}
+
+func (x *Histogram) SetSum(v float64) {
+	x.xxx_hidden_Sum = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *Histogram) SetCount(v uint64) {
+	x.xxx_hidden_Count = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+}
+
+func (x *Histogram) HasSum() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *Histogram) HasCount() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *Histogram) ClearSum() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Sum = 0
+}
+
+func (x *Histogram) ClearCount() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Count = 0
+}
+
+type Histogram_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Bounds []float64
+	Counts []uint64
+	Sum    *float64
+	Count  *uint64
+}
+
+func (b0 Histogram_builder) Build() *Histogram {
+	m0 := &Histogram{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Bounds = b.Bounds
+	x.xxx_hidden_Counts = b.Counts
+	if b.Sum != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Sum = *b.Sum
+	}
+	if b.Count != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_Count = *b.Count
+	}
 	return m0
 }
 
@@ -235,29 +410,44 @@ var File_proto_types_proto protoreflect.FileDescriptor
 
 const file_proto_types_proto_rawDesc = "" +
 	"\n" +
-	"\x11proto/types.proto\x12\bprotocol\"\x8f\x01\n" +
+	"\x11proto/types.proto\x12\bprotocol\"\xc2\x02\n" +
 	"\x06Metric\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12)\n" +
 	"\x04type\x18\x02 \x01(\x0e2\x15.protocol.Metric.TypeR\x04type\x12\x14\n" +
 	"\x05delta\x18\x03 \x01(\x03R\x05delta\x12\x14\n" +
-	"\x05value\x18\x04 \x01(\x01R\x05value\"\x1e\n" +
+	"\x05value\x18\x04 \x01(\x01R\x05value\x124\n" +
+	"\x06labels\x18\x05 \x03(\v2\x1c.protocol.Metric.LabelsEntryR\x06labels\x121\n" +
+	"\thistogram\x18\x06 \x01(\v2\x13.protocol.HistogramR\thistogram\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"-\n" +
 	"\x04Type\x12\v\n" +
 	"\aCOUNTER\x10\x00\x12\t\n" +
-	"\x05GAUGE\x10\x01B Z\x1einternal/common/protocol/protob\beditionsp\xe8\a"
+	"\x05GAUGE\x10\x01\x12\r\n" +
+	"\tHISTOGRAM\x10\x02\"c\n" +
+	"\tHistogram\x12\x16\n" +
+	"\x06bounds\x18\x01 \x03(\x01R\x06bounds\x12\x16\n" +
+	"\x06counts\x18\x02 \x03(\x04R\x06counts\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x04R\x05countB Z\x1einternal/common/protocol/protob\beditionsp\xe8\a"
 
 var file_proto_types_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_types_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proto_types_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_proto_types_proto_goTypes = []any{
-	(Metric_Type)(0), // 0: protocol.Metric.Type
-	(*Metric)(nil),   // 1: protocol.Metric
+	(Metric_Type)(0),  // 0: protocol.Metric.Type
+	(*Metric)(nil),    // 1: protocol.Metric
+	(*Histogram)(nil), // 2: protocol.Histogram
+	nil,               // 3: protocol.Metric.LabelsEntry
 }
 var file_proto_types_proto_depIdxs = []int32{
 	0, // 0: protocol.Metric.type:type_name -> protocol.Metric.Type
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	3, // 1: protocol.Metric.labels:type_name -> protocol.Metric.LabelsEntry
+	2, // 2: protocol.Metric.histogram:type_name -> protocol.Histogram
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_proto_types_proto_init() }
@@ -271,7 +461,7 @@ func file_proto_types_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_types_proto_rawDesc), len(file_proto_types_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   1,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
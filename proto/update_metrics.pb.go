@@ -20,11 +20,16 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// UpdateMetricsRequest is applied once per batch_id, retried request with the
+// same batch_id is acknowledged without applying it again.
 type UpdateMetricsRequest struct {
-	state             protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Values *[]*Metric             `protobuf:"bytes,1,rep,name=values"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Values      *[]*Metric             `protobuf:"bytes,1,rep,name=values"`
+	xxx_hidden_BatchId     *string                `protobuf:"bytes,2,opt,name=batch_id,json=batchId"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
 func (x *UpdateMetricsRequest) Reset() {
@@ -61,14 +66,42 @@ func (x *UpdateMetricsRequest) GetValues() []*Metric {
 	return nil
 }
 
+func (x *UpdateMetricsRequest) GetBatchId() string {
+	if x != nil {
+		if x.xxx_hidden_BatchId != nil {
+			return *x.xxx_hidden_BatchId
+		}
+		return ""
+	}
+	return ""
+}
+
 func (x *UpdateMetricsRequest) SetValues(v []*Metric) {
 	x.xxx_hidden_Values = &v
 }
 
+func (x *UpdateMetricsRequest) SetBatchId(v string) {
+	x.xxx_hidden_BatchId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *UpdateMetricsRequest) HasBatchId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *UpdateMetricsRequest) ClearBatchId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_BatchId = nil
+}
+
 type UpdateMetricsRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Values []*Metric
+	Values  []*Metric
+	BatchId *string
 }
 
 func (b0 UpdateMetricsRequest_builder) Build() *UpdateMetricsRequest {
@@ -76,6 +109,10 @@ func (b0 UpdateMetricsRequest_builder) Build() *UpdateMetricsRequest {
 	b, x := &b0, m0
 	_, _ = b, x
 	x.xxx_hidden_Values = &b.Values
+	if b.BatchId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_BatchId = b.BatchId
+	}
 	return m0
 }
 
@@ -143,6 +180,7 @@ func (x *UpdateMetricsResponse) ClearError() {
 type UpdateMetricsResponse_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
+	// error is no longer set, failures are reported with gRPC status.
 	Error *string
 }
 
@@ -157,33 +195,457 @@ func (b0 UpdateMetricsResponse_builder) Build() *UpdateMetricsResponse {
 	return m0
 }
 
+// MetricsBatch is one report sent over updates stream.
+// Sequence identifies batch within agent and is echoed in acknowledgement.
+// Hash is HMAC of the batch with empty hash, set when signing key is configured.
+// BatchID is generated by agent and kept across resends, batch is applied once per ID.
+// Timestamp (unix milliseconds) and nonce are signed with the batch, so captured batch
+// is rejected once out of server replay window or when it was already received.
+type MetricsBatch struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Sequence    uint64                 `protobuf:"varint,1,opt,name=sequence"`
+	xxx_hidden_Values      *[]*Metric             `protobuf:"bytes,2,rep,name=values"`
+	xxx_hidden_Hash        *string                `protobuf:"bytes,3,opt,name=hash"`
+	xxx_hidden_BatchId     *string                `protobuf:"bytes,4,opt,name=batch_id,json=batchId"`
+	xxx_hidden_Timestamp   *string                `protobuf:"bytes,5,opt,name=timestamp"`
+	xxx_hidden_Nonce       *string                `protobuf:"bytes,6,opt,name=nonce"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *MetricsBatch) Reset() {
+	*x = MetricsBatch{}
+	mi := &file_proto_update_metrics_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsBatch) ProtoMessage() {}
+
+func (x *MetricsBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_update_metrics_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *MetricsBatch) GetSequence() uint64 {
+	if x != nil {
+		return x.xxx_hidden_Sequence
+	}
+	return 0
+}
+
+func (x *MetricsBatch) GetValues() []*Metric {
+	if x != nil {
+		if x.xxx_hidden_Values != nil {
+			return *x.xxx_hidden_Values
+		}
+	}
+	return nil
+}
+
+func (x *MetricsBatch) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *MetricsBatch) GetBatchId() string {
+	if x != nil {
+		if x.xxx_hidden_BatchId != nil {
+			return *x.xxx_hidden_BatchId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *MetricsBatch) GetTimestamp() string {
+	if x != nil {
+		if x.xxx_hidden_Timestamp != nil {
+			return *x.xxx_hidden_Timestamp
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *MetricsBatch) GetNonce() string {
+	if x != nil {
+		if x.xxx_hidden_Nonce != nil {
+			return *x.xxx_hidden_Nonce
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *MetricsBatch) SetSequence(v uint64) {
+	x.xxx_hidden_Sequence = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 6)
+}
+
+func (x *MetricsBatch) SetValues(v []*Metric) {
+	x.xxx_hidden_Values = &v
+}
+
+func (x *MetricsBatch) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 6)
+}
+
+func (x *MetricsBatch) SetBatchId(v string) {
+	x.xxx_hidden_BatchId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 6)
+}
+
+func (x *MetricsBatch) SetTimestamp(v string) {
+	x.xxx_hidden_Timestamp = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 6)
+}
+
+func (x *MetricsBatch) SetNonce(v string) {
+	x.xxx_hidden_Nonce = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 6)
+}
+
+func (x *MetricsBatch) HasSequence() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *MetricsBatch) HasHash() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *MetricsBatch) HasBatchId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *MetricsBatch) HasTimestamp() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *MetricsBatch) HasNonce() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
+}
+
+func (x *MetricsBatch) ClearSequence() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Sequence = 0
+}
+
+func (x *MetricsBatch) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Hash = nil
+}
+
+func (x *MetricsBatch) ClearBatchId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_BatchId = nil
+}
+
+func (x *MetricsBatch) ClearTimestamp() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_Timestamp = nil
+}
+
+func (x *MetricsBatch) ClearNonce() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 5)
+	x.xxx_hidden_Nonce = nil
+}
+
+type MetricsBatch_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Sequence  *uint64
+	Values    []*Metric
+	Hash      *string
+	BatchId   *string
+	Timestamp *string
+	Nonce     *string
+}
+
+func (b0 MetricsBatch_builder) Build() *MetricsBatch {
+	m0 := &MetricsBatch{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Sequence != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 6)
+		x.xxx_hidden_Sequence = *b.Sequence
+	}
+	x.xxx_hidden_Values = &b.Values
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 6)
+		x.xxx_hidden_Hash = b.Hash
+	}
+	if b.BatchId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 6)
+		x.xxx_hidden_BatchId = b.BatchId
+	}
+	if b.Timestamp != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 6)
+		x.xxx_hidden_Timestamp = b.Timestamp
+	}
+	if b.Nonce != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 6)
+		x.xxx_hidden_Nonce = b.Nonce
+	}
+	return m0
+}
+
+// MetricsBatchAck confirms batch was processed, error is set when it was rejected.
+// Status holds serialized google.rpc.Status with code and details of rejection.
+type MetricsBatchAck struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Sequence    uint64                 `protobuf:"varint,1,opt,name=sequence"`
+	xxx_hidden_Error       *string                `protobuf:"bytes,2,opt,name=error"`
+	xxx_hidden_Status      []byte                 `protobuf:"bytes,3,opt,name=status"`
+	xxx_hidden_Hash        *string                `protobuf:"bytes,4,opt,name=hash"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *MetricsBatchAck) Reset() {
+	*x = MetricsBatchAck{}
+	mi := &file_proto_update_metrics_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsBatchAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsBatchAck) ProtoMessage() {}
+
+func (x *MetricsBatchAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_update_metrics_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *MetricsBatchAck) GetSequence() uint64 {
+	if x != nil {
+		return x.xxx_hidden_Sequence
+	}
+	return 0
+}
+
+func (x *MetricsBatchAck) GetError() string {
+	if x != nil {
+		if x.xxx_hidden_Error != nil {
+			return *x.xxx_hidden_Error
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *MetricsBatchAck) GetStatus() []byte {
+	if x != nil {
+		return x.xxx_hidden_Status
+	}
+	return nil
+}
+
+func (x *MetricsBatchAck) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *MetricsBatchAck) SetSequence(v uint64) {
+	x.xxx_hidden_Sequence = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *MetricsBatchAck) SetError(v string) {
+	x.xxx_hidden_Error = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *MetricsBatchAck) SetStatus(v []byte) {
+	if v == nil {
+		v = []byte{}
+	}
+	x.xxx_hidden_Status = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *MetricsBatchAck) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+}
+
+func (x *MetricsBatchAck) HasSequence() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *MetricsBatchAck) HasError() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *MetricsBatchAck) HasStatus() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *MetricsBatchAck) HasHash() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *MetricsBatchAck) ClearSequence() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Sequence = 0
+}
+
+func (x *MetricsBatchAck) ClearError() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Error = nil
+}
+
+func (x *MetricsBatchAck) ClearStatus() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Status = nil
+}
+
+func (x *MetricsBatchAck) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Hash = nil
+}
+
+type MetricsBatchAck_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Sequence *uint64
+	Error    *string
+	Status   []byte
+	Hash     *string
+}
+
+func (b0 MetricsBatchAck_builder) Build() *MetricsBatchAck {
+	m0 := &MetricsBatchAck{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Sequence != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		x.xxx_hidden_Sequence = *b.Sequence
+	}
+	if b.Error != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_Error = b.Error
+	}
+	if b.Status != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Status = b.Status
+	}
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_Hash = b.Hash
+	}
+	return m0
+}
+
 var File_proto_update_metrics_proto protoreflect.FileDescriptor
 
 const file_proto_update_metrics_proto_rawDesc = "" +
 	"\n" +
-	"\x1aproto/update_metrics.proto\x12\bprotocol\x1a\x11proto/types.proto\"@\n" +
+	"\x1aproto/update_metrics.proto\x12\bprotocol\x1a\x11proto/types.proto\"[\n" +
 	"\x14UpdateMetricsRequest\x12(\n" +
-	"\x06values\x18\x01 \x03(\v2\x10.protocol.MetricR\x06values\"-\n" +
+	"\x06values\x18\x01 \x03(\v2\x10.protocol.MetricR\x06values\x12\x19\n" +
+	"\bbatch_id\x18\x02 \x01(\tR\abatchId\"-\n" +
 	"\x15UpdateMetricsResponse\x12\x14\n" +
-	"\x05error\x18\x01 \x01(\tR\x05error2a\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\"\xb7\x01\n" +
+	"\fMetricsBatch\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12(\n" +
+	"\x06values\x18\x02 \x03(\v2\x10.protocol.MetricR\x06values\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12\x19\n" +
+	"\bbatch_id\x18\x04 \x01(\tR\abatchId\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\tR\ttimestamp\x12\x14\n" +
+	"\x05nonce\x18\x06 \x01(\tR\x05nonce\"o\n" +
+	"\x0fMetricsBatchAck\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\fR\x06status\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash2\xa9\x01\n" +
 	"\rUpdateMetrics\x12P\n" +
-	"\rUpdateMetrics\x12\x1e.protocol.UpdateMetricsRequest\x1a\x1f.protocol.UpdateMetricsResponseB Z\x1einternal/common/protocol/protob\beditionsp\xe8\a"
+	"\rUpdateMetrics\x12\x1e.protocol.UpdateMetricsRequest\x1a\x1f.protocol.UpdateMetricsResponse\x12F\n" +
+	"\rStreamUpdates\x12\x16.protocol.MetricsBatch\x1a\x19.protocol.MetricsBatchAck(\x010\x01B Z\x1einternal/common/protocol/protob\beditionsp\xe8\a"
 
-var file_proto_update_metrics_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_update_metrics_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_proto_update_metrics_proto_goTypes = []any{
 	(*UpdateMetricsRequest)(nil),  // 0: protocol.UpdateMetricsRequest
 	(*UpdateMetricsResponse)(nil), // 1: protocol.UpdateMetricsResponse
-	(*Metric)(nil),                // 2: protocol.Metric
+	(*MetricsBatch)(nil),          // 2: protocol.MetricsBatch
+	(*MetricsBatchAck)(nil),       // 3: protocol.MetricsBatchAck
+	(*Metric)(nil),                // 4: protocol.Metric
 }
 var file_proto_update_metrics_proto_depIdxs = []int32{
-	2, // 0: protocol.UpdateMetricsRequest.values:type_name -> protocol.Metric
-	0, // 1: protocol.UpdateMetrics.UpdateMetrics:input_type -> protocol.UpdateMetricsRequest
-	1, // 2: protocol.UpdateMetrics.UpdateMetrics:output_type -> protocol.UpdateMetricsResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	4, // 0: protocol.UpdateMetricsRequest.values:type_name -> protocol.Metric
+	4, // 1: protocol.MetricsBatch.values:type_name -> protocol.Metric
+	0, // 2: protocol.UpdateMetrics.UpdateMetrics:input_type -> protocol.UpdateMetricsRequest
+	2, // 3: protocol.UpdateMetrics.StreamUpdates:input_type -> protocol.MetricsBatch
+	1, // 4: protocol.UpdateMetrics.UpdateMetrics:output_type -> protocol.UpdateMetricsResponse
+	3, // 5: protocol.UpdateMetrics.StreamUpdates:output_type -> protocol.MetricsBatchAck
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_proto_update_metrics_proto_init() }
@@ -198,7 +660,7 @@ func file_proto_update_metrics_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_update_metrics_proto_rawDesc), len(file_proto_update_metrics_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -20,6 +20,7 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	UpdateMetrics_UpdateMetrics_FullMethodName = "/protocol.UpdateMetrics/UpdateMetrics"
+	UpdateMetrics_StreamUpdates_FullMethodName = "/protocol.UpdateMetrics/StreamUpdates"
 )
 
 // UpdateMetricsClient is the client API for UpdateMetrics service.
@@ -27,6 +28,9 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type UpdateMetricsClient interface {
 	UpdateMetrics(ctx context.Context, in *UpdateMetricsRequest, opts ...grpc.CallOption) (*UpdateMetricsResponse, error)
+	// StreamUpdates keeps one long-lived stream per agent, batches are applied
+	// and acknowledged in the order they are received.
+	StreamUpdates(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MetricsBatch, MetricsBatchAck], error)
 }
 
 type updateMetricsClient struct {
@@ -47,11 +51,27 @@ func (c *updateMetricsClient) UpdateMetrics(ctx context.Context, in *UpdateMetri
 	return out, nil
 }
 
+func (c *updateMetricsClient) StreamUpdates(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MetricsBatch, MetricsBatchAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UpdateMetrics_ServiceDesc.Streams[0], UpdateMetrics_StreamUpdates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MetricsBatch, MetricsBatchAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UpdateMetrics_StreamUpdatesClient = grpc.BidiStreamingClient[MetricsBatch, MetricsBatchAck]
+
 // UpdateMetricsServer is the server API for UpdateMetrics service.
 // All implementations must embed UnimplementedUpdateMetricsServer
 // for forward compatibility.
 type UpdateMetricsServer interface {
 	UpdateMetrics(context.Context, *UpdateMetricsRequest) (*UpdateMetricsResponse, error)
+	// StreamUpdates keeps one long-lived stream per agent, batches are applied
+	// and acknowledged in the order they are received.
+	StreamUpdates(grpc.BidiStreamingServer[MetricsBatch, MetricsBatchAck]) error
 	mustEmbedUnimplementedUpdateMetricsServer()
 }
 
@@ -65,6 +85,9 @@ type UnimplementedUpdateMetricsServer struct{}
 func (UnimplementedUpdateMetricsServer) UpdateMetrics(context.Context, *UpdateMetricsRequest) (*UpdateMetricsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateMetrics not implemented")
 }
+func (UnimplementedUpdateMetricsServer) StreamUpdates(grpc.BidiStreamingServer[MetricsBatch, MetricsBatchAck]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamUpdates not implemented")
+}
 func (UnimplementedUpdateMetricsServer) mustEmbedUnimplementedUpdateMetricsServer() {}
 func (UnimplementedUpdateMetricsServer) testEmbeddedByValue()                       {}
 
@@ -104,6 +127,13 @@ func _UpdateMetrics_UpdateMetrics_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UpdateMetrics_StreamUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(UpdateMetricsServer).StreamUpdates(&grpc.GenericServerStream[MetricsBatch, MetricsBatchAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UpdateMetrics_StreamUpdatesServer = grpc.BidiStreamingServer[MetricsBatch, MetricsBatchAck]
+
 // UpdateMetrics_ServiceDesc is the grpc.ServiceDesc for UpdateMetrics service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -116,6 +146,13 @@ var UpdateMetrics_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _UpdateMetrics_UpdateMetrics_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamUpdates",
+			Handler:       _UpdateMetrics_StreamUpdates_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "proto/update_metrics.proto",
 }